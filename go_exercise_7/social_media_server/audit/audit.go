@@ -0,0 +1,26 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"social_media_server/models"
+
+	"gorm.io/gorm"
+)
+
+// Snapshot chụp trạng thái tài nguyên dưới dạng JSON; nil cho ra null
+func Snapshot(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// Record ghi một bản ghi audit. Bảng audit_logs chỉ được thêm, không có thao tác sửa/xoá nào.
+func Record(ctx context.Context, db *gorm.DB, entry *models.AuditLog) error {
+	return db.WithContext(ctx).Create(entry).Error
+}
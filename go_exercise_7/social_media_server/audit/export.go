@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"social_media_server/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+
+	exportBatchSize = 500
+)
+
+var csvHeader = []string{"id", "created_at", "actor_id", "action", "resource_type", "resource_id", "before", "after", "request_id", "ip", "user_agent", "method", "path"}
+
+func ValidFormat(format string) bool {
+	return format == FormatNDJSON || format == FormatCSV
+}
+
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Export stream các bản ghi khớp query ra w theo thứ tự id tăng dần. Trả về số bản ghi đã ghi.
+func Export(ctx context.Context, query *gorm.DB, w io.Writer, format string) (int, error) {
+	if !ValidFormat(format) {
+		return 0, fmt.Errorf("audit: unknown format %q", format)
+	}
+
+	bw := bufio.NewWriter(w)
+	var cw *csv.Writer
+	if format == FormatCSV {
+		cw = csv.NewWriter(bw)
+		if err := cw.Write(csvHeader); err != nil {
+			return 0, err
+		}
+	}
+	enc := json.NewEncoder(bw)
+
+	count := 0
+	var entries []models.AuditLog
+	result := query.WithContext(ctx).Order("id ASC").
+		FindInBatches(&entries, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, e := range entries {
+				if cw != nil {
+					if err := cw.Write(csvRow(e)); err != nil {
+						return err
+					}
+				} else if err := enc.Encode(e); err != nil {
+					return err
+				}
+				count++
+			}
+			if cw != nil {
+				cw.Flush()
+				if err := cw.Error(); err != nil {
+					return err
+				}
+			}
+			return bw.Flush()
+		})
+	if result.Error != nil {
+		return count, result.Error
+	}
+	return count, bw.Flush()
+}
+
+func csvRow(e models.AuditLog) []string {
+	actor := ""
+	if e.ActorID != nil {
+		actor = strconv.FormatUint(uint64(*e.ActorID), 10)
+	}
+	return []string{
+		strconv.FormatUint(uint64(e.ID), 10),
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		actor,
+		e.Action,
+		e.ResourceType,
+		strconv.FormatUint(uint64(e.ResourceID), 10),
+		string(e.Before),
+		string(e.After),
+		e.RequestID,
+		e.IP,
+		e.UserAgent,
+		e.Method,
+		e.Path,
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"os"
+	"social_media_server/contentcheck"
+)
+
+var ContentCheck *contentcheck.Pipeline
+
+// SetupContentCheck nạp bộ lọc nội dung từ file JSON trong CONTENT_FILTER_FILE.
+// Không cấu hình thì pipeline rỗng và mọi nội dung đều được chấp nhận như trước.
+func SetupContentCheck() {
+	path := os.Getenv("CONTENT_FILTER_FILE")
+	if path == "" {
+		ContentCheck = contentcheck.New()
+		log.Println("Content filter disabled (CONTENT_FILTER_FILE not set)")
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Failed to open content filter config: %v", err)
+	}
+	defer f.Close()
+
+	cfg, err := contentcheck.LoadConfig(f)
+	if err != nil {
+		log.Fatalf("Failed to load content filter config: %v", err)
+	}
+	ContentCheck, err = cfg.Pipeline(DB)
+	if err != nil {
+		log.Fatalf("Failed to build content filter: %v", err)
+	}
+	log.Printf("Content filter loaded from %s", path)
+}
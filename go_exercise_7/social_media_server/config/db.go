@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"social_media_server/store"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var DB *gorm.DB 
+
+// ReadDB dùng cho các endpoint danh sách, nơi dữ liệu trễ một chút so với primary là chấp nhận
+// được. Trỏ tới read replica khi có DB_REPLICA_URLS, nếu không thì chính là DB.
+var ReadDB *gorm.DB
+
+var (
+	DBDriver     string
+	ReplicaCount int
+)
+
+// ConnectDB mở database (xem OpenDB) rồi migrate schema, trừ khi DB_AUTO_MIGRATE=false để việc
+// migrate được chạy riêng bằng lệnh "migrate" trước khi triển khai
+func ConnectDB() {
+	OpenDB()
+	if v := os.Getenv("DB_AUTO_MIGRATE"); v == "" || parseBoolEnv("DB_AUTO_MIGRATE", v) {
+		MigrateDB()
+	}
+}
+
+// OpenDB mở database theo DB_DRIVER (mysql, postgres hoặc sqlite; mặc định mysql) và DB_URL,
+// với mysql vẫn đọc MYSQL_URL nếu DB_URL chưa được đặt. Kết nối được thử lại với backoff trước
+// khi bỏ cuộc; DB_REPLICA_URLS (phân cách bằng dấu phẩy, cùng driver) bật đọc từ replica.
+func OpenDB() {
+	if err := TryOpenDB(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// TryOpenDB giống OpenDB nhưng trả lỗi thay vì dừng chương trình, dùng cho lệnh "doctor"
+func TryOpenDB(ctx context.Context) error {
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		driver = store.MySQL
+	}
+	dsn := os.Getenv("DB_URL")
+	if dsn == "" && driver == store.MySQL {
+		dsn = os.Getenv("MYSQL_URL")
+	}
+	if dsn == "" {
+		return errors.New("DB_URL environment variable not set")
+	}
+
+	opts := dbOptions()
+	database, err := store.Connect(ctx, driver, dsn, opts)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	log.Printf("Database connection successful (%s)!", driver)
+
+	DB = database
+	DBDriver = driver
+	ReadDB = database
+	ReplicaCount = 0
+	if replicas := splitList(os.Getenv("DB_REPLICA_URLS")); len(replicas) > 0 {
+		ReadDB, err = store.ConnectReplicas(ctx, driver, replicas, opts)
+		if err != nil {
+			ReadDB = database
+			return fmt.Errorf("failed to connect to read replicas: %w", err)
+		}
+		ReplicaCount = len(replicas)
+		log.Printf("Listing endpoints read from %d replica(s)", len(replicas))
+	}
+	return nil
+}
+
+// MigrateDB tạo hoặc cập nhật schema trên primary
+func MigrateDB() {
+	if err := store.Migrate(DB); err != nil {
+		log.Fatal("Failed to migrate database schema:", err)
+	}
+	log.Println("Database migration successful!")
+}
+
+// dbOptions đọc cấu hình pool và kết nối; biến nào không đặt thì giữ giá trị của store.DefaultOptions:
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME, DB_QUERY_TIMEOUT ("0" để tắt),
+// DB_CONNECT_ATTEMPTS và DB_CONNECT_BACKOFF (thời gian chờ trước lần thử lại đầu tiên)
+func dbOptions() store.Options {
+	opts := store.DefaultOptions
+	intEnv("DB_MAX_OPEN_CONNS", &opts.Pool.MaxOpenConns)
+	intEnv("DB_MAX_IDLE_CONNS", &opts.Pool.MaxIdleConns)
+	durationEnv("DB_CONN_MAX_LIFETIME", &opts.Pool.ConnMaxLifetime)
+	durationEnv("DB_QUERY_TIMEOUT", &opts.QueryTimeout)
+	intEnv("DB_CONNECT_ATTEMPTS", &opts.ConnectAttempts)
+	durationEnv("DB_CONNECT_BACKOFF", &opts.ConnectBackoff)
+	return opts
+}
+
+func intEnv(name string, dst *int) {
+	if v := os.Getenv(name); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("Invalid %s %q", name, v)
+		}
+		*dst = n
+	}
+}
+
+func durationEnv(name string, dst *time.Duration) {
+	if v := os.Getenv(name); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid %s %q", name, v)
+		}
+		*dst = d
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"os"
+	"social_media_server/feed"
+	"strconv"
+)
+
+var Timeline feed.Timeline
+
+// SetupFeed chọn chiến lược home feed qua FEED_STRATEGY:
+// "read" (mặc định) query DB mỗi lần đọc, "write" đẩy post mới vào timeline trong Redis.
+func SetupFeed() {
+	strategy := os.Getenv("FEED_STRATEGY")
+	if strategy == "" {
+		strategy = "read"
+	}
+
+	switch strategy {
+	case "read":
+		Timeline = feed.NewReadTimeline(ReadDB)
+	case "write":
+		if RDB == nil {
+			ConnectRedis()
+		}
+		maxLen, err := strconv.ParseInt(os.Getenv("FEED_TIMELINE_LENGTH"), 10, 64)
+		if err != nil || maxLen <= 0 {
+			maxLen = feed.DefaultTimelineLength
+		}
+		Timeline = feed.NewRedisTimeline(DB, RDB, maxLen)
+	default:
+		log.Fatalf("Unknown FEED_STRATEGY %q (expected read or write)", strategy)
+	}
+	log.Printf("Home feed strategy: fan-out-on-%s", strategy)
+}
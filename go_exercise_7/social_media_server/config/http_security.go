@@ -0,0 +1,185 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Các profile theo APP_ENV; mỗi giá trị mặc định của profile có thể ghi đè bằng biến môi trường riêng
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+const (
+	// API chỉ trả JSON/file nên không cần tải tài nguyên nào
+	defaultAPIContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	// Swagger UI dùng script/style inline và ảnh data: cho logo
+	defaultSwaggerContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+)
+
+// HTTPSecurity gom cấu hình CORS và các security header cho router
+type HTTPSecurity struct {
+	Env string
+
+	// AllowOrigins có thể chứa "*" (mọi origin) hoặc pattern subdomain như "https://*.example.com"
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	AllowCredentials bool
+	CORSMaxAge       time.Duration
+
+	HSTSMaxAge            time.Duration // 0: không gửi Strict-Transport-Security
+	HSTSIncludeSubdomains bool
+	ReferrerPolicy        string
+	ContentSecurityPolicy string
+	SwaggerSecurityPolicy string
+}
+
+func defaultHTTPSecurity(env string) HTTPSecurity {
+	s := HTTPSecurity{
+		Env:                   env,
+		AllowMethods:          []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:          []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowCredentials:      true,
+		CORSMaxAge:            12 * time.Hour,
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentSecurityPolicy: defaultAPIContentSecurityPolicy,
+		SwaggerSecurityPolicy: defaultSwaggerContentSecurityPolicy,
+	}
+	switch env {
+	case EnvDevelopment:
+		s.AllowOrigins = []string{"http://localhost:5173"}
+	case EnvStaging:
+		// HSTS ngắn để staging có thể quay về HTTP nếu cần
+		s.HSTSMaxAge = 24 * time.Hour
+	case EnvProduction:
+		s.HSTSMaxAge = 365 * 24 * time.Hour
+		s.HSTSIncludeSubdomains = true
+		s.ReferrerPolicy = "no-referrer"
+	}
+	return s
+}
+
+// LoadHTTPSecurity đọc profile APP_ENV (development mặc định) rồi áp các biến ghi đè:
+// CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS (danh sách phân tách bởi dấu phẩy),
+// CORS_ALLOW_CREDENTIALS, CORS_MAX_AGE, HSTS_MAX_AGE ("0" để tắt), HSTS_INCLUDE_SUBDOMAINS,
+// REFERRER_POLICY, CONTENT_SECURITY_POLICY và SWAGGER_CONTENT_SECURITY_POLICY.
+func LoadHTTPSecurity() HTTPSecurity {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = EnvDevelopment
+	}
+	if env != EnvDevelopment && env != EnvStaging && env != EnvProduction {
+		log.Fatalf("Unknown APP_ENV %q (expected development, staging or production)", env)
+	}
+	s := defaultHTTPSecurity(env)
+
+	if v, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok {
+		s.AllowOrigins = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOWED_METHODS"); v != "" {
+		s.AllowMethods = splitList(strings.ToUpper(v))
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		s.AllowHeaders = splitList(v)
+	}
+	if v := os.Getenv("CORS_ALLOW_CREDENTIALS"); v != "" {
+		s.AllowCredentials = parseBoolEnv("CORS_ALLOW_CREDENTIALS", v)
+	}
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		s.CORSMaxAge = parseDurationEnv("CORS_MAX_AGE", v)
+	}
+	if v := os.Getenv("HSTS_MAX_AGE"); v != "" {
+		s.HSTSMaxAge = parseDurationEnv("HSTS_MAX_AGE", v)
+	}
+	if v := os.Getenv("HSTS_INCLUDE_SUBDOMAINS"); v != "" {
+		s.HSTSIncludeSubdomains = parseBoolEnv("HSTS_INCLUDE_SUBDOMAINS", v)
+	}
+	if v, ok := os.LookupEnv("REFERRER_POLICY"); ok {
+		s.ReferrerPolicy = v
+	}
+	if v, ok := os.LookupEnv("CONTENT_SECURITY_POLICY"); ok {
+		s.ContentSecurityPolicy = v
+	}
+	if v, ok := os.LookupEnv("SWAGGER_CONTENT_SECURITY_POLICY"); ok {
+		s.SwaggerSecurityPolicy = v
+	}
+
+	for _, origin := range s.AllowOrigins {
+		if origin == "*" && s.AllowCredentials {
+			log.Fatal("CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+		}
+		if strings.Count(origin, "*") > 1 || (origin != "*" && strings.Contains(origin, "*") && !strings.Contains(origin, "://*.")) {
+			log.Fatalf("Invalid CORS origin pattern %q (wildcards are only allowed as a leading subdomain, e.g. https://*.example.com)", origin)
+		}
+	}
+	if len(s.AllowOrigins) == 0 && env != EnvDevelopment {
+		log.Printf("CORS_ALLOWED_ORIGINS is empty: cross-origin browser requests are rejected")
+	}
+	return s
+}
+
+// AllowAllOrigins: "*" trong danh sách origin
+func (s HTTPSecurity) AllowAllOrigins() bool {
+	for _, origin := range s.AllowOrigins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// OriginAllowed so khớp origin với danh sách, "https://*.example.com" khớp mọi subdomain
+// (mọi cấp) của example.com cùng scheme nhưng không khớp chính example.com
+func (s HTTPSecurity) OriginAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range s.AllowOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		i := strings.Index(allowed, "://*.")
+		if i < 0 {
+			continue
+		}
+		scheme, suffix := allowed[:i+len("://")], allowed[i+len("://*"):]
+		if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, suffix) && len(origin) > len(scheme)+len(suffix) {
+			host := origin[len(scheme) : len(origin)-len(suffix)]
+			if !strings.ContainsAny(host, "/?#@") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseBoolEnv(key, v string) bool {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q", key, v)
+	}
+	return b
+}
+
+func parseDurationEnv(key, v string) time.Duration {
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Fatalf("Invalid %s %q", key, v)
+	}
+	return d
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"log"
+	"os"
+	"social_media_server/idempotency"
+	"time"
+)
+
+var (
+	Idempotency    idempotency.Store
+	IdempotencyTTL = idempotency.DefaultTTL
+)
+
+// SetupIdempotency chọn nơi lưu Idempotency-Key qua IDEMPOTENCY_STORE: "db" (mặc định) hoặc "redis".
+// IDEMPOTENCY_TTL là thời gian giữ response đã lưu (mặc định 24h).
+func SetupIdempotency() {
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_TTL %q", v)
+		}
+		IdempotencyTTL = ttl
+	}
+
+	storeName := os.Getenv("IDEMPOTENCY_STORE")
+	if storeName == "" {
+		storeName = "db"
+	}
+	switch storeName {
+	case "db":
+		Idempotency = idempotency.NewDBStore(DB)
+	case "redis":
+		if RDB == nil {
+			ConnectRedis()
+		}
+		Idempotency = idempotency.NewRedisStore(RDB)
+	default:
+		log.Fatalf("Unknown IDEMPOTENCY_STORE %q (expected db or redis)", storeName)
+	}
+	log.Printf("Idempotency keys stored in %s for %s", storeName, IdempotencyTTL)
+}
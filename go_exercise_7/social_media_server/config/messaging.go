@@ -0,0 +1,31 @@
+package config
+
+import (
+	"encoding/base64"
+	"log"
+	"os"
+	"social_media_server/messaging"
+)
+
+// MessageCipher mã hoá nội dung tin nhắn khi lưu; nil thì lưu nguyên văn
+var MessageCipher *messaging.Cipher
+
+// SetupMessaging bật mã hoá tin nhắn khi MESSAGE_ENCRYPTION_KEY có giá trị (32 byte mã hoá base64).
+// Tin nhắn đã mã hoá cần đúng key này để đọc lại, kể cả khi sau đó tắt mã hoá.
+func SetupMessaging() {
+	v := os.Getenv("MESSAGE_ENCRYPTION_KEY")
+	if v == "" {
+		log.Println("MESSAGE_ENCRYPTION_KEY not set in .env, direct messages are stored unencrypted")
+		return
+	}
+	key, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		log.Fatalf("Invalid MESSAGE_ENCRYPTION_KEY: must be base64 encoded")
+	}
+	cipher, err := messaging.NewCipher(key)
+	if err != nil {
+		log.Fatalf("Invalid MESSAGE_ENCRYPTION_KEY: %v", err)
+	}
+	MessageCipher = cipher
+	log.Println("Direct messages are encrypted at rest")
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"log"
+	"os"
+	"social_media_server/models"
+	"social_media_server/moderation"
+	"strconv"
+)
+
+var Moderator *moderation.Moderator
+
+// SetupModeration (gọi sau ConnectStorage) đọc REPORT_HIDE_THRESHOLD và cấp quyền admin cho các username trong ADMIN_USERNAMES
+func SetupModeration() {
+	threshold := moderation.DefaultHideThreshold
+	if v := os.Getenv("REPORT_HIDE_THRESHOLD"); v != "" {
+		t, err := strconv.Atoi(v)
+		if err != nil || t <= 0 {
+			log.Fatalf("Invalid REPORT_HIDE_THRESHOLD %q", v)
+		}
+		threshold = t
+	}
+	Moderator = moderation.New(DB, Storage, threshold)
+
+	if v := os.Getenv("ADMIN_USERNAMES"); v != "" {
+		if err := DB.Model(&models.User{}).Where("username IN ?", splitList(v)).Update("role", models.RoleAdmin).Error; err != nil {
+			log.Fatalf("Failed to grant admin role: %v", err)
+		}
+	}
+	log.Printf("Content reported by %d users is hidden automatically", threshold)
+}
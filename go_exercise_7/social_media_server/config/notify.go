@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"social_media_server/notify"
+)
+
+var Notifier *notify.Notifier
+
+// SetupNotifications chọn broker realtime qua NOTIFICATION_BROKER:
+// "memory" (mặc định) cho một instance, "redis" khi chạy nhiều instance sau load balancer.
+func SetupNotifications() {
+	brokerName := os.Getenv("NOTIFICATION_BROKER")
+	if brokerName == "" {
+		brokerName = "memory"
+	}
+
+	var broker notify.Broker
+	switch brokerName {
+	case "memory":
+		broker = notify.NewHub()
+	case "redis":
+		if RDB == nil {
+			ConnectRedis()
+		}
+		broker = notify.NewRedisBroker(context.Background(), RDB)
+	default:
+		log.Fatalf("Unknown NOTIFICATION_BROKER %q (expected memory or redis)", brokerName)
+	}
+
+	Notifier = notify.NewNotifier(DB, broker)
+	log.Printf("Notification broker: %s", brokerName)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"social_media_server/ranking"
+	"time"
+)
+
+// StartRanking chạy nền việc tính lại điểm hot/top của post mỗi RANKING_INTERVAL (mặc
+// định 5m, "0" để tắt trên instance này). SCHEDULER_LOCK=redis cũng áp dụng cho việc này.
+func StartRanking(ctx context.Context) {
+	interval := ranking.DefaultInterval
+	if v := os.Getenv("RANKING_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid RANKING_INTERVAL %q", v)
+		}
+		if d == 0 {
+			log.Println("Post ranking refresh is disabled on this instance")
+			return
+		}
+		interval = d
+	}
+
+	refresher := ranking.NewRefresher(DB, interval, schedulerLocker())
+	go refresher.Run(ctx)
+	log.Printf("Refreshing post rankings every %s", interval)
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"log"
+	"os"
+	"unicode/utf8"
+)
+
+// ReactionEmojis là danh sách emoji được phép dùng làm reaction
+var ReactionEmojis = []string{"👍", "❤️", "😂", "😮", "😢", "😡", "🎉"}
+
+// SetupReactions đọc REACTION_EMOJIS (danh sách emoji phân tách bởi dấu phẩy) nếu có
+func SetupReactions() {
+	v := os.Getenv("REACTION_EMOJIS")
+	if v == "" {
+		return
+	}
+	emojis := splitList(v)
+	if len(emojis) == 0 {
+		log.Fatal("REACTION_EMOJIS must contain at least one emoji")
+	}
+	for _, emoji := range emojis {
+		if len(emoji) > 32 || utf8.RuneCountInString(emoji) > 8 {
+			log.Fatalf("Invalid reaction emoji %q in REACTION_EMOJIS", emoji)
+		}
+	}
+	ReactionEmojis = emojis
+}
+
+func ValidReactionEmoji(emoji string) bool {
+	for _, allowed := range ReactionEmojis {
+		if emoji == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -17,6 +17,17 @@ var (
 )
 
 func ConnectRedis() {
+	RDB = NewRedisClient()
+
+	pong, err := RDB.Ping(Ctx).Result()
+	if err != nil {
+		log.Fatalf("Could not connect to Redis: %v", err)
+	}
+	fmt.Printf("Connected to Redis successfully! Ping response: %s\n", pong)
+}
+
+// NewRedisClient tạo client theo REDIS_ADDR, REDIS_PASSWORD, REDIS_DB và REDIS_USE_TLS, chưa kết nối
+func NewRedisClient() *redis.Client {
 	redisAddr := os.Getenv("REDIS_ADDR")
 	if redisAddr == "" {
 		redisAddr = "127.0.0.1:6379"
@@ -51,11 +62,13 @@ func ConnectRedis() {
 	}
 
 
-	RDB = redis.NewClient(redisOptions)
+	return redis.NewClient(redisOptions)
+}
 
-	pong, err := RDB.Ping(Ctx).Result()
-	if err != nil {
-		log.Fatalf("Could not connect to Redis: %v", err)
-	}
-	fmt.Printf("Connected to Redis successfully! Ping response: %s\n", pong)
+// RedisInUse cho biết cấu hình hiện tại có tính năng nào cần Redis không
+func RedisInUse() bool {
+	return os.Getenv("FEED_STRATEGY") == "write" ||
+		os.Getenv("NOTIFICATION_BROKER") == "redis" ||
+		os.Getenv("IDEMPOTENCY_STORE") == "redis" ||
+		os.Getenv("SCHEDULER_LOCK") == "redis"
 }
\ No newline at end of file
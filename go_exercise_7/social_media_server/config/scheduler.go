@@ -0,0 +1,74 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"social_media_server/audit"
+	"social_media_server/models"
+	"social_media_server/ranking"
+	"social_media_server/scheduler"
+	"time"
+)
+
+// StartScheduler chạy nền việc xuất bản post hẹn giờ, quét mỗi SCHEDULER_INTERVAL (mặc định 30s,
+// "0" để tắt trên instance này). SCHEDULER_LOCK=redis dùng khoá Redis để mỗi lượt chỉ một
+// instance quét; không có khoá thì mỗi post vẫn chỉ được xuất bản một lần.
+func StartScheduler(ctx context.Context) {
+	interval := scheduler.DefaultInterval
+	if v := os.Getenv("SCHEDULER_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid SCHEDULER_INTERVAL %q", v)
+		}
+		if d == 0 {
+			log.Println("Scheduled publishing is disabled on this instance")
+			return
+		}
+		interval = d
+	}
+
+	publisher := scheduler.NewPublisher(DB, interval, schedulerLocker(), scheduledPostPublished)
+	go publisher.Run(ctx)
+	log.Printf("Publishing scheduled posts every %s", interval)
+}
+
+// schedulerLocker tạo khoá theo SCHEDULER_LOCK, dùng chung cho các việc chạy nền định kỳ
+func schedulerLocker() scheduler.Locker {
+	switch lock := os.Getenv("SCHEDULER_LOCK"); lock {
+	case "", "none":
+		return nil
+	case "redis":
+		if RDB == nil {
+			ConnectRedis()
+		}
+		return scheduler.NewRedisLocker(RDB)
+	default:
+		log.Fatalf("Unknown SCHEDULER_LOCK %q (expected none or redis)", lock)
+		return nil
+	}
+}
+
+// scheduledPostPublished làm những việc CreatePost làm khi xuất bản: đẩy vào feed, gửi thông báo,
+// tính điểm xếp hạng và ghi audit log (không có người thực hiện)
+func scheduledPostPublished(ctx context.Context, before, after *models.Post) {
+	if err := Timeline.PostCreated(ctx, after); err != nil {
+		log.Printf("Failed to fan out post %d: %v", after.ID, err)
+	}
+	if err := Notifier.PostCreated(ctx, after); err != nil {
+		log.Printf("Failed to send notifications for post %d: %v", after.ID, err)
+	}
+	if err := ranking.Refresh(DB.WithContext(ctx), []uint{after.ID}); err != nil {
+		log.Printf("Failed to score post %d: %v", after.ID, err)
+	}
+	entry := models.AuditLog{
+		Action:       models.AuditUpdate,
+		ResourceType: models.TargetPost,
+		ResourceID:   after.ID,
+		Before:       audit.Snapshot(before),
+		After:        audit.Snapshot(after),
+	}
+	if err := audit.Record(ctx, DB, &entry); err != nil {
+		log.Printf("Failed to write audit log for scheduled post %d: %v", after.ID, err)
+	}
+}
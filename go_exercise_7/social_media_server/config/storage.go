@@ -0,0 +1,97 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"os"
+	"social_media_server/storage"
+	"strconv"
+	"time"
+)
+
+var (
+	Storage           storage.BlobStore
+	URLSigner         *storage.URLSigner
+	MaxAttachmentSize int64         = 10 << 20
+	AttachmentURLTTL  time.Duration = 15 * time.Minute
+)
+
+func ConnectStorage() {
+	store, driver, err := OpenStorage(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	Storage = store
+
+	if maxStr := os.Getenv("ATTACHMENT_MAX_BYTES"); maxStr != "" {
+		maxBytes, err := strconv.ParseInt(maxStr, 10, 64)
+		if err != nil || maxBytes <= 0 {
+			log.Fatalf("Invalid ATTACHMENT_MAX_BYTES %q", maxStr)
+		}
+		MaxAttachmentSize = maxBytes
+	}
+
+	if ttlStr := os.Getenv("ATTACHMENT_URL_TTL"); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("Invalid ATTACHMENT_URL_TTL %q", ttlStr)
+		}
+		AttachmentURLTTL = ttl
+	}
+
+	signingKey := []byte(os.Getenv("ATTACHMENT_SIGNING_KEY"))
+	if len(signingKey) == 0 {
+		// Key ngẫu nhiên chỉ dùng được cho 1 instance và mất hiệu lực khi restart
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			log.Fatalf("Could not generate attachment signing key: %v", err)
+		}
+		log.Println("ATTACHMENT_SIGNING_KEY not set in .env, using a random key (download links will not survive restarts)")
+	}
+	URLSigner = storage.NewURLSigner(signingKey)
+
+	log.Printf("Attachment storage ready (driver: %s)", driver)
+}
+
+// OpenStorage tạo blob store theo STORAGE_DRIVER ("local" mặc định hoặc "s3") và trả về tên driver
+func OpenStorage(ctx context.Context) (storage.BlobStore, string, error) {
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "local"
+	}
+
+	switch driver {
+	case "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+			log.Println("STORAGE_LOCAL_DIR not set in .env, using default:", dir)
+		}
+		store, err := storage.NewLocalStore(dir)
+		if err != nil {
+			return nil, driver, fmt.Errorf("could not initialize local storage: %w", err)
+		}
+		return store, driver, nil
+	case "s3":
+		useSSL, err := strconv.ParseBool(os.Getenv("S3_USE_SSL"))
+		if err != nil {
+			useSSL = true
+		}
+		store, err := storage.NewS3Store(ctx, storage.S3Options{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			UseSSL:    useSSL,
+		})
+		if err != nil {
+			return nil, driver, fmt.Errorf("could not connect to S3 storage: %w", err)
+		}
+		return store, driver, nil
+	default:
+		return nil, driver, fmt.Errorf("unknown STORAGE_DRIVER %q (expected local or s3)", driver)
+	}
+}
@@ -0,0 +1,88 @@
+package contentcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Config là cấu hình pipeline dạng JSON, ví dụ:
+//
+//	{
+//	  "word_lists": [
+//	    {"name": "profanity", "action": "mask", "words": ["đồ ngốc"], "patterns": ["f+u+c+k"]}
+//	  ],
+//	  "links": {"max": 3, "action": "flag"},
+//	  "duplicates": {"window": "10m", "action": "reject"}
+//	}
+type Config struct {
+	WordLists  []WordListConfig `json:"word_lists"`
+	Links      *LinkConfig      `json:"links"`
+	Duplicates *DuplicateConfig `json:"duplicates"`
+}
+
+type WordListConfig struct {
+	Name     string   `json:"name"`
+	Action   Action   `json:"action"`
+	Words    []string `json:"words"`
+	Patterns []string `json:"patterns"`
+}
+
+type LinkConfig struct {
+	Max    int    `json:"max"`
+	Action Action `json:"action"`
+}
+
+type DuplicateConfig struct {
+	Window string `json:"window"`
+	Action Action `json:"action"`
+}
+
+func LoadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("contentcheck: invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// Pipeline dựng pipeline theo thứ tự: danh sách từ cấm, giới hạn link, rồi trùng lặp
+// (để fingerprint được tính trên nội dung đã che)
+func (c *Config) Pipeline(db *gorm.DB) (*Pipeline, error) {
+	var rules []Rule
+	for i, list := range c.WordLists {
+		name := list.Name
+		if name == "" {
+			name = fmt.Sprintf("word_list_%d", i+1)
+		}
+		f, err := NewWordFilter(name, list.Action, list.Words, list.Patterns)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, f)
+	}
+	if c.Links != nil {
+		l, err := NewLinkLimit(c.Links.Max, c.Links.Action)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, l)
+	}
+	if c.Duplicates != nil {
+		window, err := time.ParseDuration(c.Duplicates.Window)
+		if err != nil {
+			return nil, fmt.Errorf("contentcheck: invalid duplicates window %q: %w", c.Duplicates.Window, err)
+		}
+		d, err := NewDuplicateFilter(db, window, c.Duplicates.Action)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, d)
+	}
+	return New(rules...), nil
+}
@@ -0,0 +1,113 @@
+package contentcheck
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// leet đưa các ký tự hay dùng để lách bộ lọc về chữ cái tương ứng
+var leet = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'@': 'a',
+	'$': 's',
+}
+
+// separator là các ký tự hay chèn vào giữa chữ cái ("s.p.a.m", "s-p-a-m") và bị bỏ khi chuẩn hoá
+func separator(r rune) bool {
+	return strings.ContainsRune(".-_*'`~|+", r) || unicode.Is(unicode.Cf, r)
+}
+
+// normalized là văn bản đã chuẩn hoá kèm vị trí byte trong văn bản gốc của từng byte,
+// để có thể che đúng đoạn gốc khi khớp trên bản chuẩn hoá
+type normalized struct {
+	text  string
+	start []int
+	end   []int
+}
+
+// span trả về đoạn [start, end) trong văn bản gốc ứng với đoạn [i, j) của bản chuẩn hoá
+func (n *normalized) span(i, j int) (int, int) {
+	return n.start[i], n.end[j-1]
+}
+
+type foldedRune struct {
+	r          rune
+	start, end int
+}
+
+// Normalize đưa văn bản về dạng so khớp: chữ thường, bỏ dấu (kể cả dấu tiếng Việt, đ → d),
+// quy đổi ký tự toàn chiều rộng và leetspeak, bỏ ký tự ngăn cách chèn giữa chữ và rút gọn
+// chữ lặp từ 3 lần trở lên ("spaaaam" → "spam").
+func Normalize(s string) string {
+	return normalize(s).text
+}
+
+func normalize(s string) *normalized {
+	var runes []foldedRune
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !separator(r) {
+			for _, f := range fold(r) {
+				runes = append(runes, foldedRune{r: f, start: i, end: i + size})
+			}
+		}
+		i += size
+	}
+
+	var b strings.Builder
+	n := &normalized{}
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j].r == runes[i].r {
+			j++
+		}
+		count := j - i
+		if count >= 3 && unicode.IsLetter(runes[i].r) {
+			count = 1
+		}
+		for k := 0; k < count; k++ {
+			// khi rút gọn, chữ còn lại đại diện cho cả chuỗi lặp trong văn bản gốc
+			start, end := runes[i+k].start, runes[i+k].end
+			if count == 1 {
+				end = runes[j-1].end
+			}
+			var buf [utf8.UTFMax]byte
+			w := utf8.EncodeRune(buf[:], runes[i].r)
+			b.Write(buf[:w])
+			for range w {
+				n.start = append(n.start, start)
+				n.end = append(n.end, end)
+			}
+		}
+		i = j
+	}
+	n.text = b.String()
+	return n
+}
+
+// fold trả về các rune đã chuẩn hoá của một rune gốc (rỗng với dấu kết hợp đứng riêng)
+func fold(r rune) []rune {
+	if m, ok := leet[r]; ok {
+		return []rune{m}
+	}
+	if r == 'đ' || r == 'Đ' {
+		return []rune{'d'}
+	}
+
+	var out []rune
+	for _, d := range norm.NFKD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		out = append(out, unicode.ToLower(d))
+	}
+	return out
+}
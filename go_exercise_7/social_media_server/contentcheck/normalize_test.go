@@ -0,0 +1,49 @@
+package contentcheck
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello World", "hello world"},
+		{"Đồ NGỐC", "do ngoc"},
+		{"Tiếng Việt có dấu", "tieng viet co dau"},
+		{"5p4m", "spam"},
+		{"$P@M", "spam"},
+		{"s.p.a.m", "spam"},
+		{"s-p_a*m", "spam"},
+		{"sp\u200bam", "spam"},
+		{"ＳＰＡＭ", "spam"},
+		{"spaaaam", "spam"},
+		{"SPAAAAM", "spam"},
+		{"too good", "too good"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeSpan(t *testing.T) {
+	tests := []struct {
+		in         string
+		i, j       int
+		start, end int
+	}{
+		// "đồ" chiếm 5 byte gốc nhưng chỉ 2 byte sau chuẩn hoá
+		{"đồ ngốc", 3, 7, 6, 12},
+		{"s.p.a.m", 0, 4, 0, 7},
+		// chữ còn lại sau khi rút gọn đại diện cho cả chuỗi lặp
+		{"spaaaam!", 2, 3, 2, 6},
+		{"ＳＰＡＭ", 1, 3, 3, 9},
+	}
+	for _, tt := range tests {
+		start, end := normalize(tt.in).span(tt.i, tt.j)
+		if start != tt.start || end != tt.end {
+			t.Errorf("normalize(%q).span(%d, %d) = [%d, %d), want [%d, %d)", tt.in, tt.i, tt.j, start, end, tt.start, tt.end)
+		}
+	}
+}
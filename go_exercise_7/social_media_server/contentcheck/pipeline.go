@@ -0,0 +1,130 @@
+package contentcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type Action string
+
+const (
+	// ActionReject từ chối nội dung, không lưu gì cả
+	ActionReject Action = "reject"
+	// ActionFlag vẫn lưu nhưng tạo báo cáo để moderator xem lại
+	ActionFlag Action = "flag"
+	// ActionMask thay đoạn vi phạm bằng dấu * rồi lưu bình thường
+	ActionMask Action = "mask"
+)
+
+func (a Action) valid() bool {
+	return a == ActionReject || a == ActionFlag || a == ActionMask
+}
+
+// Field là một trường văn bản của nội dung; rule có action mask sửa trực tiếp qua Value
+type Field struct {
+	Name  string
+	Value *string
+}
+
+// Submission là nội dung sắp được lưu. Author dùng để nhận ra tin trùng lặp của cùng một người
+// ("user:<id>" khi đăng nhập, "ip:<addr>" với khách).
+type Submission struct {
+	TargetType string
+	Author     string
+	Update     bool
+	Fields     []Field
+}
+
+func (s *Submission) text() string {
+	parts := make([]string, len(s.Fields))
+	for i, f := range s.Fields {
+		parts[i] = *f.Value
+	}
+	return strings.Join(parts, "\n")
+}
+
+type Violation struct {
+	Rule    string `json:"rule"`
+	Action  Action `json:"action"`
+	Message string `json:"message"`
+}
+
+type Result struct {
+	Violations []Violation
+}
+
+func (r *Result) has(action Action) bool {
+	for _, v := range r.Violations {
+		if v.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Result) Rejected() bool { return r.has(ActionReject) }
+
+func (r *Result) Flagged() bool { return r.has(ActionFlag) }
+
+// Reason gộp thông điệp của các vi phạm có action đã cho, dùng làm lý do báo cáo hoặc lỗi trả về
+func (r *Result) Reason(action Action) string {
+	var messages []string
+	for _, v := range r.Violations {
+		if v.Action == action {
+			messages = append(messages, v.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Rule kiểm tra một submission và trả về vi phạm (nil nếu không có).
+// Rule có action mask tự che nội dung trong Fields trước khi trả về.
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, sub *Submission) (*Violation, error)
+}
+
+// Recorder là rule cần biết nội dung nào đã thực sự được lưu (ví dụ để phát hiện trùng lặp)
+type Recorder interface {
+	Accepted(ctx context.Context, sub *Submission) error
+}
+
+// Pipeline chạy lần lượt các rule; dừng ngay khi có rule từ chối
+type Pipeline struct {
+	rules []Rule
+}
+
+func New(rules ...Rule) *Pipeline {
+	return &Pipeline{rules: rules}
+}
+
+func (p *Pipeline) Check(ctx context.Context, sub *Submission) (*Result, error) {
+	result := &Result{}
+	for _, rule := range p.rules {
+		v, err := rule.Check(ctx, sub)
+		if err != nil {
+			return nil, fmt.Errorf("contentcheck: rule %s: %w", rule.Name(), err)
+		}
+		if v == nil {
+			continue
+		}
+		result.Violations = append(result.Violations, *v)
+		if v.Action == ActionReject {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Accepted báo cho các Recorder rằng submission đã được lưu
+func (p *Pipeline) Accepted(ctx context.Context, sub *Submission) error {
+	for _, rule := range p.rules {
+		if r, ok := rule.(Recorder); ok {
+			if err := r.Accepted(ctx, sub); err != nil {
+				return fmt.Errorf("contentcheck: rule %s: %w", rule.Name(), err)
+			}
+		}
+	}
+	return nil
+}
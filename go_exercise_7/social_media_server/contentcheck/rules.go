@@ -0,0 +1,245 @@
+package contentcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"social_media_server/models"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// WordFilter chặn từ cấm (khớp nguyên từ) và regex, cả hai đều so trên văn bản đã Normalize
+// nên pattern cũng phải viết ở dạng chữ thường không dấu.
+type WordFilter struct {
+	name     string
+	action   Action
+	words    []*regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+func NewWordFilter(name string, action Action, words, patterns []string) (*WordFilter, error) {
+	if !action.valid() {
+		return nil, fmt.Errorf("contentcheck: invalid action %q for %s", action, name)
+	}
+	f := &WordFilter{name: name, action: action}
+	for _, w := range words {
+		if w = Normalize(strings.TrimSpace(w)); w != "" {
+			f.words = append(f.words, regexp.MustCompile(regexp.QuoteMeta(w)))
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("contentcheck: invalid pattern %q for %s: %w", p, name, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+func (f *WordFilter) Name() string { return f.name }
+
+func (f *WordFilter) Check(ctx context.Context, sub *Submission) (*Violation, error) {
+	matched := false
+	for _, field := range sub.Fields {
+		n := normalize(*field.Value)
+		spans := f.match(n)
+		if len(spans) == 0 {
+			continue
+		}
+		matched = true
+		if f.action != ActionMask {
+			break
+		}
+		*field.Value = mask(*field.Value, spans)
+	}
+	if !matched {
+		return nil, nil
+	}
+	return &Violation{Rule: f.name, Action: f.action, Message: "Content contains disallowed words"}, nil
+}
+
+// match trả về các đoạn vi phạm trong văn bản gốc
+func (f *WordFilter) match(n *normalized) [][2]int {
+	var spans [][2]int
+	add := func(i, j int) {
+		if i < j {
+			start, end := n.span(i, j)
+			spans = append(spans, [2]int{start, end})
+		}
+	}
+	for _, re := range f.words {
+		for _, loc := range re.FindAllStringIndex(n.text, -1) {
+			if wordBoundary(n.text, loc[0], loc[1]) {
+				add(loc[0], loc[1])
+			}
+		}
+	}
+	for _, re := range f.patterns {
+		for _, loc := range re.FindAllStringIndex(n.text, -1) {
+			add(loc[0], loc[1])
+		}
+	}
+	return spans
+}
+
+func wordBoundary(s string, i, j int) bool {
+	if i > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:i])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if j < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[j:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// mask thay mỗi rune trong các đoạn đã cho bằng '*', giữ nguyên khoảng trắng
+func mask(s string, spans [][2]int) string {
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	var b strings.Builder
+	pos := 0
+	for _, sp := range spans {
+		if sp[1] <= pos {
+			continue
+		}
+		if sp[0] > pos {
+			b.WriteString(s[pos:sp[0]])
+		} else {
+			sp[0] = pos
+		}
+		for _, r := range s[sp[0]:sp[1]] {
+			if unicode.IsSpace(r) {
+				b.WriteRune(r)
+			} else {
+				b.WriteByte('*')
+			}
+		}
+		pos = sp[1]
+	}
+	b.WriteString(s[pos:])
+	return b.String()
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'()\[\]]+`)
+
+const linkPlaceholder = "[link removed]"
+
+// LinkLimit giới hạn số link trong toàn bộ nội dung; khi mask, các link vượt giới hạn bị thay thế
+type LinkLimit struct {
+	max    int
+	action Action
+}
+
+func NewLinkLimit(max int, action Action) (*LinkLimit, error) {
+	if !action.valid() {
+		return nil, fmt.Errorf("contentcheck: invalid action %q for links", action)
+	}
+	if max < 0 {
+		return nil, fmt.Errorf("contentcheck: link limit must not be negative")
+	}
+	return &LinkLimit{max: max, action: action}, nil
+}
+
+func (l *LinkLimit) Name() string { return "links" }
+
+func (l *LinkLimit) Check(ctx context.Context, sub *Submission) (*Violation, error) {
+	count := 0
+	for _, field := range sub.Fields {
+		count += len(linkPattern.FindAllStringIndex(*field.Value, -1))
+	}
+	if count <= l.max {
+		return nil, nil
+	}
+
+	if l.action == ActionMask {
+		seen := 0
+		for _, field := range sub.Fields {
+			*field.Value = linkPattern.ReplaceAllStringFunc(*field.Value, func(link string) string {
+				seen++
+				if seen <= l.max {
+					return link
+				}
+				return linkPlaceholder
+			})
+		}
+	}
+	return &Violation{
+		Rule:    l.Name(),
+		Action:  l.action,
+		Message: fmt.Sprintf("Content contains %d links, at most %d allowed", count, l.max),
+	}, nil
+}
+
+// DuplicateFilter phát hiện cùng một người gửi lại nội dung giống hệt (sau khi Normalize)
+// trong khoảng window. Bản ghi fingerprint chỉ được tạo khi nội dung đã lưu thành công.
+type DuplicateFilter struct {
+	db     *gorm.DB
+	window time.Duration
+	action Action
+}
+
+func NewDuplicateFilter(db *gorm.DB, window time.Duration, action Action) (*DuplicateFilter, error) {
+	if !action.valid() || action == ActionMask {
+		return nil, fmt.Errorf("contentcheck: invalid action %q for duplicates (expected reject or flag)", action)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("contentcheck: duplicate window must be positive")
+	}
+	return &DuplicateFilter{db: db, window: window, action: action}, nil
+}
+
+func (d *DuplicateFilter) Name() string { return "duplicates" }
+
+func fingerprint(sub *Submission) string {
+	sum := sha256.Sum256([]byte(Normalize(sub.text())))
+	return hex.EncodeToString(sum[:])
+}
+
+// applies: sửa bài không tính là gửi lại, và không biết tác giả thì không so được
+func (d *DuplicateFilter) applies(sub *Submission) bool {
+	return !sub.Update && sub.Author != "" && strings.TrimSpace(sub.text()) != ""
+}
+
+func (d *DuplicateFilter) Check(ctx context.Context, sub *Submission) (*Violation, error) {
+	if !d.applies(sub) {
+		return nil, nil
+	}
+	var count int64
+	if err := d.db.WithContext(ctx).Model(&models.ContentFingerprint{}).
+		Where("author = ? AND hash = ? AND created_at > ?", sub.Author, fingerprint(sub), time.Now().Add(-d.window)).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return &Violation{Rule: d.Name(), Action: d.action, Message: "Duplicate of a message sent recently"}, nil
+}
+
+func (d *DuplicateFilter) Accepted(ctx context.Context, sub *Submission) error {
+	if !d.applies(sub) {
+		return nil
+	}
+	db := d.db.WithContext(ctx)
+	// dọn luôn các fingerprint đã hết hạn của tác giả này để bảng không phình ra
+	if err := db.Where("author = ? AND created_at <= ?", sub.Author, time.Now().Add(-d.window)).
+		Delete(&models.ContentFingerprint{}).Error; err != nil {
+		return err
+	}
+	return db.Create(&models.ContentFingerprint{Author: sub.Author, Hash: fingerprint(sub)}).Error
+}
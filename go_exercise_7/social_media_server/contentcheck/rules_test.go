@@ -0,0 +1,88 @@
+package contentcheck
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWordFilterMask(t *testing.T) {
+	f, err := NewWordFilter("banned", ActionMask, []string{"spam", "Đồ ngốc"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in      string
+		want    string
+		matched bool
+	}{
+		{"buy spam now", "buy **** now", true},
+		{"SPAM, spam", "****, ****", true},
+		{"5p4m", "****", true},
+		{"s.p.a.m!", "*******!", true},
+		{"spaaaam", "*******", true},
+		{"ＳＰＡＭ here", "**** here", true},
+		{"Anh là đồ ngốc.", "Anh là ** ****.", true},
+		// từ cấm nằm trong một từ dài hơn thì không bị chặn
+		{"spammer", "spammer", false},
+		{"antispam", "antispam", false},
+		{"spam2", "spam2", false},
+		{"đồ ngốcnghếch", "đồ ngốcnghếch", false},
+		{"hello world", "hello world", false},
+	}
+	for _, tt := range tests {
+		value := tt.in
+		v, err := f.Check(context.Background(), &Submission{Fields: []Field{{Name: "content", Value: &value}}})
+		if err != nil {
+			t.Fatalf("Check(%q): %v", tt.in, err)
+		}
+		if (v != nil) != tt.matched {
+			t.Errorf("Check(%q) violation = %v, want matched %v", tt.in, v, tt.matched)
+		}
+		if value != tt.want {
+			t.Errorf("Check(%q) masked to %q, want %q", tt.in, value, tt.want)
+		}
+	}
+}
+
+func TestWordFilterRejectKeepsValue(t *testing.T) {
+	f, err := NewWordFilter("banned", ActionReject, []string{"spam"}, []string{`fr[e3]{2} money`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range []string{"spam", "Free Money!!", "fr33 m0ney"} {
+		value := in
+		v, err := f.Check(context.Background(), &Submission{Fields: []Field{{Name: "content", Value: &value}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == nil || v.Action != ActionReject {
+			t.Errorf("Check(%q) = %v, want a reject violation", in, v)
+		}
+		if value != in {
+			t.Errorf("Check(%q) changed the value to %q", in, value)
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		in    string
+		spans [][2]int
+		want  string
+	}{
+		{"hello world", nil, "hello world"},
+		{"hello world", [][2]int{{0, 5}}, "***** world"},
+		{"hello world", [][2]int{{0, 11}}, "***** *****"},
+		// đoạn chưa sắp xếp và chồng lên nhau
+		{"abcdefgh", [][2]int{{5, 7}, {1, 3}, {2, 4}}, "a***e**h"},
+		{"abcdefgh", [][2]int{{1, 6}, {2, 4}}, "a*****gh"},
+		// offset là byte trong văn bản gốc, mỗi rune nhiều byte chỉ thành một dấu *
+		{"đồ ngốc nhé", [][2]int{{0, 12}}, "** **** nhé"},
+		{"xin chào", [][2]int{{4, 9}}, "xin ****"},
+	}
+	for _, tt := range tests {
+		if got := mask(tt.in, tt.spans); got != tt.want {
+			t.Errorf("mask(%q, %v) = %q, want %q", tt.in, tt.spans, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,412 @@
+package controllers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"social_media_server/config"
+	"social_media_server/deletion"
+	"social_media_server/media"
+	"social_media_server/models"
+	"social_media_server/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const thumbnailMaxDim = 320
+
+// Các loại file được phép upload, xác định bằng MIME sniffing chứ không tin header của client
+var allowedAttachmentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+	"text/plain":      ".txt",
+	"application/zip": ".zip",
+}
+
+var thumbnailTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+type AttachmentController struct{}
+
+func NewAttachmentController() *AttachmentController {
+	return &AttachmentController{}
+}
+
+// apiPrefix trả về tiền tố phiên bản API của route đang xử lý (ví dụ "/api/v1"),
+// rỗng với các route cũ không có tiền tố
+func apiPrefix(c *gin.Context) string {
+	const api = "/api/"
+	fullPath := c.FullPath()
+	if !strings.HasPrefix(fullPath, api) {
+		return ""
+	}
+	if i := strings.Index(fullPath[len(api):], "/"); i >= 0 {
+		return fullPath[:len(api)+i]
+	}
+	return fullPath
+}
+
+// signAttachmentURLs điền URL tải file có chữ ký cho các attachment trước khi trả về client,
+// cùng phiên bản API với request hiện tại
+func signAttachmentURLs(c *gin.Context, attachments []models.Attachment) {
+	expiresAt := time.Now().Add(config.AttachmentURLTTL)
+	prefix := apiPrefix(c)
+	for i := range attachments {
+		a := &attachments[i]
+		a.URL = config.URLSigner.Sign(fmt.Sprintf("%s/attachments/%d/download", prefix, a.ID), expiresAt)
+		if a.ThumbnailKey != "" {
+			a.ThumbnailURL = config.URLSigner.Sign(fmt.Sprintf("%s/attachments/%d/thumbnail", prefix, a.ID), expiresAt)
+		}
+	}
+}
+
+func randomKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// deleteAttachmentBlobs xoá file trong storage, lỗi chỉ được log vì bản ghi DB mới là nguồn chính
+func deleteAttachmentBlobs(c *gin.Context, attachments []models.Attachment) {
+	deletion.Blobs(c.Request.Context(), config.Storage, attachments)
+}
+
+// @Summary Upload an attachment to a post
+// @Description Upload an image or file (multipart field "file") and attach it to a post. The type is detected from the file content, image metadata (EXIF) is stripped and a thumbnail is generated for images.
+// @Tags attachments
+// @Accept  multipart/form-data
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param file formData file true "File to upload"
+// @Success 201 {object} models.Attachment "Successfully uploaded attachment"
+// @Failure 400 {object} map[string]string "Invalid post ID or missing file"
+// @Failure 403 {object} map[string]string "Not the owner of the post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 413 {object} map[string]string "File too large"
+// @Failure 415 {object} map[string]string "Unsupported file type"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/attachments [post]
+func (ac *AttachmentController) UploadAttachment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var post models.Post
+	if err := requestDB(c).First(&post, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+		return
+	}
+
+	if !canModify(c, post.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to add attachments to this post"})
+		return
+	}
+
+	// Chừa thêm 1MB cho phần header của multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxAttachmentSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", config.MaxAttachmentSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file must be uploaded in the \"file\" form field"})
+		return
+	}
+	if fileHeader.Size > config.MaxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", config.MaxAttachmentSize)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, config.MaxAttachmentSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	if int64(len(data)) > config.MaxAttachmentSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds the maximum size of %d bytes", config.MaxAttachmentSize)})
+		return
+	}
+	if len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded file is empty"})
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	ext, ok := allowedAttachmentTypes[contentType]
+	if !ok {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": fmt.Sprintf("File type %s is not allowed", contentType)})
+		return
+	}
+
+	data, err = media.StripMetadata(contentType, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Uploaded image is malformed"})
+		return
+	}
+
+	key, err := randomKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+		return
+	}
+
+	attachment := models.Attachment{
+		PostID:      post.ID,
+		FileName:    filepath.Base(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		StorageKey:  fmt.Sprintf("posts/%d/%s%s", post.ID, key, ext),
+	}
+
+	ctx := c.Request.Context()
+	if err := config.Storage.Put(ctx, attachment.StorageKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		log.Printf("Failed to store attachment %s: %v", attachment.StorageKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store attachment"})
+		return
+	}
+
+	if thumbnailTypes[contentType] {
+		thumb, err := media.Thumbnail(data, thumbnailMaxDim)
+		if err != nil {
+			// Không tạo được thumbnail thì vẫn giữ file gốc
+			log.Printf("Failed to generate thumbnail for %s: %v", attachment.StorageKey, err)
+		} else {
+			thumbKey := fmt.Sprintf("posts/%d/%s_thumb.jpg", post.ID, key)
+			if err := config.Storage.Put(ctx, thumbKey, bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg"); err != nil {
+				log.Printf("Failed to store thumbnail %s: %v", thumbKey, err)
+			} else {
+				attachment.ThumbnailKey = thumbKey
+			}
+		}
+	}
+
+	if err := requestDB(c).Create(&attachment).Error; err != nil {
+		deleteAttachmentBlobs(c, []models.Attachment{attachment})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment"})
+		return
+	}
+
+	recordAudit(c, models.AuditCreate, resourceAttachment, attachment.ID, nil, attachment)
+
+	attachments := []models.Attachment{attachment}
+	signAttachmentURLs(c, attachments)
+	c.JSON(http.StatusCreated, attachments[0])
+}
+
+// @Summary List attachments of a post
+// @Description Get all attachments of a post with signed download URLs. Attachments of drafts, scheduled and hidden posts are only listed for users who can see the post
+// @Tags attachments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Success 200 {array} models.Attachment "Successfully retrieved attachments"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/attachments [get]
+func (ac *AttachmentController) GetAttachments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var post models.Post
+	if err := visiblePosts(c, requestDB(c), models.PostPublished, models.PostArchived).Preload("Attachments").First(&post, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attachments"})
+		return
+	}
+
+	signAttachmentURLs(c, post.Attachments)
+	c.JSON(http.StatusOK, post.Attachments)
+}
+
+// @Summary Download an attachment
+// @Description Download the original file using a signed URL returned by the API. The link stops working once the post is no longer visible to the caller
+// @Tags attachments
+// @Produce  octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Expiry timestamp from the signed URL"
+// @Param signature query string true "Signature from the signed URL"
+// @Success 200 {file} file "Attachment content"
+// @Failure 400 {object} map[string]string "Invalid attachment ID"
+// @Failure 403 {object} map[string]string "Invalid or expired signature"
+// @Failure 404 {object} map[string]string "Attachment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /attachments/{id}/download [get]
+func (ac *AttachmentController) DownloadAttachment(c *gin.Context) {
+	ac.serveBlob(c, false)
+}
+
+// @Summary Download an attachment thumbnail
+// @Description Download the JPEG thumbnail of an image attachment using a signed URL returned by the API
+// @Tags attachments
+// @Produce  jpeg
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Expiry timestamp from the signed URL"
+// @Param signature query string true "Signature from the signed URL"
+// @Success 200 {file} file "Thumbnail content"
+// @Failure 400 {object} map[string]string "Invalid attachment ID"
+// @Failure 403 {object} map[string]string "Invalid or expired signature"
+// @Failure 404 {object} map[string]string "Attachment or thumbnail not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /attachments/{id}/thumbnail [get]
+func (ac *AttachmentController) DownloadThumbnail(c *gin.Context) {
+	ac.serveBlob(c, true)
+}
+
+func (ac *AttachmentController) serveBlob(c *gin.Context, thumbnail bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	if err := config.URLSigner.Verify(c.Request.URL.Path, c.Query("expires"), c.Query("signature")); err != nil {
+		if errors.Is(err, storage.ErrURLExpired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Download link has expired"})
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid download signature"})
+		return
+	}
+
+	// Link đã ký vẫn hết hiệu lực khi người xem không còn thấy post (chuyển về nháp, bị ẩn, bị xoá)
+	var attachment models.Attachment
+	if err := requestDB(c).Where("post_id IN (?)", visiblePostIDs(c)).First(&attachment, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attachment"})
+		return
+	}
+
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	if thumbnail {
+		if attachment.ThumbnailKey == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment has no thumbnail"})
+			return
+		}
+		key, contentType = attachment.ThumbnailKey, "image/jpeg"
+	}
+
+	blob, err := config.Storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read attachment"})
+		return
+	}
+	defer blob.Close()
+
+	disposition := "inline"
+	if !strings.HasPrefix(contentType, "image/") {
+		disposition = "attachment"
+	}
+	extraHeaders := map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	}
+	size := attachment.Size
+	if thumbnail {
+		size = -1
+	}
+	c.DataFromReader(http.StatusOK, size, contentType, blob, extraHeaders)
+}
+
+// @Summary Delete an attachment
+// @Description Delete an attachment and its stored files by its ID. Only the owner of the post can delete its attachments
+// @Tags attachments
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Attachment ID"
+// @Success 200 {object} map[string]string "Message: Attachment deleted successfully"
+// @Failure 400 {object} map[string]string "Invalid attachment ID"
+// @Failure 403 {object} map[string]string "Not the owner of the post"
+// @Failure 404 {object} map[string]string "Attachment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /attachments/{id} [delete]
+func (ac *AttachmentController) DeleteAttachment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+
+	var attachment models.Attachment
+	if err := requestDB(c).First(&attachment, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve attachment for deletion"})
+		return
+	}
+
+	var post models.Post
+	if err := requestDB(c).Unscoped().First(&post, attachment.PostID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post for deletion"})
+		return
+	}
+	if !canModify(c, post.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this attachment"})
+		return
+	}
+
+	// Xoá hẳn bản ghi vì file trong storage cũng bị xoá theo
+	if err := requestDB(c).Unscoped().Delete(&models.Attachment{}, attachment.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		return
+	}
+	deleteAttachmentBlobs(c, []models.Attachment{attachment})
+	recordAudit(c, models.AuditDelete, resourceAttachment, attachment.ID, attachment, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"social_media_server/audit"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Loại tài nguyên ghi trong audit log, ngoài models.TargetPost và models.TargetComment
+const (
+	resourceAttachment             = "attachment"
+	resourceUser                   = "user"
+	resourceFollow                 = "follow"
+	resourceReport                 = "report"
+	resourceNotification           = "notification"
+	resourceNotificationPreference = "notification_preference"
+	resourceImport                 = "import"
+	resourceCommunity              = "community"
+	resourceCommunityMember        = "community_member"
+	resourceJoinRequest            = "community_join_request"
+	resourceBlock                  = "block"
+	resourceConversation           = "conversation"
+	resourceParticipant            = "conversation_participant"
+	resourceMessage                = "message"
+	resourceBookmark               = "bookmark"
+	resourceCollection             = "bookmark_collection"
+	resourceVote                   = "vote"
+	resourceReaction               = "reaction"
+)
+
+type AuditController struct{}
+
+type AuditLogListResponse struct {
+	Data       []models.AuditLog `json:"data"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+func NewAuditController() *AuditController {
+	return &AuditController{}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+	return s
+}
+
+// recordAudit ghi lại một thay đổi kèm thông tin request. Lỗi chỉ được log để không làm hỏng
+// thao tác chính đã thành công. before/after là nil khi tài nguyên chưa có hoặc đã bị xoá.
+func recordAudit(c *gin.Context, action, resourceType string, resourceID uint, before, after interface{}) {
+	entry := models.AuditLog{
+		ActorID:      middleware.CurrentUserID(c),
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Before:       audit.Snapshot(before),
+		After:        audit.Snapshot(after),
+		RequestID:    middleware.GetRequestID(c),
+		IP:           c.ClientIP(),
+		UserAgent:    truncate(c.Request.UserAgent(), 255),
+		Method:       c.Request.Method,
+		Path:         truncate(c.Request.URL.Path, 255),
+	}
+	if err := audit.Record(c.Request.Context(), config.DB, &entry); err != nil {
+		log.Printf("Failed to write audit log for %s %s %d: %v", action, resourceType, resourceID, err)
+	}
+}
+
+// auditQuery dựng query từ các bộ lọc chung của danh sách và export
+func auditQuery(c *gin.Context) (*gorm.DB, error) {
+	query := readDB(c).Model(&models.AuditLog{})
+
+	if v := c.Query("actor_id"); v != "" {
+		actorID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, errors.New("actor_id must be a user ID")
+		}
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if v := c.Query("action"); v != "" {
+		query = query.Where("action = ?", v)
+	}
+	if v := c.Query("resource_type"); v != "" {
+		query = query.Where("resource_type = ?", v)
+	}
+	if v := c.Query("resource_id"); v != "" {
+		resourceID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, errors.New("resource_id must be a positive integer")
+		}
+		query = query.Where("resource_id = ?", resourceID)
+	}
+	if v := c.Query("request_id"); v != "" {
+		query = query.Where("request_id = ?", v)
+	}
+	if v := c.Query("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("since must be an RFC 3339 time")
+		}
+		query = query.Where("created_at >= ?", since)
+	}
+	if v := c.Query("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.New("until must be an RFC 3339 time")
+		}
+		query = query.Where("created_at < ?", until)
+	}
+	return query, nil
+}
+
+// @Summary List audit log entries
+// @Description Get audit log entries for every create, update and delete, newest first. Requires the admin role
+// @Tags admin
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param actor_id query int false "Filter by the user who made the change"
+// @Param action query string false "Filter by action (create, update, delete)"
+// @Param resource_type query string false "Filter by resource type (post, comment, attachment, user, follow, report, notification, notification_preference, import)"
+// @Param resource_id query int false "Filter by resource ID"
+// @Param request_id query string false "Filter by request ID (X-Request-ID)"
+// @Param since query string false "Only entries at or after this RFC 3339 time"
+// @Param until query string false "Only entries before this RFC 3339 time"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Entries per page (1-100)" default(20)
+// @Success 200 {object} AuditLogListResponse "Successfully retrieved audit log"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Admin role required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/audit_logs [get]
+func (ac *AuditController) GetAuditLogs(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query, err := auditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit log entries"})
+		return
+	}
+
+	entries := []models.AuditLog{}
+	if err := query.Order("id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, AuditLogListResponse{Data: entries, Pagination: pagination})
+}
+
+// @Summary Export audit log entries
+// @Description Stream audit log entries matching the filters, oldest first, as NDJSON or CSV. Requires the admin role
+// @Tags admin
+// @Produce  plain
+// @Security ApiKeyAuth
+// @Param format query string false "Export format" Enums(ndjson, csv) default(ndjson)
+// @Param actor_id query int false "Filter by the user who made the change"
+// @Param action query string false "Filter by action (create, update, delete)"
+// @Param resource_type query string false "Filter by resource type"
+// @Param resource_id query int false "Filter by resource ID"
+// @Param request_id query string false "Filter by request ID (X-Request-ID)"
+// @Param since query string false "Only entries at or after this RFC 3339 time"
+// @Param until query string false "Only entries before this RFC 3339 time"
+// @Success 200 {array} models.AuditLog "Exported audit log entries"
+// @Failure 400 {object} map[string]string "Invalid format or filters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Admin role required"
+// @Router /admin/audit_logs/export [get]
+func (ac *AuditController) ExportAuditLogs(c *gin.Context) {
+	format := c.DefaultQuery("format", audit.FormatNDJSON)
+	if !audit.ValidFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected ndjson or csv"})
+		return
+	}
+	query, err := auditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-log-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", audit.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if count, err := audit.Export(c.Request.Context(), query, c.Writer, format); err != nil {
+		log.Printf("Audit log export failed after %d entries: %v", count, err)
+	}
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"social_media_server/middleware"
+	"social_media_server/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// canModify: nội dung ẩn danh (tạo trước khi có tài khoản) vẫn ai cũng sửa được như trước,
+// nội dung có chủ thì chỉ chủ sở hữu được sửa/xoá
+func canModify(c *gin.Context, ownerID *uint) bool {
+	if ownerID == nil {
+		return true
+	}
+	user, ok := middleware.CurrentUser(c)
+	return ok && user.ID == *ownerID
+}
+
+// canModerate: như canModify nhưng moderator/admin cũng được phép (dùng cho xoá)
+func canModerate(c *gin.Context, ownerID *uint) bool {
+	return canModify(c, ownerID) || isModerator(c)
+}
+
+func isModerator(c *gin.Context) bool {
+	user, ok := middleware.CurrentUser(c)
+	return ok && user.IsModerator()
+}
+
+// visible ẩn nội dung bị kiểm duyệt với người dùng thường, moderator vẫn thấy để xử lý
+func visible(c *gin.Context, query *gorm.DB) *gorm.DB {
+	if isModerator(c) {
+		return query
+	}
+	return query.Where("hidden = ?", false)
+}
+
+// visiblePosts như visible nhưng chỉ lấy post có status thuộc statuses; tác giả luôn thấy post
+// của mình ở mọi trạng thái (nháp, hẹn giờ, lưu trữ)
+func visiblePosts(c *gin.Context, query *gorm.DB, statuses ...string) *gorm.DB {
+	query = visible(c, query)
+	if userID := middleware.CurrentUserID(c); userID != nil {
+		return query.Where("(status IN ? OR user_id = ?)", statuses, *userID)
+	}
+	return query.Where("status IN ?", statuses)
+}
+
+// visiblePostIDs là subquery ID các post người xem hiện tại được thấy, dùng cho dữ liệu gắn với post
+func visiblePostIDs(c *gin.Context) *gorm.DB {
+	return visiblePosts(c, requestDB(c).Model(&models.Post{}).Select("id"), models.PostPublished, models.PostArchived)
+}
+
+// visibleComments như visible nhưng còn bỏ comment của post mà người xem không thấy được
+func visibleComments(c *gin.Context, query *gorm.DB) *gorm.DB {
+	return visible(c, query).Where("post_id IN (?)", visiblePostIDs(c))
+}
+
+// communityMembership trả về membership của người dùng hiện tại trong community, nil nếu chưa tham gia
+func communityMembership(c *gin.Context, communityID uint) (*models.CommunityMembership, error) {
+	userID := middleware.CurrentUserID(c)
+	if userID == nil {
+		return nil, nil
+	}
+	var membership models.CommunityMembership
+	err := requestDB(c).Where("community_id = ? AND user_id = ?", communityID, *userID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// canModerateCommunity: owner/moderator của community chứa nội dung, hoặc moderator toàn trang
+func canModerateCommunity(c *gin.Context, communityID *uint) bool {
+	if communityID == nil {
+		return false
+	}
+	if isModerator(c) {
+		return true
+	}
+	membership, err := communityMembership(c, *communityID)
+	return err == nil && membership != nil && membership.IsModerator()
+}
@@ -0,0 +1,495 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkController struct{}
+
+// BookmarkRequest: collection_id bỏ trống để lưu post ngoài mọi collection
+type BookmarkRequest struct {
+	CollectionID *uint `json:"collection_id"`
+}
+
+type CollectionRequest struct {
+	Name string `json:"name"`
+}
+
+type BookmarkListResponse struct {
+	Data       []models.Bookmark `json:"data"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+type CollectionListResponse struct {
+	Data       []models.Collection `json:"data"`
+	Pagination Pagination          `json:"pagination"`
+}
+
+func NewBookmarkController() *BookmarkController {
+	return &BookmarkController{}
+}
+
+// loadPostBookmarks gắn số lượt lưu và việc người dùng hiện tại đã lưu post hay chưa
+func loadPostBookmarks(c *gin.Context, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	var counts []struct {
+		PostID uint
+		Count  int64
+	}
+	if err := requestDB(c).Model(&models.Bookmark{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ?", ids).
+		Group("post_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		byID[row.PostID] = row.Count
+	}
+
+	saved := map[uint]bool{}
+	if userID := viewerID(c); userID != 0 {
+		var savedIDs []uint
+		if err := requestDB(c).Model(&models.Bookmark{}).
+			Where("user_id = ? AND post_id IN ?", userID, ids).
+			Pluck("post_id", &savedIDs).Error; err != nil {
+			return err
+		}
+		for _, id := range savedIDs {
+			saved[id] = true
+		}
+	}
+
+	for i := range posts {
+		posts[i].BookmarkCount = byID[posts[i].ID]
+		posts[i].Bookmarked = saved[posts[i].ID]
+	}
+	return nil
+}
+
+func loadCollectionCounts(db *gorm.DB, collections []models.Collection) error {
+	if len(collections) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(collections))
+	for i := range collections {
+		ids[i] = collections[i].ID
+	}
+	var counts []struct {
+		CollectionID uint
+		Count        int64
+	}
+	if err := db.Model(&models.Bookmark{}).
+		Select("collection_id, COUNT(*) AS count").
+		Where("collection_id IN ?", ids).
+		Group("collection_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		byID[row.CollectionID] = row.Count
+	}
+	for i := range collections {
+		collections[i].BookmarkCount = byID[collections[i].ID]
+	}
+	return nil
+}
+
+// findCollection đọc collection của người dùng hiện tại, collection của người khác được coi như không tồn tại
+func findCollection(c *gin.Context, id uint) (*models.Collection, bool) {
+	user, _ := middleware.CurrentUser(c)
+	var collection models.Collection
+	if err := requestDB(c).Where("id = ? AND user_id = ?", id, user.ID).First(&collection).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+		return nil, false
+	}
+	return &collection, true
+}
+
+func findCollectionParam(c *gin.Context) (*models.Collection, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection ID"})
+		return nil, false
+	}
+	return findCollection(c, uint(id))
+}
+
+// validCollectionName chuẩn hoá tên và kiểm tra người dùng chưa có collection khác cùng tên
+func validCollectionName(c *gin.Context, name string, exceptID uint) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1-64 characters"})
+		return "", false
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	var existing int64
+	if err := requestDB(c).Model(&models.Collection{}).
+		Where("user_id = ? AND name = ? AND id <> ?", user.ID, name, exceptID).
+		Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check collection name"})
+		return "", false
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a collection with this name"})
+		return "", false
+	}
+	return name, true
+}
+
+// @Summary Bookmark a post
+// @Description Save a post for later, optionally into one of the current user's collections. Bookmarking a post again moves it to the given collection (or out of any collection when collection_id is omitted)
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param bookmark body BookmarkRequest false "Collection to save the post into"
+// @Success 200 {object} models.Bookmark "Bookmark moved to another collection"
+// @Success 201 {object} models.Bookmark "Post bookmarked"
+// @Failure 400 {object} map[string]string "Invalid post ID or request body"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Post or collection not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/bookmark [post]
+func (bc *BookmarkController) BookmarkPost(c *gin.Context) {
+	postID, ok := findTarget(c, models.TargetPost)
+	if !ok {
+		return
+	}
+
+	var req BookmarkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.CollectionID != nil {
+		if _, ok := findCollection(c, *req.CollectionID); !ok {
+			return
+		}
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	var existing []models.Bookmark
+	if err := requestDB(c).Where("user_id = ? AND post_id = ?", user.ID, postID).Limit(1).Find(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmark"})
+		return
+	}
+
+	if len(existing) > 0 {
+		before := existing[0]
+		bookmark := before
+		bookmark.CollectionID = req.CollectionID
+		if err := requestDB(c).Model(&models.Bookmark{}).
+			Where("user_id = ? AND post_id = ?", user.ID, postID).
+			Update("collection_id", req.CollectionID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bookmark"})
+			return
+		}
+		recordAudit(c, models.AuditUpdate, resourceBookmark, postID, before, bookmark)
+		c.JSON(http.StatusOK, bookmark)
+		return
+	}
+
+	bookmark := models.Bookmark{UserID: user.ID, PostID: postID, CollectionID: req.CollectionID}
+	result := requestDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&bookmark)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bookmark post"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		// request khác vừa lưu cùng post
+		c.JSON(http.StatusOK, bookmark)
+		return
+	}
+	recordAudit(c, models.AuditCreate, resourceBookmark, postID, nil, bookmark)
+	c.JSON(http.StatusCreated, bookmark)
+}
+
+// @Summary Remove a bookmark
+// @Description Remove a post from the current user's bookmarks
+// @Tags bookmarks
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Success 200 {object} map[string]string "Message: Bookmark removed"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Post not bookmarked"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/bookmark [delete]
+func (bc *BookmarkController) UnbookmarkPost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	var bookmark models.Bookmark
+	if err := requestDB(c).Where("user_id = ? AND post_id = ?", user.ID, uint(id)).First(&bookmark).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "You have not bookmarked this post"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmark"})
+		return
+	}
+	if err := requestDB(c).Where("user_id = ? AND post_id = ?", user.ID, uint(id)).Delete(&models.Bookmark{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove bookmark"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceBookmark, bookmark.PostID, bookmark, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Bookmark removed"})
+}
+
+// @Summary List bookmarks
+// @Description Get the current user's bookmarked posts, most recently saved first, optionally only those in one collection. Posts that are no longer visible are left out
+// @Tags bookmarks
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param collection_id query int false "Only bookmarks in this collection"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Bookmarks per page (1-100)" default(20)
+// @Success 200 {object} BookmarkListResponse "Successfully retrieved bookmarks"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /bookmarks [get]
+func (bc *BookmarkController) GetBookmarks(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	query := requestDB(c).Model(&models.Bookmark{}).Where("user_id = ? AND post_id IN (?)", user.ID, visiblePostIDs(c))
+	if v := c.Query("collection_id"); v != "" {
+		collectionID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "collection_id must be a collection ID"})
+			return
+		}
+		if _, ok := findCollection(c, uint(collectionID)); !ok {
+			return
+		}
+		query = query.Where("collection_id = ?", collectionID)
+	}
+
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bookmarks"})
+		return
+	}
+
+	bookmarks := []models.Bookmark{}
+	if err := query.Preload("Post").Order("created_at DESC").Order("post_id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&bookmarks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+
+	posts := make([]models.Post, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		if bookmark.Post != nil {
+			posts = append(posts, *bookmark.Post)
+		}
+	}
+	if err := loadPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	for i, j := 0, 0; i < len(bookmarks); i++ {
+		if bookmarks[i].Post != nil {
+			bookmarks[i].Post = &posts[j]
+			j++
+		}
+	}
+
+	c.JSON(http.StatusOK, BookmarkListResponse{Data: bookmarks, Pagination: pagination})
+}
+
+// @Summary List bookmark collections
+// @Description Get the current user's bookmark collections with how many bookmarks each holds, in alphabetical order
+// @Tags bookmarks
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Collections per page (1-100)" default(20)
+// @Success 200 {object} CollectionListResponse "Successfully retrieved collections"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /bookmarks/collections [get]
+func (bc *BookmarkController) GetCollections(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	query := requestDB(c).Model(&models.Collection{}).Where("user_id = ?", user.ID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count collections"})
+		return
+	}
+
+	collections := []models.Collection{}
+	if err := query.Order("name").Order("id").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collections"})
+		return
+	}
+	if err := loadCollectionCounts(requestDB(c), collections); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bookmarks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CollectionListResponse{Data: collections, Pagination: pagination})
+}
+
+// @Summary Create a bookmark collection
+// @Description Create a named collection to organise bookmarks. Names are 1-64 characters and unique per user
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param collection body CollectionRequest true "Collection to create"
+// @Success 201 {object} models.Collection "Successfully created collection"
+// @Failure 400 {object} map[string]string "Invalid name"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 409 {object} map[string]string "Name already used"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /bookmarks/collections [post]
+func (bc *BookmarkController) CreateCollection(c *gin.Context) {
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, ok := validCollectionName(c, req.Name, 0)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	collection := models.Collection{UserID: user.ID, Name: name}
+	if err := requestDB(c).Create(&collection).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create collection"})
+		return
+	}
+	recordAudit(c, models.AuditCreate, resourceCollection, collection.ID, nil, collection)
+	c.JSON(http.StatusCreated, collection)
+}
+
+// @Summary Rename a bookmark collection
+// @Description Rename one of the current user's bookmark collections
+// @Tags bookmarks
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Collection ID"
+// @Param collection body CollectionRequest true "New name"
+// @Success 200 {object} models.Collection "Successfully renamed collection"
+// @Failure 400 {object} map[string]string "Invalid collection ID or name"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 409 {object} map[string]string "Name already used"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /bookmarks/collections/{id} [put]
+func (bc *BookmarkController) UpdateCollection(c *gin.Context) {
+	collection, ok := findCollectionParam(c)
+	if !ok {
+		return
+	}
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, ok := validCollectionName(c, req.Name, collection.ID)
+	if !ok {
+		return
+	}
+
+	before := *collection
+	collection.Name = name
+	if err := requestDB(c).Model(collection).Update("name", name).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, resourceCollection, collection.ID, before, *collection)
+
+	collections := []models.Collection{*collection}
+	if err := loadCollectionCounts(requestDB(c), collections); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count bookmarks"})
+		return
+	}
+	c.JSON(http.StatusOK, collections[0])
+}
+
+// @Summary Delete a bookmark collection
+// @Description Delete one of the current user's bookmark collections. Bookmarks in it are kept outside any collection
+// @Tags bookmarks
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Collection ID"
+// @Success 200 {object} map[string]string "Message: Collection deleted"
+// @Failure 400 {object} map[string]string "Invalid collection ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Collection not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /bookmarks/collections/{id} [delete]
+func (bc *BookmarkController) DeleteCollection(c *gin.Context) {
+	collection, ok := findCollectionParam(c)
+	if !ok {
+		return
+	}
+
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).
+			Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceCollection, collection.ID, *collection, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted"})
+}
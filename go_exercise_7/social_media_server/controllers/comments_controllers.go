@@ -2,9 +2,18 @@ package controllers
 
 import (
 	"errors"
+	"log"
 	"net/http"
+	"social_media_server/audit"
 	"social_media_server/config"
+	"social_media_server/contentcheck"
+	"social_media_server/middleware"
 	"social_media_server/models"
+	"social_media_server/poststats"
+	"social_media_server/reactions"
+	"social_media_server/render"
+	"social_media_server/revision"
+	"social_media_server/tagging"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -13,19 +22,129 @@ import (
 
 type CommentController struct{}
 
+type CommentListResponse struct {
+	Data       []models.Comment `json:"data"`
+	Pagination Pagination       `json:"pagination"`
+}
+
 func NewCommentController() *CommentController {
 	return &CommentController{}
 }
 
+// @Summary List comments of a post
+// @Description Get the comments of a post page by page, each with its vote and emoji reaction counts
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Comments per page (1-100)" default(20)
+// @Param order query string false "Sort order by creation time" Enums(asc, desc) default(asc)
+// @Success 200 {object} CommentListResponse "Successfully retrieved comments"
+// @Failure 400 {object} map[string]string "Invalid post ID or query parameters"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/comments [get]
+func (cc *CommentController) GetPostComments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := c.DefaultQuery("order", "asc")
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+		return
+	}
+
+	var post models.Post
+	if err := visiblePosts(c, requestDB(c), models.PostPublished, models.PostArchived).First(&post, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+		return
+	}
+
+	query := visible(c, readDB(c).Model(&models.Comment{})).Where("post_id = ?", post.ID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count comments"})
+		return
+	}
+
+	comments := []models.Comment{}
+	if err := query.Order("created_at " + order).Order("id " + order).
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	if err := loadCommentReactions(c, comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CommentListResponse{Data: comments, Pagination: pagination})
+}
+
+// @Summary Get a single comment by ID
+// @Description Get details of a specific comment by its ID, including its vote and emoji reaction counts
+// @Tags comments
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Comment ID"
+// @Success 200 {object} models.Comment "Successfully retrieved comment"
+// @Failure 400 {object} map[string]string "Invalid comment ID"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id} [get]
+func (cc *CommentController) GetComment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	var comment models.Comment
+	if err := visibleComments(c, requestDB(c)).First(&comment, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment"})
+		return
+	}
+	comments := []models.Comment{comment}
+	if err := loadCommentReactions(c, comments); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	c.JSON(http.StatusOK, comments[0])
+}
+
 // @Summary Create a new comment for a post
-// @Description Create a new comment with content and associate it with a PostID
+// @Description Create a new comment with content and associate it with a PostID. Set parent_id to reply to another comment of the same post. content_format may be "plain" (default) or "markdown". The content filter may reject the comment, mask parts of it or flag it for review. @mentions and #hashtags in the content are listed in entities with their character offsets, and mentioned users are notified
 // @Tags comments
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param comment body models.Comment true "Comment object that needs to be created (ensure PostID is valid)"
+// @Param Idempotency-Key header string false "Unique key for safely retrying the request; repeats return the first response"
 // @Success 201 {object} models.Comment "Successfully created comment"
-// @Failure 400 {object} map[string]string "Bad Request (e.g., missing content or PostID)"
+// @Failure 400 {object} map[string]string "Bad Request (e.g., missing content or PostID, parent comment on another post, post not published)"
 // @Failure 404 {object} map[string]string "Post not found for the given PostID"
+// @Failure 409 {object} map[string]string "A request with the same Idempotency-Key is still being processed"
+// @Failure 422 {object} map[string]string "Rejected by the content filter, or Idempotency-Key reused with a different request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /comments [post]
 func (cc *CommentController) CreateComment(c *gin.Context) {
@@ -43,9 +162,13 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "PostID is required to create a comment"})
 		return
 	}
+	if comment.ContentFormat != "" && !render.ValidFormat(comment.ContentFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content_format, expected plain or markdown"})
+		return
+	}
 
 	var post models.Post
-	if err := config.DB.First(&post, comment.PostID).Error; err != nil {
+	if err := visiblePosts(c, requestDB(c), models.PostPublished, models.PostArchived).First(&post, comment.PostID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found, cannot create comment"})
 			return
@@ -53,25 +176,76 @@ func (cc *CommentController) CreateComment(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking post existence"})
 		return
 	}
+	if post.Status != models.PostPublished {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comments can only be added to published posts"})
+		return
+	}
+
+	if comment.ParentID != nil {
+		var parent models.Comment
+		if err := requestDB(c).First(&parent, *comment.ParentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error checking parent comment"})
+			return
+		}
+		if parent.PostID != comment.PostID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent comment belongs to another post"})
+			return
+		}
+	}
+
+	comment.UserID = middleware.CurrentUserID(c)
+	comment.Hidden, comment.EditedAt = false, nil
+
+	sub := newSubmission(c, models.TargetComment, false, contentcheck.Field{Name: "content", Value: &comment.Content})
+	check, ok := checkContent(c, sub)
+	if !ok {
+		return
+	}
 
-	if err := config.DB.Create(&comment).Error; err != nil {
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if _, err := tagging.Sync(tx, models.TargetComment, comment.ID, comment.Content); err != nil {
+			return err
+		}
+		if err := poststats.CommentAdded(tx, comment.PostID, comment.CreatedAt); err != nil {
+			return err
+		}
+		rev := revision.FromComment(&comment)
+		rev.EditorID = comment.UserID
+		return revision.Append(tx, &rev)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
+	contentSaved(c, sub, check, comment.ID)
+	recordAudit(c, models.AuditCreate, models.TargetComment, comment.ID, nil, comment)
+
+	if err := config.Notifier.CommentCreated(c.Request.Context(), &comment, &post); err != nil {
+		log.Printf("Failed to send notifications for comment %d: %v", comment.ID, err)
+	}
 
 	c.JSON(http.StatusCreated, comment)
 }
 
 // @Summary Update an existing comment
-// @Description Update the content of an existing comment by its ID
+// @Description Update the content of an existing comment by its ID. Comments with an owner can only be updated by that user. Every change is kept as a revision and marks the comment as edited
 // @Tags comments
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param id path int true "Comment ID"
-// @Param comment body models.Comment true "Comment object with updated content (only Content is used)"
+// @Param comment body models.Comment true "Comment object with updated content (only Content and ContentFormat are used)"
 // @Success 200 {object} models.Comment "Successfully updated comment"
 // @Failure 400 {object} map[string]string "Invalid comment ID or Bad Request (e.g., empty content)"
+// @Failure 403 {object} map[string]string "Not the owner of the comment"
 // @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 422 {object} map[string]string "Rejected by the content filter"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /comments/{id} [put]
 func (cc *CommentController) UpdateComment(c *gin.Context) {
@@ -83,7 +257,7 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 	}
 
 	var comment models.Comment
-	if err := config.DB.First(&comment, uint(id)).Error; err != nil {
+	if err := requestDB(c).First(&comment, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 			return
@@ -92,6 +266,13 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 		return
 	}
 
+	if !canModify(c, comment.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to modify this comment"})
+		return
+	}
+	before := audit.Snapshot(comment)
+	original := revision.FromComment(&comment)
+
 	var commentUpdates models.Comment
 	if err := c.ShouldBindJSON(&commentUpdates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -103,23 +284,45 @@ func (cc *CommentController) UpdateComment(c *gin.Context) {
 		return
 	}
 
+	if commentUpdates.ContentFormat != "" {
+		if !render.ValidFormat(commentUpdates.ContentFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content_format, expected plain or markdown"})
+			return
+		}
+		comment.ContentFormat = commentUpdates.ContentFormat
+	}
+
 	comment.Content = commentUpdates.Content
 
-	if err := config.DB.Save(&comment).Error; err != nil {
+	sub := newSubmission(c, models.TargetComment, true, contentcheck.Field{Name: "content", Value: &comment.Content})
+	check, ok := checkContent(c, sub)
+	if !ok {
+		return
+	}
+
+	edited := revision.FromComment(&comment)
+	edited.EditorID = middleware.CurrentUserID(c)
+	mentioned, err := saveEdit(requestDB(c), &comment, &comment.EditedAt, original, edited, comment.UserID, comment.CreatedAt)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
 		return
 	}
+	contentSaved(c, sub, check, comment.ID)
+	recordAudit(c, models.AuditUpdate, models.TargetComment, comment.ID, before, comment)
+	notifyMentioned(c, mentioned, &comment.PostID, &comment.ID)
 	c.JSON(http.StatusOK, comment)
 }
 
 // @Summary Delete a comment
-// @Description Delete a comment by its ID
+// @Description Delete a comment by its ID. Comments with an owner can only be deleted by that user, a moderator or a moderator of the post's community
 // @Tags comments
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param id path int true "Comment ID"
 // @Success 200 {object} map[string]string "Message: Comment deleted successfully"
 // @Failure 400 {object} map[string]string "Invalid comment ID"
+// @Failure 403 {object} map[string]string "Not the owner of the comment"
 // @Failure 404 {object} map[string]string "Comment not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /comments/{id} [delete]
@@ -132,7 +335,7 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 	}
 
 	var comment models.Comment
-	if err := config.DB.First(&comment, uint(id)).Error; err != nil {
+	if err := requestDB(c).First(&comment, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
 			return
@@ -141,9 +344,31 @@ func (cc *CommentController) DeleteComment(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Delete(&models.Comment{}, uint(id)).Error; err != nil {
+	if !canModerate(c, comment.UserID) {
+		var post models.Post
+		if err := requestDB(c).Select("id", "community_id").First(&post, comment.PostID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+			return
+		}
+		if !canModerateCommunity(c, post.CommunityID) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this comment"})
+			return
+		}
+	}
+
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Comment{}, uint(id))
+		if result.Error != nil || result.RowsAffected == 0 || comment.Hidden {
+			return result.Error
+		}
+		return poststats.CommentRemoved(tx, comment.PostID)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete comment"})
 		return
 	}
+	recordAudit(c, models.AuditDelete, models.TargetComment, comment.ID, comment, nil)
+	if err := reactions.Delete(requestDB(c), models.TargetComment, []uint{comment.ID}); err != nil {
+		log.Printf("Failed to delete reactions of comment %d: %v", comment.ID, err)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
 }
\ No newline at end of file
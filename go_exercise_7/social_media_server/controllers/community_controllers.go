@@ -0,0 +1,842 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CommunityController struct{}
+
+type CreateCommunityRequest struct {
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Rules       string `json:"rules"`
+	JoinPolicy  string `json:"join_policy"`
+}
+
+// UpdateCommunityRequest: trường bỏ trống (null) giữ nguyên giá trị cũ, slug không đổi được
+type UpdateCommunityRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Rules       *string `json:"rules"`
+	JoinPolicy  *string `json:"join_policy"`
+}
+
+type JoinCommunityRequest struct {
+	Message string `json:"message"`
+}
+
+type CommunityListResponse struct {
+	Data       []models.Community `json:"data"`
+	Pagination Pagination         `json:"pagination"`
+}
+
+type CommunityMemberListResponse struct {
+	Data       []models.CommunityMembership `json:"data"`
+	Pagination Pagination                   `json:"pagination"`
+}
+
+type JoinRequestListResponse struct {
+	Data       []models.CommunityJoinRequest `json:"data"`
+	Pagination Pagination                    `json:"pagination"`
+}
+
+type PostListResponse struct {
+	Data       []models.Post `json:"data"`
+	Pagination Pagination    `json:"pagination"`
+}
+
+func NewCommunityController() *CommunityController {
+	return &CommunityController{}
+}
+
+// findCommunityParam đọc community theo :slug và tự trả lỗi cho client nếu không tìm được
+func findCommunityParam(c *gin.Context) (*models.Community, bool) {
+	var community models.Community
+	if err := requestDB(c).Where("slug = ?", c.Param("slug")).First(&community).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Community not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve community"})
+		return nil, false
+	}
+	return &community, true
+}
+
+// requireCommunityModerator chỉ cho owner/moderator của community hoặc moderator toàn trang đi tiếp
+func requireCommunityModerator(c *gin.Context, community *models.Community) bool {
+	if canModerateCommunity(c, &community.ID) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "Only moderators of the community can do this"})
+	return false
+}
+
+func loadMemberCounts(db *gorm.DB, communities []models.Community) error {
+	if len(communities) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(communities))
+	for i := range communities {
+		ids[i] = communities[i].ID
+	}
+	var counts []struct {
+		CommunityID uint
+		Count       int64
+	}
+	if err := db.Model(&models.CommunityMembership{}).
+		Select("community_id, COUNT(*) AS count").
+		Where("community_id IN ?", ids).
+		Group("community_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		byID[row.CommunityID] = row.Count
+	}
+	for i := range communities {
+		communities[i].MemberCount = byID[communities[i].ID]
+	}
+	return nil
+}
+
+// @Summary Create a community
+// @Description Create a community. The slug (4-64 lowercase letters, digits or dashes) identifies it in URLs and cannot be changed. join_policy is "open" (default, anyone can join) or "approval" (moderators review join requests). The creator becomes its owner
+// @Tags communities
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param community body CreateCommunityRequest true "Community to create"
+// @Success 201 {object} models.Community "Successfully created community"
+// @Failure 400 {object} map[string]string "Invalid name, slug or join policy"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 409 {object} map[string]string "Slug already taken"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities [post]
+func (cc *CommunityController) CreateCommunity(c *gin.Context) {
+	var req CreateCommunityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1-100 characters"})
+		return
+	}
+	if !models.ValidSlug(req.Slug) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Slug must be 4-64 lowercase letters, digits or dashes and cannot start or end with a dash"})
+		return
+	}
+	if req.JoinPolicy == "" {
+		req.JoinPolicy = models.JoinOpen
+	}
+	if !models.ValidJoinPolicy(req.JoinPolicy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Join policy must be open or approval"})
+		return
+	}
+
+	var existing int64
+	if err := requestDB(c).Unscoped().Model(&models.Community{}).Where("slug = ?", req.Slug).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already taken"})
+		return
+	}
+
+	owner, _ := middleware.CurrentUser(c)
+	community := models.Community{
+		Name:        req.Name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		Rules:       req.Rules,
+		JoinPolicy:  req.JoinPolicy,
+		OwnerID:     owner.ID,
+	}
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&community).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.CommunityMembership{CommunityID: community.ID, UserID: owner.ID, Role: models.CommunityOwner}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create community"})
+		return
+	}
+	community.MemberCount = 1
+
+	recordAudit(c, models.AuditCreate, resourceCommunity, community.ID, nil, community)
+	c.JSON(http.StatusCreated, community)
+}
+
+// @Summary List communities
+// @Description Get communities with their member counts, newest first
+// @Tags communities
+// @Produce  json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Communities per page (1-100)" default(20)
+// @Success 200 {object} CommunityListResponse "Successfully retrieved communities"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities [get]
+func (cc *CommunityController) GetCommunities(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := readDB(c).Model(&models.Community{}).Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count communities"})
+		return
+	}
+
+	communities := []models.Community{}
+	if err := readDB(c).Order("created_at DESC, id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&communities).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve communities"})
+		return
+	}
+	if err := loadMemberCounts(readDB(c), communities); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CommunityListResponse{Data: communities, Pagination: pagination})
+}
+
+// @Summary Get a community
+// @Description Get a community by its slug with its rules and member count
+// @Tags communities
+// @Produce  json
+// @Param slug path string true "Community slug"
+// @Success 200 {object} models.Community "Successfully retrieved community"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug} [get]
+func (cc *CommunityController) GetCommunity(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	communities := []models.Community{*community}
+	if err := loadMemberCounts(requestDB(c), communities); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count members"})
+		return
+	}
+	c.JSON(http.StatusOK, communities[0])
+}
+
+// @Summary Update a community
+// @Description Update the name, description, rules or join policy of a community. Only its moderators (or site moderators) can do this; omitted fields keep their value
+// @Tags communities
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param community body UpdateCommunityRequest true "Fields to update"
+// @Success 200 {object} models.Community "Successfully updated community"
+// @Failure 400 {object} map[string]string "Invalid name or join policy"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not a moderator of the community"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug} [put]
+func (cc *CommunityController) UpdateCommunity(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	if !requireCommunityModerator(c, community) {
+		return
+	}
+
+	var req UpdateCommunityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	before := *community
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be 1-100 characters"})
+			return
+		}
+		community.Name = name
+	}
+	if req.Description != nil {
+		community.Description = *req.Description
+	}
+	if req.Rules != nil {
+		community.Rules = *req.Rules
+	}
+	if req.JoinPolicy != nil {
+		if !models.ValidJoinPolicy(*req.JoinPolicy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Join policy must be open or approval"})
+			return
+		}
+		community.JoinPolicy = *req.JoinPolicy
+	}
+
+	if err := requestDB(c).Save(community).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update community"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, resourceCommunity, community.ID, before, community)
+
+	communities := []models.Community{*community}
+	if err := loadMemberCounts(requestDB(c), communities); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count members"})
+		return
+	}
+	c.JSON(http.StatusOK, communities[0])
+}
+
+// @Summary Join a community
+// @Description Join an open community right away, or ask to join a community that requires approval. A rejected request can be sent again
+// @Tags communities
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param request body JoinCommunityRequest false "Optional message for the moderators (approval communities only)"
+// @Success 200 {object} models.CommunityMembership "Joined the community"
+// @Success 202 {object} models.CommunityJoinRequest "Join request waiting for approval"
+// @Failure 400 {object} map[string]string "Invalid message"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 409 {object} map[string]string "Already a member or a request is already pending"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/join [post]
+func (cc *CommunityController) JoinCommunity(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+
+	var req JoinCommunityRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if len(req.Message) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message must be at most 500 characters"})
+		return
+	}
+
+	membership, err := communityMembership(c, community.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check community membership"})
+		return
+	}
+	if membership != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already a member of this community"})
+		return
+	}
+	userID := *middleware.CurrentUserID(c)
+
+	if community.JoinPolicy == models.JoinOpen {
+		membership := models.CommunityMembership{CommunityID: community.ID, UserID: userID, Role: models.CommunityMember}
+		result := requestDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&membership)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join community"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "You are already a member of this community"})
+			return
+		}
+		recordAudit(c, models.AuditCreate, resourceCommunityMember, community.ID, nil, membership)
+		c.JSON(http.StatusOK, membership)
+		return
+	}
+
+	var request models.CommunityJoinRequest
+	err = requestDB(c).Where("community_id = ? AND user_id = ?", community.ID, userID).First(&request).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		request = models.CommunityJoinRequest{CommunityID: community.ID, UserID: userID, Message: req.Message, Status: models.JoinRequestPending}
+		result := requestDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&request)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create join request"})
+			return
+		}
+		if result.RowsAffected == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Your join request is already pending"})
+			return
+		}
+		recordAudit(c, models.AuditCreate, resourceJoinRequest, request.ID, nil, request)
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join request"})
+		return
+	case request.Status == models.JoinRequestPending:
+		c.JSON(http.StatusConflict, gin.H{"error": "Your join request is already pending"})
+		return
+	default:
+		// Yêu cầu cũ đã được xử lý (bị từ chối, hoặc được duyệt rồi rời community): mở lại
+		before := request
+		request.Message, request.Status = req.Message, models.JoinRequestPending
+		request.ResolvedByID, request.ResolvedAt = nil, nil
+		if err := requestDB(c).Save(&request).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create join request"})
+			return
+		}
+		recordAudit(c, models.AuditUpdate, resourceJoinRequest, request.ID, before, request)
+	}
+	c.JSON(http.StatusAccepted, request)
+}
+
+// @Summary Leave a community
+// @Description Leave a community. The owner cannot leave their own community
+// @Tags communities
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Success 200 {object} map[string]string "Message: Left community"
+// @Failure 400 {object} map[string]string "The owner cannot leave"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Community not found or not a member"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/leave [post]
+func (cc *CommunityController) LeaveCommunity(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+
+	membership, err := communityMembership(c, community.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check community membership"})
+		return
+	}
+	if membership == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of this community"})
+		return
+	}
+	if membership.Role == models.CommunityOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner cannot leave the community"})
+		return
+	}
+
+	if err := requestDB(c).Where("community_id = ? AND user_id = ?", community.ID, membership.UserID).Delete(&models.CommunityMembership{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave community"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceCommunityMember, community.ID, membership, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Left community"})
+}
+
+// @Summary List members of a community
+// @Description Get the members of a community with their roles, oldest first
+// @Tags communities
+// @Produce  json
+// @Param slug path string true "Community slug"
+// @Param role query string false "Only members with this role" Enums(member, moderator, owner)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Members per page (1-100)" default(20)
+// @Success 200 {object} CommunityMemberListResponse "Successfully retrieved members"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/members [get]
+func (cc *CommunityController) GetMembers(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+
+	query := readDB(c).Model(&models.CommunityMembership{}).Where("community_id = ?", community.ID)
+	if role := c.Query("role"); role != "" {
+		if role != models.CommunityMember && role != models.CommunityModerator && role != models.CommunityOwner {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of: member, moderator, owner"})
+			return
+		}
+		query = query.Where("role = ?", role)
+	}
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count members"})
+		return
+	}
+
+	members := []models.CommunityMembership{}
+	if err := query.Preload("User").
+		Order("created_at ASC, user_id ASC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&members).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+
+	c.JSON(http.StatusOK, CommunityMemberListResponse{Data: members, Pagination: pagination})
+}
+
+// findMemberParam đọc membership theo :user_id trong community và tự trả lỗi cho client nếu không tìm được
+func findMemberParam(c *gin.Context, community *models.Community) (*models.CommunityMembership, bool) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	var membership models.CommunityMembership
+	if err := requestDB(c).Where("community_id = ? AND user_id = ?", community.ID, uint(userID)).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not a member of this community"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve member"})
+		return nil, false
+	}
+	return &membership, true
+}
+
+// @Summary Remove a member from a community
+// @Description Remove a member from a community. Community moderators can remove regular members; only the owner (or site moderators) can remove moderators. The owner cannot be removed
+// @Tags communities
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param user_id path int true "User ID of the member"
+// @Success 200 {object} map[string]string "Message: Member removed"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not allowed to remove this member"
+// @Failure 404 {object} map[string]string "Community not found or not a member"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/members/{user_id} [delete]
+func (cc *CommunityController) RemoveMember(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	if !requireCommunityModerator(c, community) {
+		return
+	}
+	member, ok := findMemberParam(c, community)
+	if !ok {
+		return
+	}
+
+	if member.Role == models.CommunityOwner {
+		c.JSON(http.StatusForbidden, gin.H{"error": "The owner cannot be removed from the community"})
+		return
+	}
+	if member.IsModerator() && !isModerator(c) && *middleware.CurrentUserID(c) != community.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can remove moderators"})
+		return
+	}
+
+	if err := requestDB(c).Where("community_id = ? AND user_id = ?", community.ID, member.UserID).Delete(&models.CommunityMembership{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceCommunityMember, community.ID, member, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// @Summary Change the role of a community member
+// @Description Promote a member to community moderator or demote a moderator back to member. Only the owner (or site admins) can do this
+// @Tags communities
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param user_id path int true "User ID of the member"
+// @Param role body UpdateRoleRequest true "New role (member or moderator)"
+// @Success 200 {object} models.CommunityMembership "Successfully updated role"
+// @Failure 400 {object} map[string]string "Invalid user ID or role"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not the owner of the community"
+// @Failure 404 {object} map[string]string "Community not found or not a member"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/members/{user_id}/role [put]
+func (cc *CommunityController) UpdateMemberRole(c *gin.Context) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	current, _ := middleware.CurrentUser(c)
+	if current.ID != community.OwnerID && current.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change member roles"})
+		return
+	}
+	member, ok := findMemberParam(c, community)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Role != models.CommunityMember && req.Role != models.CommunityModerator {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be member or moderator"})
+		return
+	}
+	if member.Role == models.CommunityOwner {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The owner's role cannot be changed"})
+		return
+	}
+
+	before := *member
+	if err := requestDB(c).Model(&models.CommunityMembership{}).
+		Where("community_id = ? AND user_id = ?", community.ID, member.UserID).
+		Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	member.Role = req.Role
+	recordAudit(c, models.AuditUpdate, resourceCommunityMember, community.ID, before, member)
+	c.JSON(http.StatusOK, member)
+}
+
+// @Summary List join requests of a community
+// @Description Get the join requests of a community, oldest first. Only its moderators (or site moderators) can see them
+// @Tags communities
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param status query string false "Only requests with this status" Enums(pending, approved, rejected) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Requests per page (1-100)" default(20)
+// @Success 200 {object} JoinRequestListResponse "Successfully retrieved join requests"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not a moderator of the community"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/join_requests [get]
+func (cc *CommunityController) GetJoinRequests(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	if !requireCommunityModerator(c, community) {
+		return
+	}
+
+	status := c.DefaultQuery("status", models.JoinRequestPending)
+	if status != models.JoinRequestPending && status != models.JoinRequestApproved && status != models.JoinRequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of: pending, approved, rejected"})
+		return
+	}
+
+	query := requestDB(c).Model(&models.CommunityJoinRequest{}).Where("community_id = ? AND status = ?", community.ID, status)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count join requests"})
+		return
+	}
+
+	requests := []models.CommunityJoinRequest{}
+	if err := query.Order("updated_at ASC, id ASC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, JoinRequestListResponse{Data: requests, Pagination: pagination})
+}
+
+// @Summary Approve a join request
+// @Description Approve a pending join request; the user becomes a member of the community
+// @Tags communities
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param id path int true "Join request ID"
+// @Success 200 {object} models.CommunityJoinRequest "Join request approved"
+// @Failure 400 {object} map[string]string "Invalid join request ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not a moderator of the community"
+// @Failure 404 {object} map[string]string "Community or join request not found"
+// @Failure 409 {object} map[string]string "Join request already resolved"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/join_requests/{id}/approve [post]
+func (cc *CommunityController) ApproveJoinRequest(c *gin.Context) {
+	cc.resolveJoinRequest(c, models.JoinRequestApproved)
+}
+
+// @Summary Reject a join request
+// @Description Reject a pending join request. The user may ask again later
+// @Tags communities
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param slug path string true "Community slug"
+// @Param id path int true "Join request ID"
+// @Success 200 {object} models.CommunityJoinRequest "Join request rejected"
+// @Failure 400 {object} map[string]string "Invalid join request ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not a moderator of the community"
+// @Failure 404 {object} map[string]string "Community or join request not found"
+// @Failure 409 {object} map[string]string "Join request already resolved"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/join_requests/{id}/reject [post]
+func (cc *CommunityController) RejectJoinRequest(c *gin.Context) {
+	cc.resolveJoinRequest(c, models.JoinRequestRejected)
+}
+
+func (cc *CommunityController) resolveJoinRequest(c *gin.Context, status string) {
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+	if !requireCommunityModerator(c, community) {
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join request ID"})
+		return
+	}
+	var request models.CommunityJoinRequest
+	if err := requestDB(c).Where("id = ? AND community_id = ?", uint(id), community.ID).First(&request).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Join request not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve join request"})
+		return
+	}
+
+	before := request
+	now := time.Now()
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		// Chỉ một moderator xử lý được yêu cầu khi nhiều người bấm cùng lúc
+		result := tx.Model(&models.CommunityJoinRequest{}).
+			Where("id = ? AND status = ?", request.ID, models.JoinRequestPending).
+			Updates(map[string]interface{}{"status": status, "resolved_by_id": middleware.CurrentUserID(c), "resolved_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errJoinRequestResolved
+		}
+		if status != models.JoinRequestApproved {
+			return nil
+		}
+		membership := models.CommunityMembership{CommunityID: community.ID, UserID: request.UserID, Role: models.CommunityMember}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership).Error
+	})
+	if errors.Is(err, errJoinRequestResolved) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Join request has already been resolved"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve join request"})
+		return
+	}
+
+	request.Status, request.ResolvedByID, request.ResolvedAt = status, middleware.CurrentUserID(c), &now
+	recordAudit(c, models.AuditUpdate, resourceJoinRequest, request.ID, before, request)
+	c.JSON(http.StatusOK, request)
+}
+
+var errJoinRequestResolved = errors.New("join request already resolved")
+
+// @Summary List posts of a community
+// @Description Get the published posts of a community, newest first unless sort says otherwise, plus the current user's own drafts, scheduled and archived posts in it. Accepts the same status, sort, window, include and comments_limit parameters as the main post listing
+// @Tags communities
+// @Produce  json
+// @Param slug path string true "Community slug"
+// @Param status query string false "Only posts with this status" Enums(draft, scheduled, published, archived)
+// @Param sort query string false "Ordering: new (newest first), hot (recent and popular), top (highest score), discussed (most comments) or active (latest comment first)" Enums(new, hot, top, discussed, active) default(new)
+// @Param window query string false "Only posts published within this window, for sort=top and sort=discussed" Enums(day, week, month, all) default(day)
+// @Param include query string false "Comma-separated relations to embed: comments, attachments (default: all)"
+// @Param comments_limit query int false "Embed at most this many of the latest comments per post (1-100)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Posts per page (1-100)" default(20)
+// @Success 200 {object} PostListResponse "Successfully retrieved posts"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 404 {object} map[string]string "Community not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /communities/{slug}/posts [get]
+func (cc *CommunityController) GetCommunityPosts(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inc, err := parsePostIncludes(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := parsePostSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	community, ok := findCommunityParam(c)
+	if !ok {
+		return
+	}
+
+	query, err := postListQuery(c, readDB(c).Model(&models.Post{}).Where("community_id = ?", community.ID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = sort.filter(query)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count posts"})
+		return
+	}
+
+	posts := []models.Post{}
+	if err := sort.order(inc.preload(query)).
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		return
+	}
+	if err := inc.loadLimited(readDB(c), posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	if err := loadPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	for i := range posts {
+		signAttachmentURLs(c, posts[i].Attachments)
+	}
+
+	c.JSON(http.StatusOK, PostListResponse{Data: posts, Pagination: pagination})
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/contentcheck"
+	"social_media_server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSubmission gói các trường văn bản sắp lưu để đưa qua bộ lọc nội dung
+func newSubmission(c *gin.Context, targetType string, update bool, fields ...contentcheck.Field) *contentcheck.Submission {
+	author := "ip:" + c.ClientIP()
+	if userID := middleware.CurrentUserID(c); userID != nil {
+		author = fmt.Sprintf("user:%d", *userID)
+	}
+	return &contentcheck.Submission{TargetType: targetType, Author: author, Update: update, Fields: fields}
+}
+
+// checkContent chạy bộ lọc trước khi lưu. Trả về false nếu nội dung bị từ chối hoặc lỗi
+// (đã trả response cho client); các trường có thể đã bị che nếu rule có action mask.
+func checkContent(c *gin.Context, sub *contentcheck.Submission) (*contentcheck.Result, bool) {
+	result, err := config.ContentCheck.Check(c.Request.Context(), sub)
+	if err != nil {
+		log.Printf("Content check failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check content"})
+		return nil, false
+	}
+	if result.Rejected() {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": result.Reason(contentcheck.ActionReject)})
+		return nil, false
+	}
+	return result, true
+}
+
+// contentSaved ghi nhận nội dung đã lưu và gửi nội dung bị gắn cờ vào hàng đợi kiểm duyệt
+func contentSaved(c *gin.Context, sub *contentcheck.Submission, result *contentcheck.Result, targetID uint) {
+	ctx := c.Request.Context()
+	if err := config.ContentCheck.Accepted(ctx, sub); err != nil {
+		log.Printf("Failed to record %s %d for content checks: %v", sub.TargetType, targetID, err)
+	}
+	if result.Flagged() {
+		if _, err := config.Moderator.Report(ctx, nil, sub.TargetType, targetID, result.Reason(contentcheck.ActionFlag)); err != nil {
+			log.Printf("Failed to flag %s %d for review: %v", sub.TargetType, targetID, err)
+		}
+	}
+}
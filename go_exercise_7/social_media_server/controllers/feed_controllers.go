@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/feed"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxFeedLimit = 100
+
+type FeedController struct{}
+
+type FeedResponse struct {
+	Data       []models.Post `json:"data"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func NewFeedController() *FeedController {
+	return &FeedController{}
+}
+
+// @Summary Get the home feed
+// @Description Get posts from the users the current user follows, newest first. Pass next_cursor from the previous response as cursor to get the next page
+// @Tags feed
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param cursor query string false "Cursor returned by the previous page"
+// @Param limit query int false "Posts per page (1-100)" default(20)
+// @Success 200 {object} FeedResponse "Successfully retrieved feed"
+// @Failure 400 {object} map[string]string "Invalid cursor or limit"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /feed [get]
+func (fc *FeedController) GetFeed(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	limit := defaultPageSize
+	if v := c.Query("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > maxFeedLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxFeedLimit)})
+			return
+		}
+		limit = l
+	}
+
+	cursor, err := feed.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+		return
+	}
+
+	posts, next, err := config.Timeline.Page(c.Request.Context(), user.ID, cursor, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve feed"})
+		return
+	}
+
+	resp := FeedResponse{Data: posts}
+	if resp.Data == nil {
+		resp.Data = []models.Post{}
+	}
+	if err := loadPostReactions(c, resp.Data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, resp.Data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	for i := range resp.Data {
+		signAttachmentURLs(c, resp.Data[i].Attachments)
+	}
+	if next != nil {
+		resp.NextCursor = next.Encode()
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,696 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxMessageLength = 5000
+
+// MessageController: nội dung tin nhắn là riêng tư nên không được ghi vào audit log, chỉ ghi việc tạo hội thoại
+type MessageController struct{}
+
+type CreateConversationRequest struct {
+	ParticipantIDs []uint `json:"participant_ids"`
+	Title          string `json:"title"`
+}
+
+type SendMessageRequest struct {
+	Body string `json:"body"`
+}
+
+type MarkConversationReadRequest struct {
+	MessageID *uint `json:"message_id"`
+}
+
+type ConversationListResponse struct {
+	Data       []models.Conversation `json:"data"`
+	Pagination Pagination            `json:"pagination"`
+}
+
+type MessageListResponse struct {
+	Data       []models.Message `json:"data"`
+	Pagination Pagination       `json:"pagination"`
+}
+
+func NewMessageController() *MessageController {
+	return &MessageController{}
+}
+
+// sealMessage lưu tin nhắn (mã hoá nếu bật MessageCipher), m.Body vẫn là nội dung gốc sau khi lưu
+func sealMessage(tx *gorm.DB, m *models.Message) error {
+	plaintext := m.Body
+	m.Encrypted = false
+	if config.MessageCipher != nil {
+		encrypted, err := config.MessageCipher.Encrypt(plaintext)
+		if err != nil {
+			return err
+		}
+		m.Body, m.Encrypted = encrypted, true
+	}
+	err := tx.Save(m).Error
+	m.Body = plaintext
+	return err
+}
+
+func openMessages(messages []models.Message) error {
+	for i := range messages {
+		if !messages[i].Encrypted {
+			continue
+		}
+		if config.MessageCipher == nil {
+			return errors.New("message is encrypted but MESSAGE_ENCRYPTION_KEY is not set")
+		}
+		body, err := config.MessageCipher.Decrypt(messages[i].Body)
+		if err != nil {
+			return err
+		}
+		messages[i].Body = body
+	}
+	return nil
+}
+
+func validMessageBody(c *gin.Context, body string) (string, bool) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxMessageLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message body must be 1-" + strconv.Itoa(maxMessageLength) + " characters"})
+		return "", false
+	}
+	return body, true
+}
+
+// loadUnreadCounts đếm tin nhắn của người khác mới hơn tin nhắn cuối userID đã đọc trong từng hội thoại
+func loadUnreadCounts(db *gorm.DB, userID uint, conversations []models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(conversations))
+	for i := range conversations {
+		ids[i] = conversations[i].ID
+	}
+	var counts []struct {
+		ConversationID uint
+		Count          int64
+	}
+	if err := unreadMessages(db, userID).
+		Select("messages.conversation_id, COUNT(*) AS count").
+		Where("messages.conversation_id IN ?", ids).
+		Group("messages.conversation_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		byID[row.ConversationID] = row.Count
+	}
+	for i := range conversations {
+		conversations[i].UnreadCount = byID[conversations[i].ID]
+	}
+	return nil
+}
+
+func unreadMessages(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Model(&models.Message{}).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id AND conversation_participants.user_id = ?", userID).
+		Where("messages.sender_id <> ? AND messages.id > conversation_participants.last_read_message_id", userID)
+}
+
+// findConversationParam đọc hội thoại theo :id mà người dùng hiện tại đang tham gia; hội thoại của
+// người khác trả 404 như không tồn tại
+func findConversationParam(c *gin.Context) (*models.Conversation, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid conversation ID"})
+		return nil, false
+	}
+
+	var conversation models.Conversation
+	err = requestDB(c).Joins("JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id AND conversation_participants.user_id = ?", *middleware.CurrentUserID(c)).
+		First(&conversation, uint(id)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversation"})
+		return nil, false
+	}
+	return &conversation, true
+}
+
+// respondConversation trả hội thoại kèm người tham gia (và read receipt của họ) cùng số tin chưa đọc
+func respondConversation(c *gin.Context, status int, conversation *models.Conversation) {
+	if err := requestDB(c).Preload("Participants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, user_id ASC")
+	}).Preload("Participants.User").First(conversation, conversation.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve participants"})
+		return
+	}
+	conversations := []models.Conversation{*conversation}
+	if err := loadUnreadCounts(requestDB(c), *middleware.CurrentUserID(c), conversations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread messages"})
+		return
+	}
+	c.JSON(status, conversations[0])
+}
+
+// directBlocked: trong hội thoại 1-1, một trong hai người đã chặn người kia
+func directBlocked(db *gorm.DB, conversation *models.Conversation, userID uint) (bool, error) {
+	if conversation.IsGroup {
+		return false, nil
+	}
+	var other models.ConversationParticipant
+	err := db.Where("conversation_id = ? AND user_id <> ?", conversation.ID, userID).First(&other).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return blocked(db, userID, other.UserID)
+}
+
+// messageAudit là phần của tin nhắn được ghi vào audit log; nội dung tin nhắn không bao giờ được ghi
+type messageAudit struct {
+	ConversationID uint       `json:"conversation_id"`
+	MessageID      uint       `json:"message_id"`
+	SenderID       uint       `json:"sender_id"`
+	EditedAt       *time.Time `json:"edited_at,omitempty"`
+}
+
+func auditedMessage(message *models.Message) messageAudit {
+	return messageAudit{ConversationID: message.ConversationID, MessageID: message.ID, SenderID: message.SenderID, EditedAt: message.EditedAt}
+}
+
+// @Summary Start a conversation
+// @Description Start a direct conversation with one user, or a group conversation with several users (at most 20 people including yourself). Starting a direct conversation that already exists returns it with status 200. title is only used for groups. Users who blocked you or whom you blocked cannot be added
+// @Tags messages
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param conversation body CreateConversationRequest true "Other participants and optional group title"
+// @Success 200 {object} models.Conversation "Existing direct conversation"
+// @Success 201 {object} models.Conversation "Successfully created conversation"
+// @Failure 400 {object} map[string]string "Invalid participants or title"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Blocked user"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations [post]
+func (mc *MessageController) CreateConversation(c *gin.Context) {
+	var req CreateConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	current, _ := middleware.CurrentUser(c)
+
+	seen := map[uint]bool{current.ID: true}
+	var others []uint
+	for _, id := range req.ParticipantIDs {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	if len(others) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "participant_ids must contain at least one other user"})
+		return
+	}
+	if len(others)+1 > models.MaxGroupParticipants {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A conversation can have at most " + strconv.Itoa(models.MaxGroupParticipants) + " participants"})
+		return
+	}
+	isGroup := len(others) > 1
+	title := strings.TrimSpace(req.Title)
+	if !isGroup {
+		title = ""
+	}
+	if len(title) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Title must be at most 100 characters"})
+		return
+	}
+
+	var found int64
+	if err := requestDB(c).Model(&models.User{}).Where("id IN ?", others).Count(&found).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+	if found != int64(len(others)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var blocks int64
+	if err := requestDB(c).Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id IN ?) OR (blocker_id IN ? AND blocked_id = ?)", current.ID, others, others, current.ID).
+		Count(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocked users"})
+		return
+	}
+	if blocks > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot start a conversation with a user you blocked or who blocked you"})
+		return
+	}
+
+	conversation := models.Conversation{Title: title, IsGroup: isGroup, CreatorID: current.ID, LastMessageAt: time.Now()}
+	if !isGroup {
+		key := models.DirectConversationKey(current.ID, others[0])
+		conversation.DirectKey = &key
+	}
+	created := false
+	err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&conversation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Hội thoại 1-1 giữa hai người đã có (có thể vừa được tạo bởi request song song)
+			return tx.Where("direct_key = ?", *conversation.DirectKey).First(&conversation).Error
+		}
+		created = true
+		participants := make([]models.ConversationParticipant, 0, len(others)+1)
+		for _, id := range append([]uint{current.ID}, others...) {
+			participants = append(participants, models.ConversationParticipant{ConversationID: conversation.ID, UserID: id})
+		}
+		return tx.Create(&participants).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create conversation"})
+		return
+	}
+
+	if !created {
+		respondConversation(c, http.StatusOK, &conversation)
+		return
+	}
+	recordAudit(c, models.AuditCreate, resourceConversation, conversation.ID, nil, conversation)
+	respondConversation(c, http.StatusCreated, &conversation)
+}
+
+// @Summary List conversations
+// @Description Get the conversations of the current user with their participants, read receipts and unread message counts, most recently active first
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Conversations per page (1-100)" default(20)
+// @Success 200 {object} ConversationListResponse "Successfully retrieved conversations"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations [get]
+func (mc *MessageController) GetConversations(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	userID := *middleware.CurrentUserID(c)
+
+	query := requestDB(c).Model(&models.Conversation{}).
+		Joins("JOIN conversation_participants ON conversation_participants.conversation_id = conversations.id AND conversation_participants.user_id = ?", userID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count conversations"})
+		return
+	}
+
+	conversations := []models.Conversation{}
+	if err := query.Preload("Participants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC, user_id ASC")
+	}).Preload("Participants.User").
+		Order("conversations.last_message_at DESC, conversations.id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&conversations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations"})
+		return
+	}
+	if err := loadUnreadCounts(requestDB(c), userID, conversations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ConversationListResponse{Data: conversations, Pagination: pagination})
+}
+
+// @Summary Count unread messages
+// @Description Get the number of unread direct messages of the current user across all conversations
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} UnreadCountResponse "Unread count"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/unread_count [get]
+func (mc *MessageController) GetUnreadCount(c *gin.Context) {
+	var count int64
+	if err := unreadMessages(requestDB(c), *middleware.CurrentUserID(c)).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count unread messages"})
+		return
+	}
+	c.JSON(http.StatusOK, UnreadCountResponse{UnreadCount: count})
+}
+
+// @Summary Get a conversation
+// @Description Get a conversation of the current user with its participants, their read receipts (last_read_message_id) and the unread message count
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} models.Conversation "Successfully retrieved conversation"
+// @Failure 400 {object} map[string]string "Invalid conversation ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Conversation not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/{id} [get]
+func (mc *MessageController) GetConversation(c *gin.Context) {
+	conversation, ok := findConversationParam(c)
+	if !ok {
+		return
+	}
+	respondConversation(c, http.StatusOK, conversation)
+}
+
+// @Summary Leave a group conversation
+// @Description Leave a group conversation. Direct conversations cannot be left
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Conversation ID"
+// @Success 200 {object} map[string]string "Message: Left conversation"
+// @Failure 400 {object} map[string]string "Invalid conversation ID or direct conversation"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Conversation not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/{id}/leave [post]
+func (mc *MessageController) LeaveConversation(c *gin.Context) {
+	conversation, ok := findConversationParam(c)
+	if !ok {
+		return
+	}
+	if !conversation.IsGroup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot leave a direct conversation"})
+		return
+	}
+
+	userID := *middleware.CurrentUserID(c)
+	if err := requestDB(c).Where("conversation_id = ? AND user_id = ?", conversation.ID, userID).
+		Delete(&models.ConversationParticipant{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave conversation"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceParticipant, conversation.ID,
+		models.ConversationParticipant{ConversationID: conversation.ID, UserID: userID}, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Left conversation"})
+}
+
+// @Summary List messages of a conversation
+// @Description Get the messages of a conversation of the current user, newest first
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Conversation ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Messages per page (1-100)" default(20)
+// @Success 200 {object} MessageListResponse "Successfully retrieved messages"
+// @Failure 400 {object} map[string]string "Invalid conversation ID or query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Conversation not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/{id}/messages [get]
+func (mc *MessageController) GetMessages(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	conversation, ok := findConversationParam(c)
+	if !ok {
+		return
+	}
+
+	query := requestDB(c).Model(&models.Message{}).Where("conversation_id = ?", conversation.ID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count messages"})
+		return
+	}
+
+	messages := []models.Message{}
+	if err := query.Order("id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&messages).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+		return
+	}
+	if err := openMessages(messages); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decrypt messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MessageListResponse{Data: messages, Pagination: pagination})
+}
+
+// @Summary Send a message
+// @Description Send a message to a conversation of the current user. Messages cannot be sent in a direct conversation when either user has blocked the other. The message counts as read by its sender
+// @Tags messages
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Conversation ID"
+// @Param message body SendMessageRequest true "Message body (1-5000 characters)"
+// @Param Idempotency-Key header string false "Unique key for safely retrying the request; repeats return the first response"
+// @Success 201 {object} models.Message "Successfully sent message"
+// @Failure 400 {object} map[string]string "Invalid conversation ID or body"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Blocked user"
+// @Failure 404 {object} map[string]string "Conversation not found"
+// @Failure 409 {object} map[string]string "A request with the same Idempotency-Key is still being processed"
+// @Failure 422 {object} map[string]string "Idempotency-Key reused with a different request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/{id}/messages [post]
+func (mc *MessageController) SendMessage(c *gin.Context) {
+	conversation, ok := findConversationParam(c)
+	if !ok {
+		return
+	}
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := validMessageBody(c, req.Body)
+	if !ok {
+		return
+	}
+
+	userID := *middleware.CurrentUserID(c)
+	isBlocked, err := directBlocked(requestDB(c), conversation, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocked users"})
+		return
+	}
+	if isBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message a user you blocked or who blocked you"})
+		return
+	}
+
+	message := models.Message{ConversationID: conversation.ID, SenderID: userID, Body: body}
+	err = requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := sealMessage(tx, &message); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Conversation{}).Where("id = ?", conversation.ID).
+			Update("last_message_at", message.CreatedAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ?", conversation.ID, userID).
+			Updates(map[string]interface{}{"last_read_message_id": message.ID, "last_read_at": message.CreatedAt}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+		return
+	}
+	recordAudit(c, models.AuditCreate, resourceMessage, message.ID, nil, auditedMessage(&message))
+	c.JSON(http.StatusCreated, message)
+}
+
+// @Summary Mark a conversation as read
+// @Description Move the read receipt of the current user forward to message_id, or to the latest message when it is omitted. Read receipts never move backwards
+// @Tags messages
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Conversation ID"
+// @Param request body MarkConversationReadRequest false "Last message read"
+// @Success 200 {object} models.Conversation "Conversation with updated read receipts"
+// @Failure 400 {object} map[string]string "Invalid conversation or message ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Conversation or message not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /conversations/{id}/read [post]
+func (mc *MessageController) MarkRead(c *gin.Context) {
+	conversation, ok := findConversationParam(c)
+	if !ok {
+		return
+	}
+	var req MarkConversationReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var message models.Message
+	query := requestDB(c).Select("id").Where("conversation_id = ?", conversation.ID)
+	if req.MessageID != nil {
+		query = query.Where("id = ?", *req.MessageID)
+	}
+	err := query.Order("id DESC").First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) && req.MessageID != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve message"})
+		return
+	}
+
+	if message.ID > 0 {
+		userID, now := *middleware.CurrentUserID(c), time.Now()
+		result := requestDB(c).Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ? AND last_read_message_id < ?", conversation.ID, userID, message.ID).
+			Updates(map[string]interface{}{"last_read_message_id": message.ID, "last_read_at": now})
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark conversation as read"})
+			return
+		}
+		if result.RowsAffected > 0 {
+			recordAudit(c, models.AuditUpdate, resourceParticipant, conversation.ID, nil, models.ConversationParticipant{
+				ConversationID: conversation.ID, UserID: userID, LastReadMessageID: message.ID, LastReadAt: &now,
+			})
+		}
+	}
+	respondConversation(c, http.StatusOK, conversation)
+}
+
+// findOwnMessageParam đọc tin nhắn theo :id; chỉ người gửi (vẫn còn trong hội thoại) được sửa/xoá
+func findOwnMessageParam(c *gin.Context) (*models.Message, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+		return nil, false
+	}
+
+	userID := *middleware.CurrentUserID(c)
+	var message models.Message
+	err = requestDB(c).Joins("JOIN conversation_participants ON conversation_participants.conversation_id = messages.conversation_id AND conversation_participants.user_id = ?", userID).
+		First(&message, uint(id)).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve message"})
+		return nil, false
+	}
+	if message.SenderID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own messages"})
+		return nil, false
+	}
+	return &message, true
+}
+
+// @Summary Edit a message
+// @Description Edit the body of a message sent by the current user. Messages in a direct conversation cannot be edited while either user blocks the other
+// @Tags messages
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Message ID"
+// @Param message body SendMessageRequest true "New message body (1-5000 characters)"
+// @Success 200 {object} models.Message "Successfully updated message"
+// @Failure 400 {object} map[string]string "Invalid message ID or body"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not the sender of the message, or blocked user"
+// @Failure 404 {object} map[string]string "Message not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /messages/{id} [put]
+func (mc *MessageController) UpdateMessage(c *gin.Context) {
+	message, ok := findOwnMessageParam(c)
+	if !ok {
+		return
+	}
+	var req SendMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body, ok := validMessageBody(c, req.Body)
+	if !ok {
+		return
+	}
+
+	// Như khi gửi: không được sửa tin trong hội thoại 1-1 khi một bên đã chặn bên kia
+	var conversation models.Conversation
+	if err := requestDB(c).First(&conversation, message.ConversationID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversation"})
+		return
+	}
+	isBlocked, err := directBlocked(requestDB(c), &conversation, message.SenderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check blocked users"})
+		return
+	}
+	if isBlocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot message a user you blocked or who blocked you"})
+		return
+	}
+
+	before := auditedMessage(message)
+	now := time.Now()
+	message.Body, message.EditedAt = body, &now
+	if err := sealMessage(requestDB(c), message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update message"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, resourceMessage, message.ID, before, auditedMessage(message))
+	c.JSON(http.StatusOK, message)
+}
+
+// @Summary Delete a message
+// @Description Delete a message sent by the current user. It disappears for every participant
+// @Tags messages
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Message ID"
+// @Success 200 {object} map[string]string "Message: Message deleted"
+// @Failure 400 {object} map[string]string "Invalid message ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Not the sender of the message"
+// @Failure 404 {object} map[string]string "Message not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /messages/{id} [delete]
+func (mc *MessageController) DeleteMessage(c *gin.Context) {
+	message, ok := findOwnMessageParam(c)
+	if !ok {
+		return
+	}
+	if err := requestDB(c).Delete(&models.Message{}, message.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete message"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceMessage, message.ID, auditedMessage(message), nil)
+	c.JSON(http.StatusOK, gin.H{"message": "Message deleted"})
+}
@@ -0,0 +1,488 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social_media_server/audit"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"social_media_server/moderation"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxReportReasonLength = 500
+
+type ModerationController struct{}
+
+type CreateReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetID   uint   `json:"target_id"`
+	Reason     string `json:"reason"`
+}
+
+type ResolveReportRequest struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
+
+type ModerationNoteRequest struct {
+	Note string `json:"note"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type ReportListResponse struct {
+	Data       []models.Report `json:"data"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+type ModerationActionListResponse struct {
+	Data       []models.ModerationAction `json:"data"`
+	Pagination Pagination                `json:"pagination"`
+}
+
+func NewModerationController() *ModerationController {
+	return &ModerationController{}
+}
+
+// @Summary Report a post or comment
+// @Description Report content for review by moderators. Content reported by enough distinct users is hidden automatically until a moderator reviews it
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param report body CreateReportRequest true "Target type (post or comment), target ID and reason (max 500 characters)"
+// @Success 201 {object} models.Report "Successfully created report"
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Reported content not found"
+// @Failure 409 {object} map[string]string "Content already reported by this user"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /reports [post]
+func (mc *ModerationController) CreateReport(c *gin.Context) {
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !models.ValidTargetType(req.TargetType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be post or comment"})
+		return
+	}
+	if req.TargetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_id is required"})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || len([]rune(req.Reason)) > maxReportReasonLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required and must be at most 500 characters"})
+		return
+	}
+
+	report, err := config.Moderator.Report(c.Request.Context(), middleware.CurrentUserID(c), req.TargetType, req.TargetID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, moderation.ErrTargetNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Reported content not found"})
+		case errors.Is(err, moderation.ErrAlreadyReported):
+			c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this content"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create report"})
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditCreate, resourceReport, report.ID, nil, report)
+	c.JSON(http.StatusCreated, report)
+}
+
+// @Summary List reports
+// @Description Get the moderation queue, oldest reports first. Requires the moderator or admin role
+// @Tags moderation
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param status query string false "Filter by status (open, resolved, dismissed)" default(open)
+// @Param target_type query string false "Filter by target type (post or comment)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Reports per page (1-100)" default(20)
+// @Success 200 {object} ReportListResponse "Successfully retrieved reports"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/reports [get]
+func (mc *ModerationController) GetReports(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := c.DefaultQuery("status", models.ReportOpen)
+	if status != models.ReportOpen && status != models.ReportResolved && status != models.ReportDismissed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, resolved or dismissed"})
+		return
+	}
+	query := requestDB(c).Model(&models.Report{}).Where("status = ?", status)
+
+	if targetType := c.Query("target_type"); targetType != "" {
+		if !models.ValidTargetType(targetType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be post or comment"})
+			return
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count reports"})
+		return
+	}
+
+	reports := []models.Report{}
+	if err := query.Order("created_at ASC").Order("id ASC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReportListResponse{Data: reports, Pagination: pagination})
+}
+
+// @Summary Get a report by ID
+// @Description Get a single report. Requires the moderator or admin role
+// @Tags moderation
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Report ID"
+// @Success 200 {object} models.Report "Successfully retrieved report"
+// @Failure 400 {object} map[string]string "Invalid report ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Report not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/reports/{id} [get]
+func (mc *ModerationController) GetReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var report models.Report
+	if err := requestDB(c).First(&report, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Resolve a report
+// @Description Review an open report. "hide" hides the content and "delete" deletes it, both closing every open report on that content; "dismiss" closes only this report. Requires the moderator or admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Report ID"
+// @Param resolution body ResolveReportRequest true "Action (hide, delete or dismiss) and optional note"
+// @Success 200 {object} models.Report "Successfully resolved report"
+// @Failure 400 {object} map[string]string "Invalid report ID or action"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Report not found"
+// @Failure 409 {object} map[string]string "Report already closed"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/reports/{id}/resolve [post]
+func (mc *ModerationController) ResolveReport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var before models.Report
+	if err := requestDB(c).First(&before, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve report"})
+		return
+	}
+	var target interface{}
+	if req.Action == models.ModerationDelete {
+		target = loadModerationTarget(requestDB(c), before.TargetType, before.TargetID)
+	}
+
+	moderator, _ := middleware.CurrentUser(c)
+	report, err := config.Moderator.Resolve(c.Request.Context(), moderator.ID, uint(id), req.Action, req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		case errors.Is(err, moderation.ErrReportClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": "Report is already closed"})
+		case errors.Is(err, moderation.ErrInvalidAction):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action must be hide, delete or dismiss"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+		}
+		return
+	}
+
+	recordAudit(c, models.AuditUpdate, resourceReport, report.ID, before, report)
+	if req.Action == models.ModerationDelete {
+		recordAudit(c, models.AuditDelete, report.TargetType, report.TargetID, target, nil)
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Hide a post
+// @Description Hide a post from everyone except moderators. Requires the moderator or admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param note body ModerationNoteRequest false "Optional note"
+// @Success 200 {object} map[string]string "Message: Post hidden"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/posts/{id}/hide [post]
+func (mc *ModerationController) HidePost(c *gin.Context) {
+	mc.setHidden(c, models.TargetPost, true)
+}
+
+// @Summary Unhide a post
+// @Description Make a hidden post visible again. Requires the moderator or admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param note body ModerationNoteRequest false "Optional note"
+// @Success 200 {object} map[string]string "Message: Post unhidden"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/posts/{id}/unhide [post]
+func (mc *ModerationController) UnhidePost(c *gin.Context) {
+	mc.setHidden(c, models.TargetPost, false)
+}
+
+// @Summary Hide a comment
+// @Description Hide a comment from everyone except moderators. Requires the moderator or admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param note body ModerationNoteRequest false "Optional note"
+// @Success 200 {object} map[string]string "Message: Comment hidden"
+// @Failure 400 {object} map[string]string "Invalid comment ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/comments/{id}/hide [post]
+func (mc *ModerationController) HideComment(c *gin.Context) {
+	mc.setHidden(c, models.TargetComment, true)
+}
+
+// @Summary Unhide a comment
+// @Description Make a hidden comment visible again. Requires the moderator or admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param note body ModerationNoteRequest false "Optional note"
+// @Success 200 {object} map[string]string "Message: Comment unhidden"
+// @Failure 400 {object} map[string]string "Invalid comment ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/comments/{id}/unhide [post]
+func (mc *ModerationController) UnhideComment(c *gin.Context) {
+	mc.setHidden(c, models.TargetComment, false)
+}
+
+// loadModerationTarget đọc post/comment (kể cả đang bị ẩn) để chụp snapshot cho audit log
+func loadModerationTarget(db *gorm.DB, targetType string, id uint) interface{} {
+	var target interface{} = &models.Post{}
+	if targetType == models.TargetComment {
+		target = &models.Comment{}
+	}
+	if err := db.First(target, id).Error; err != nil {
+		return nil
+	}
+	return target
+}
+
+func (mc *ModerationController) setHidden(c *gin.Context, targetType string, hidden bool) {
+	name := "Post"
+	if targetType == models.TargetComment {
+		name = "Comment"
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + strings.ToLower(name) + " ID"})
+		return
+	}
+
+	// body là tuỳ chọn nên bỏ qua lỗi bind khi không gửi gì
+	var req ModerationNoteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	before := audit.Snapshot(loadModerationTarget(requestDB(c), targetType, uint(id)))
+	moderator, _ := middleware.CurrentUser(c)
+	if err := config.Moderator.SetHidden(c.Request.Context(), moderator.ID, targetType, uint(id), hidden, req.Note); err != nil {
+		if errors.Is(err, moderation.ErrTargetNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": name + " not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update " + strings.ToLower(name)})
+		return
+	}
+
+	recordAudit(c, models.AuditUpdate, targetType, uint(id), before, loadModerationTarget(requestDB(c), targetType, uint(id)))
+
+	if hidden {
+		c.JSON(http.StatusOK, gin.H{"message": name + " hidden"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": name + " unhidden"})
+}
+
+// @Summary List moderation actions
+// @Description Get the record of moderation actions, newest first. Actions without moderator_id were taken automatically. Requires the moderator or admin role
+// @Tags moderation
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param moderator_id query int false "Filter by moderator"
+// @Param target_type query string false "Filter by target type (post or comment)"
+// @Param target_id query int false "Filter by target ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Actions per page (1-100)" default(20)
+// @Success 200 {object} ModerationActionListResponse "Successfully retrieved moderation actions"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Moderator role required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /moderation/actions [get]
+func (mc *ModerationController) GetActions(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := requestDB(c).Model(&models.ModerationAction{})
+	if v := c.Query("moderator_id"); v != "" {
+		moderatorID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid moderator_id"})
+			return
+		}
+		query = query.Where("moderator_id = ?", moderatorID)
+	}
+	if targetType := c.Query("target_type"); targetType != "" {
+		if !models.ValidTargetType(targetType) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "target_type must be post or comment"})
+			return
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+	if v := c.Query("target_id"); v != "" {
+		targetID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target_id"})
+			return
+		}
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count moderation actions"})
+		return
+	}
+
+	actions := []models.ModerationAction{}
+	if err := query.Order("created_at DESC").Order("id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&actions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve moderation actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ModerationActionListResponse{Data: actions, Pagination: pagination})
+}
+
+// @Summary Change a user's role
+// @Description Grant or revoke the moderator or admin role. Requires the admin role
+// @Tags moderation
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID"
+// @Param role body UpdateRoleRequest true "New role (user, moderator or admin)"
+// @Success 200 {object} models.User "Successfully updated role"
+// @Failure 400 {object} map[string]string "Invalid user ID or role"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Admin role required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /admin/users/{id}/role [put]
+func (mc *ModerationController) UpdateUserRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be user, moderator or admin"})
+		return
+	}
+
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	before := audit.Snapshot(user)
+	if err := requestDB(c).Model(user).Update("role", req.Role).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, resourceUser, user.ID, before, user)
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"social_media_server/audit"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const streamHeartbeat = 25 * time.Second
+
+type NotificationController struct{}
+
+type NotificationListResponse struct {
+	Data        []models.Notification `json:"data"`
+	Pagination  Pagination            `json:"pagination"`
+	UnreadCount int64                 `json:"unread_count"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func NewNotificationController() *NotificationController {
+	return &NotificationController{}
+}
+
+func countUnread(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// @Summary List notifications
+// @Description Get the notifications of the current user, newest first
+// @Tags notifications
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param unread query bool false "Only return unread notifications"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Notifications per page (1-100)" default(20)
+// @Success 200 {object} NotificationListResponse "Successfully retrieved notifications"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications [get]
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := requestDB(c).Model(&models.Notification{}).Where("user_id = ?", user.ID)
+	if v := c.Query("unread"); v != "" {
+		unread, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unread value"})
+			return
+		}
+		if unread {
+			query = query.Where("read_at IS NULL")
+		}
+	}
+
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+
+	notifications := []models.Notification{}
+	if err := query.Preload("Actor").Order("created_at DESC").Order("id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+		return
+	}
+
+	unreadCount, err := countUnread(requestDB(c), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationListResponse{Data: notifications, Pagination: pagination, UnreadCount: unreadCount})
+}
+
+// @Summary Count unread notifications
+// @Description Get the number of unread notifications of the current user
+// @Tags notifications
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} UnreadCountResponse "Unread count"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications/unread_count [get]
+func (nc *NotificationController) GetUnreadCount(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	count, err := countUnread(requestDB(c), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, UnreadCountResponse{UnreadCount: count})
+}
+
+// @Summary Mark a notification as read
+// @Description Mark one notification of the current user as read
+// @Tags notifications
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Notification "Notification marked as read"
+// @Failure 400 {object} map[string]string "Invalid notification ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Notification not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications/{id}/read [post]
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	var notification models.Notification
+	if err := requestDB(c).Where("user_id = ?", user.ID).First(&notification, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		before := audit.Snapshot(notification)
+		now := time.Now()
+		if err := requestDB(c).Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+		notification.ReadAt = &now
+		recordAudit(c, models.AuditUpdate, resourceNotification, notification.ID, before, notification)
+	}
+	c.JSON(http.StatusOK, notification)
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification of the current user as read
+// @Tags notifications
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} map[string]int64 "Number of notifications marked as read"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications/read_all [post]
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	result := requestDB(c).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", user.ID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	if result.RowsAffected > 0 {
+		// cập nhật hàng loạt nên chỉ ghi một bản ghi không gắn ID cụ thể
+		recordAudit(c, models.AuditUpdate, resourceNotification, 0, nil, gin.H{"user_id": user.ID, "marked_read": result.RowsAffected})
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": result.RowsAffected})
+}
+
+// @Summary Get notification preferences
+// @Description Get which notification types the current user receives
+// @Tags notifications
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NotificationPreference "Notification preferences"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications/preferences [get]
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	pref, err := config.Notifier.Preference(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, pref)
+}
+
+// @Summary Update notification preferences
+// @Description Choose which notification types the current user receives
+// @Tags notifications
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param preferences body models.NotificationPreference true "Notification types to enable"
+// @Success 200 {object} models.NotificationPreference "Updated preferences"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /notifications/preferences [put]
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	before, err := config.Notifier.Preference(c.Request.Context(), user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	pref := models.DefaultNotificationPreference(user.ID)
+	if err := c.ShouldBindJSON(&pref); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	pref.UserID = user.ID
+
+	if err := requestDB(c).Save(&pref).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update preferences"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, resourceNotificationPreference, user.ID, before, pref)
+	c.JSON(http.StatusOK, pref)
+}
+
+// @Summary Stream notifications in real time
+// @Description Server-Sent Events stream of new notifications for the current user. Browsers using EventSource can pass the API key in the api_key query parameter. The first event is "unread_count"; each new notification is sent as a "notification" event
+// @Tags notifications
+// @Produce  text/event-stream
+// @Security ApiKeyAuth
+// @Param api_key query string false "API key, for clients that cannot set the Authorization header"
+// @Success 200 {object} models.Notification "Event stream"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Router /notifications/stream [get]
+func (nc *NotificationController) StreamNotifications(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+
+	events, unsubscribe := config.Notifier.Broker().Subscribe(user.ID)
+	defer unsubscribe()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	if count, err := countUnread(requestDB(c), user.ID); err == nil {
+		c.SSEvent("unread_count", UnreadCountResponse{UnreadCount: count})
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case n, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent("notification", n)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
+			return true
+		}
+	})
+}
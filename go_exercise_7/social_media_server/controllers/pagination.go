@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type Pagination struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// parsePagination đọc ?page= và ?page_size=, mặc định trang 1 với defaultPageSize phần tử
+func parsePagination(c *gin.Context) (Pagination, error) {
+	p := Pagination{Page: 1, PageSize: defaultPageSize}
+	if v := c.Query("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return p, errors.New("page must be a positive integer")
+		}
+		p.Page = page
+	}
+	if v := c.Query("page_size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxPageSize {
+			return p, errors.New("page_size must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		p.PageSize = size
+	}
+	return p, nil
+}
@@ -4,12 +4,20 @@ import (
 	// "encoding/json" // Không cần nữa nếu không cache
 	"errors"
 	"net/http"
-	"social_media_server/config"
+	"social_media_server/audit"
+	"social_media_server/contentcheck"
+	"social_media_server/deletion"
+	"social_media_server/middleware"
 	"social_media_server/models"
+	"social_media_server/render"
+	"social_media_server/revision"
+	"social_media_server/tagging"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PostController struct{}
@@ -26,30 +34,86 @@ func NewPostController() *PostController {
 // )
 
 // @Summary Get all posts
-// @Description Get a list of all posts with their comments
+// @Description Get a list of all published posts, plus the current user's own drafts, scheduled and archived posts, newest first unless sort says otherwise. Rankings other than new use scores refreshed periodically by the server. comment_count and last_comment_at summarise the visible comments, so include= can skip embedding them. By default every comment and attachment is embedded; use include to choose what is embedded and comments_limit to embed only the latest comments of each post. Each post and embedded comment carries its vote and emoji reaction counts, and each post its bookmark count and whether the current user bookmarked it
 // @Tags posts
 // @Accept  json
 // @Produce  json
+// @Param status query string false "Only posts with this status" Enums(draft, scheduled, published, archived)
+// @Param sort query string false "Ordering: new (newest first), hot (recent and popular), top (highest score), discussed (most comments) or active (latest comment first)" Enums(new, hot, top, discussed, active) default(new)
+// @Param window query string false "Only posts published within this window, for sort=top and sort=discussed" Enums(day, week, month, all) default(day)
+// @Param include query string false "Comma-separated relations to embed: comments, attachments (default: all)"
+// @Param comments_limit query int false "Embed at most this many of the latest comments per post (1-100)"
 // @Success 200 {array} models.Post "Successfully retrieved list of posts"
+// @Failure 400 {object} map[string]string "Invalid status, sort, window, include or comments_limit"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /posts [get]
 func (pc *PostController) GetPosts(c *gin.Context) {
+	inc, err := parsePostIncludes(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sort, err := parsePostSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := postListQuery(c, readDB(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var posts []models.Post
-	if err := config.DB.Preload("Comments").Order("created_at DESC").Find(&posts).Error; err != nil {
+	if err := sort.order(inc.preload(sort.filter(query))).Find(&posts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
 		return
 	}
+	if err := inc.loadLimited(readDB(c), posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	if err := loadPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	for i := range posts {
+		signAttachmentURLs(c, posts[i].Attachments)
+	}
 	c.JSON(http.StatusOK, posts)
 }
 
+// postListQuery áp dụng quyền xem và bộ lọc ?status= chung cho các danh sách post
+func postListQuery(c *gin.Context, query *gorm.DB) (*gorm.DB, error) {
+	query = visiblePosts(c, query, models.PostPublished)
+	if status := c.Query("status"); status != "" {
+		if !models.ValidPostStatus(status) {
+			return nil, errors.New("status must be one of: draft, scheduled, published, archived")
+		}
+		query = query.Where("status = ?", status)
+	}
+	return query, nil
+}
+
 // @Summary Create a new post
-// @Description Create a new post with title and content
+// @Description Create a new post with title and content. content_format may be "plain" (default) or "markdown"; the sanitized HTML is returned in content_html. status may be "published" (default), "draft" or "scheduled"; scheduled posts need a future publish_at and are published by the server at that time. Set community_id to post inside a community the current user is a member of. Authenticated posts are owned by the current user and appear in followers' feeds once published. The content filter may reject the post, mask parts of it or flag it for review. @mentions and #hashtags in the content are listed in entities with their character offsets, and mentioned users are notified once the post is published
 // @Tags posts
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param post body models.Post true "Post object that needs to be created"
+// @Param Idempotency-Key header string false "Unique key for safely retrying the request; repeats return the first response"
 // @Success 201 {object} models.Post "Successfully created post"
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 403 {object} map[string]string "Not a member of the community"
+// @Failure 409 {object} map[string]string "A request with the same Idempotency-Key is still being processed"
+// @Failure 422 {object} map[string]string "Rejected by the content filter, or Idempotency-Key reused with a different request"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /posts [post]
 func (pc *PostController) CreatePost(c *gin.Context) {
@@ -59,22 +123,88 @@ func (pc *PostController) CreatePost(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Create(&post).Error; err != nil {
+	if post.ContentFormat != "" && !render.ValidFormat(post.ContentFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content_format, expected plain or markdown"})
+		return
+	}
+
+	if post.CommunityID != nil {
+		var community models.Community
+		if err := requestDB(c).First(&community, *post.CommunityID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Community not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve community"})
+			return
+		}
+		membership, err := communityMembership(c, community.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check community membership"})
+			return
+		}
+		if membership == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You must be a member of the community to post in it"})
+			return
+		}
+	}
+
+	post.UserID = middleware.CurrentUserID(c)
+	post.Hidden, post.EditedAt = false, nil
+	post.CommentCount, post.LastCommentAt = 0, nil
+	// comment và file đính kèm đi qua API riêng, không nhận kèm khi tạo post
+	post.Comments, post.Attachments = nil, nil
+
+	status, publishAt := post.Status, post.PublishAt
+	post.Status, post.PublishAt = "", nil
+	published, err := applyPostStatus(&post, status, publishAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := newSubmission(c, models.TargetPost, false,
+		contentcheck.Field{Name: "title", Value: &post.Title},
+		contentcheck.Field{Name: "content", Value: &post.Content})
+	check, ok := checkContent(c, sub)
+	if !ok {
+		return
+	}
+
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&post).Error; err != nil {
+			return err
+		}
+		if _, err := tagging.Sync(tx, models.TargetPost, post.ID, post.Content); err != nil {
+			return err
+		}
+		rev := revision.FromPost(&post)
+		rev.EditorID = post.UserID
+		return revision.Append(tx, &rev)
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
+	contentSaved(c, sub, check, post.ID)
+	recordAudit(c, models.AuditCreate, models.TargetPost, post.ID, nil, post)
+
+	if published {
+		postPublished(c.Request.Context(), &post)
+	}
 
 	c.JSON(http.StatusCreated, post)
 }
 
 // @Summary Get a single post by ID
-// @Description Get details of a specific post by its ID, including comments
+// @Description Get details of a specific post by its ID, including comments and attachments unless include says otherwise, vote and emoji reaction counts, and bookmark count. Drafts and scheduled posts are only visible to their author
 // @Tags posts
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Post ID"
+// @Param include query string false "Comma-separated relations to embed: comments, attachments (default: all)"
+// @Param comments_limit query int false "Embed at most this many of the latest comments (1-100)"
 // @Success 200 {object} models.Post "Successfully retrieved post"
-// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 400 {object} map[string]string "Invalid post ID, include or comments_limit"
 // @Failure 404 {object} map[string]string "Post not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /posts/{id} [get]
@@ -86,9 +216,15 @@ func (pc *PostController) GetPost(c *gin.Context) {
 		return
 	}
 
+	inc, err := parsePostIncludes(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var post models.Post
 	// Chỉ lấy từ DB
-	if err := config.DB.Preload("Comments").First(&post, uint(id)).Error; err != nil {
+	if err := inc.preload(visiblePosts(c, requestDB(c), models.PostPublished, models.PostArchived)).First(&post, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -96,19 +232,37 @@ func (pc *PostController) GetPost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
 		return
 	}
+	posts := []models.Post{post}
+	if err := inc.loadLimited(requestDB(c), posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	if err := loadPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	post = posts[0]
+	signAttachmentURLs(c, post.Attachments)
 	c.JSON(http.StatusOK, post)
 }
 
 // @Summary Update an existing post
-// @Description Update title and content of an existing post by its ID
+// @Description Update title and content of an existing post by its ID. Posts with an owner can only be updated by that user. Every change is kept as a revision and marks a published post as edited. status and publish_at move drafts and scheduled posts between states or publish them; published posts can only be archived and archived posts published again
 // @Tags posts
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param id path int true "Post ID"
-// @Param post body models.Post true "Post object with updated fields (only Title and Content are used)"
+// @Param post body models.Post true "Post object with updated fields (only Title, Content, ContentFormat, Status and PublishAt are used)"
 // @Success 200 {object} models.Post "Successfully updated post"
 // @Failure 400 {object} map[string]string "Invalid post ID or Bad Request"
+// @Failure 403 {object} map[string]string "Not the owner of the post"
 // @Failure 404 {object} map[string]string "Post not found"
+// @Failure 422 {object} map[string]string "Rejected by the content filter"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /posts/{id} [put]
 func (pc *PostController) UpdatePost(c *gin.Context) {
@@ -120,7 +274,7 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 	}
 
 	var post models.Post
-	if err := config.DB.First(&post, uint(id)).Error; err != nil {
+	if err := requestDB(c).First(&post, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -129,31 +283,80 @@ func (pc *PostController) UpdatePost(c *gin.Context) {
 		return
 	}
 
+	if !canModify(c, post.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to modify this post"})
+		return
+	}
+	before := audit.Snapshot(post)
+	original := revision.FromPost(&post)
+
 	var postUpdates models.Post
 	if err := c.ShouldBindJSON(&postUpdates); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if postUpdates.ContentFormat != "" {
+		if !render.ValidFormat(postUpdates.ContentFormat) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid content_format, expected plain or markdown"})
+			return
+		}
+		post.ContentFormat = postUpdates.ContentFormat
+	}
+
 	post.Title = postUpdates.Title
 	post.Content = postUpdates.Content
 
-	if err := config.DB.Save(&post).Error; err != nil {
+	createdAt := post.CreatedAt
+	unpublished := post.Status == models.PostDraft || post.Status == models.PostScheduled
+	published, err := applyPostStatus(&post, postUpdates.Status, postUpdates.PublishAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub := newSubmission(c, models.TargetPost, true,
+		contentcheck.Field{Name: "title", Value: &post.Title},
+		contentcheck.Field{Name: "content", Value: &post.Content})
+	check, ok := checkContent(c, sub)
+	if !ok {
+		return
+	}
+
+	edited := revision.FromPost(&post)
+	edited.EditorID = middleware.CurrentUserID(c)
+	// Sửa bản nháp trước khi xuất bản vẫn lưu revision nhưng không đánh dấu post là đã sửa
+	editedAt := &post.EditedAt
+	if unpublished {
+		var discarded *time.Time
+		editedAt = &discarded
+	}
+	mentioned, err := saveEdit(requestDB(c), &post, editedAt, original, edited, post.UserID, createdAt)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
 		return
 	}
+	contentSaved(c, sub, check, post.ID)
+	recordAudit(c, models.AuditUpdate, models.TargetPost, post.ID, before, post)
+	if published {
+		postPublished(c.Request.Context(), &post)
+	} else if post.Status == models.PostPublished {
+		notifyMentioned(c, mentioned, &post.ID, nil)
+	}
 	// Không còn invalidate cache
 	c.JSON(http.StatusOK, post)
 }
 
 // @Summary Delete a post
-// @Description Delete a post by its ID and its associated comments
+// @Description Delete a post by its ID and its associated comments and attachments. Posts with an owner can only be deleted by that user, a moderator or a moderator of the post's community
 // @Tags posts
 // @Accept  json
 // @Produce  json
+// @Security ApiKeyAuth
 // @Param id path int true "Post ID"
 // @Success 200 {object} map[string]string "Message: Post and associated comments deleted successfully"
 // @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 403 {object} map[string]string "Not the owner of the post"
 // @Failure 404 {object} map[string]string "Post not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Router /posts/{id} [delete]
@@ -166,7 +369,7 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 	}
 
 	var post models.Post
-	if err := config.DB.First(&post, uint(id)).Error; err != nil {
+	if err := requestDB(c).First(&post, uint(id)).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -175,15 +378,23 @@ func (pc *PostController) DeletePost(c *gin.Context) {
 		return
 	}
 
-	if err := config.DB.Where("post_id = ?", uint(id)).Delete(&models.Comment{}).Error; err != nil {
-		// log.Printf("Error deleting comments for post ID %s: %v\n", idStr, err)
-		// Có thể log lỗi này, nhưng không cần thiết nếu chỉ tạm bỏ Redis
+	if !canModerate(c, post.UserID) && !canModerateCommunity(c, post.CommunityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to delete this post"})
+		return
 	}
 
-	if err := config.DB.Delete(&models.Post{}, uint(id)).Error; err != nil {
+	var attachments []models.Attachment
+	if err := requestDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		attachments, err = deletion.Post(tx, post.ID)
+		return err
+	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
 		return
 	}
+	recordAudit(c, models.AuditDelete, models.TargetPost, post.ID, post, nil)
+	deleteAttachmentBlobs(c, attachments)
+
 	// Không còn invalidate cache
 	c.JSON(http.StatusOK, gin.H{"message": "Post and associated comments deleted successfully"})
 }
\ No newline at end of file
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"social_media_server/models"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxCommentsLimit = 100
+
+// postIncludes quyết định những quan hệ nào được nhúng vào post trả về
+type postIncludes struct {
+	comments      bool
+	attachments   bool
+	commentsLimit int
+	showHidden    bool
+}
+
+// parsePostIncludes đọc ?include=comments,attachments và ?comments_limit=.
+// Không truyền include thì giữ hành vi cũ: nhúng toàn bộ comment và attachment.
+func parsePostIncludes(c *gin.Context) (postIncludes, error) {
+	inc := postIncludes{comments: true, attachments: true, showHidden: isModerator(c)}
+
+	if v, ok := c.GetQuery("include"); ok {
+		inc = postIncludes{showHidden: inc.showHidden}
+		for _, part := range strings.Split(v, ",") {
+			switch strings.TrimSpace(part) {
+			case "comments":
+				inc.comments = true
+			case "attachments":
+				inc.attachments = true
+			case "":
+			default:
+				return inc, errors.New("include must be a comma-separated list of: comments, attachments")
+			}
+		}
+	}
+
+	if v := c.Query("comments_limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxCommentsLimit {
+			return inc, errors.New("comments_limit must be between 1 and " + strconv.Itoa(maxCommentsLimit))
+		}
+		inc.commentsLimit = limit
+		inc.comments = true
+	}
+	return inc, nil
+}
+
+func (inc postIncludes) preload(query *gorm.DB) *gorm.DB {
+	if inc.attachments {
+		query = query.Preload("Attachments")
+	}
+	if inc.comments && inc.commentsLimit == 0 {
+		if inc.showHidden {
+			query = query.Preload("Comments")
+		} else {
+			query = query.Preload("Comments", "hidden = ?", false)
+		}
+	}
+	return query
+}
+
+// loadLimited nhúng tối đa commentsLimit comment mới nhất cho mỗi post bằng một query duy nhất
+func (inc postIncludes) loadLimited(db *gorm.DB, posts []models.Post) error {
+	if !inc.comments || inc.commentsLimit == 0 || len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	ranked := db.Model(&models.Comment{}).
+		Select("comments.*, ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY created_at DESC, id DESC) AS comment_rank").
+		Where("post_id IN ?", ids)
+	if !inc.showHidden {
+		ranked = ranked.Where("hidden = ?", false)
+	}
+
+	var comments []models.Comment
+	if err := db.Unscoped().Table("(?) AS ranked", ranked).
+		Where("comment_rank <= ?", inc.commentsLimit).
+		Order("post_id ASC, created_at ASC, id ASC").
+		Find(&comments).Error; err != nil {
+		return err
+	}
+
+	byPost := make(map[uint][]models.Comment, len(posts))
+	for _, comment := range comments {
+		byPost[comment.PostID] = append(byPost[comment.PostID], comment)
+	}
+	for i := range posts {
+		posts[i].Comments = byPost[posts[i].ID]
+		if posts[i].Comments == nil {
+			posts[i].Comments = []models.Comment{}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"errors"
+	"social_media_server/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// postSort là cách sắp xếp chọn bằng ?sort= và ?window=. SortHot và SortTop đọc điểm đã tính sẵn
+// trong post_scores (xem package ranking), post chưa có điểm được coi như 0. SortDiscussed và
+// SortActive dùng comment_count và last_comment_at của post.
+type postSort struct {
+	sort  string
+	since time.Time
+}
+
+func parsePostSort(c *gin.Context) (postSort, error) {
+	s := postSort{sort: c.DefaultQuery("sort", models.SortNew)}
+	if !models.ValidPostSort(s.sort) {
+		return s, errors.New("sort must be one of: new, hot, top, discussed, active")
+	}
+
+	window, ok := models.WindowDuration(c.DefaultQuery("window", models.WindowDay))
+	if !ok {
+		return s, errors.New("window must be one of: day, week, month, all")
+	}
+	if window > 0 && (s.sort == models.SortTop || s.sort == models.SortDiscussed) {
+		s.since = time.Now().UTC().Add(-window)
+	}
+	return s, nil
+}
+
+// filter nối bảng điểm và giới hạn theo window, cần gọi trước khi Count
+func (s postSort) filter(query *gorm.DB) *gorm.DB {
+	if s.sort == models.SortHot || s.sort == models.SortTop {
+		query = query.Joins("LEFT JOIN post_scores ON post_scores.post_id = posts.id")
+	}
+	if !s.since.IsZero() {
+		query = query.Where("posts.created_at >= ?", s.since)
+	}
+	return query
+}
+
+func (s postSort) order(query *gorm.DB) *gorm.DB {
+	switch s.sort {
+	case models.SortHot:
+		query = query.Order("COALESCE(post_scores.hot, 0) DESC")
+	case models.SortTop:
+		query = query.Order("COALESCE(post_scores.score, 0) DESC")
+	case models.SortDiscussed:
+		query = query.Order("posts.comment_count DESC")
+	case models.SortActive:
+		// Post chưa có comment xếp sau cùng
+		query = query.Order("posts.last_comment_at IS NULL").Order("posts.last_comment_at DESC")
+	}
+	return query.Order("posts.created_at DESC").Order("posts.id DESC")
+}
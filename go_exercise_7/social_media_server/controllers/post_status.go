@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"social_media_server/config"
+	"social_media_server/models"
+	"social_media_server/ranking"
+	"time"
+)
+
+// applyPostStatus áp dụng status/publish_at từ request lên post (post.Status rỗng nghĩa là post
+// mới tạo) và kiểm tra chuyển trạng thái hợp lệ: nháp và hẹn giờ chuyển qua lại hoặc xuất bản,
+// post đã xuất bản chỉ có thể lưu trữ rồi xuất bản lại. Trả về true nếu post vừa được xuất bản.
+//
+// Khi xuất bản, created_at được đặt thành thời điểm xuất bản để danh sách, feed và cursor
+// vẫn sắp xếp theo created_at như trước.
+func applyPostStatus(post *models.Post, status string, publishAt *time.Time) (bool, error) {
+	current := post.Status
+	if status == "" {
+		switch {
+		case publishAt != nil:
+			status = models.PostScheduled
+		case current == "":
+			status = models.PostPublished
+		default:
+			status = current
+		}
+	}
+	if !models.ValidPostStatus(status) {
+		return false, errors.New("status must be one of: draft, scheduled, published, archived")
+	}
+	wasPublished := current == models.PostPublished || current == models.PostArchived
+
+	switch status {
+	case models.PostDraft, models.PostScheduled:
+		if wasPublished {
+			return false, errors.New("published posts can only be archived")
+		}
+		if status == models.PostDraft {
+			post.PublishAt = nil
+			break
+		}
+		if publishAt == nil {
+			publishAt = post.PublishAt
+		}
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return false, errors.New("publish_at must be in the future for scheduled posts")
+		}
+		at := publishAt.UTC()
+		post.PublishAt = &at
+	case models.PostArchived:
+		if !wasPublished {
+			return false, errors.New("only published posts can be archived")
+		}
+	case models.PostPublished:
+		post.PublishAt = nil
+		if !wasPublished && current != "" {
+			post.CreatedAt = time.Now()
+		}
+	}
+	post.Status = status
+	return status == models.PostPublished && !wasPublished, nil
+}
+
+// postPublished đẩy post vừa xuất bản vào feed của follower, gửi thông báo và tính điểm xếp hạng
+// ban đầu. Lỗi chỉ được log vì post đã lưu thành công.
+func postPublished(ctx context.Context, post *models.Post) {
+	if err := config.Timeline.PostCreated(ctx, post); err != nil {
+		log.Printf("Failed to fan out post %d: %v", post.ID, err)
+	}
+	if err := ranking.Refresh(config.DB.WithContext(ctx), []uint{post.ID}); err != nil {
+		log.Printf("Failed to score post %d: %v", post.ID, err)
+	}
+	if err := config.Notifier.PostCreated(ctx, post); err != nil {
+		log.Printf("Failed to send notifications for post %d: %v", post.ID, err)
+	}
+}
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"social_media_server/reactions"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactionController struct{}
+
+type VoteRequest struct {
+	Value *int `json:"value" binding:"required"`
+}
+
+type ReactionRequest struct {
+	Emoji string `json:"emoji" binding:"required"`
+}
+
+type ReactionListResponse struct {
+	Data       []models.Reaction `json:"data"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+func NewReactionController() *ReactionController {
+	return &ReactionController{}
+}
+
+func viewerID(c *gin.Context) uint {
+	if id := middleware.CurrentUserID(c); id != nil {
+		return *id
+	}
+	return 0
+}
+
+// loadPostReactions gắn số reaction/vote vào các post và comment đã nhúng, mỗi loại chỉ tốn
+// vài query cho cả danh sách
+func loadPostReactions(c *gin.Context, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	postIDs := make([]uint, len(posts))
+	var comments []*models.Comment
+	for i := range posts {
+		postIDs[i] = posts[i].ID
+		for j := range posts[i].Comments {
+			comments = append(comments, &posts[i].Comments[j])
+		}
+	}
+
+	summaries, err := reactions.Summaries(requestDB(c), models.TargetPost, postIDs, viewerID(c))
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Reactions = summaries[posts[i].ID]
+	}
+	return applyCommentReactions(c, comments)
+}
+
+// loadCommentReactions gắn số reaction/vote vào danh sách comment
+func loadCommentReactions(c *gin.Context, comments []models.Comment) error {
+	ptrs := make([]*models.Comment, len(comments))
+	for i := range comments {
+		ptrs[i] = &comments[i]
+	}
+	return applyCommentReactions(c, ptrs)
+}
+
+func applyCommentReactions(c *gin.Context, comments []*models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(comments))
+	for i, comment := range comments {
+		ids[i] = comment.ID
+	}
+	summaries, err := reactions.Summaries(requestDB(c), models.TargetComment, ids, viewerID(c))
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		comment.Reactions = summaries[comment.ID]
+	}
+	return nil
+}
+
+// respondSummary trả về số reaction/vote mới nhất của post/comment sau khi thay đổi
+func respondSummary(c *gin.Context, targetType string, targetID uint) {
+	summaries, err := reactions.Summaries(requestDB(c), targetType, []uint{targetID}, viewerID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	c.JSON(http.StatusOK, summaries[targetID])
+}
+
+func (rc *ReactionController) vote(c *gin.Context, targetType string) {
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	value := *req.Value
+	if value != models.VoteUp && value != models.VoteDown && value != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value must be 1, -1 or 0"})
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	old, err := reactions.SetVote(requestDB(c), user.ID, targetType, targetID, value)
+	if err != nil {
+		if errors.Is(err, reactions.ErrConflict) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Vote was changed by another request, try again"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save vote"})
+		return
+	}
+	auditVote(c, models.Vote{UserID: user.ID, TargetType: targetType, TargetID: targetID}, old, value)
+	respondSummary(c, targetType, targetID)
+}
+
+// auditVote ghi lại việc đổi vote từ old sang value; 0 là chưa vote hoặc bỏ vote
+func auditVote(c *gin.Context, vote models.Vote, old, value int) {
+	if old == value {
+		return
+	}
+	var before, after interface{}
+	action := models.AuditUpdate
+	if old != 0 {
+		v := vote
+		v.Value = old
+		before = v
+	} else {
+		action = models.AuditCreate
+	}
+	if value != 0 {
+		v := vote
+		v.Value = value
+		after = v
+	} else {
+		action = models.AuditDelete
+	}
+	recordAudit(c, action, resourceVote, vote.TargetID, before, after)
+}
+
+func (rc *ReactionController) react(c *gin.Context, targetType string) {
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+
+	var req ReactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !config.ValidReactionEmoji(req.Emoji) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported reaction emoji"})
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	added, err := reactions.React(requestDB(c), user.ID, targetType, targetID, req.Emoji)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save reaction"})
+		return
+	}
+	if added {
+		recordAudit(c, models.AuditCreate, resourceReaction, targetID, nil,
+			models.Reaction{UserID: user.ID, TargetType: targetType, TargetID: targetID, Emoji: req.Emoji})
+	}
+	respondSummary(c, targetType, targetID)
+}
+
+func (rc *ReactionController) unreact(c *gin.Context, targetType string) {
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+
+	user, _ := middleware.CurrentUser(c)
+	removed, err := reactions.Unreact(requestDB(c), user.ID, targetType, targetID, c.Param("emoji"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove reaction"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reaction not found"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceReaction, targetID,
+		models.Reaction{UserID: user.ID, TargetType: targetType, TargetID: targetID, Emoji: c.Param("emoji")}, nil)
+	respondSummary(c, targetType, targetID)
+}
+
+func (rc *ReactionController) list(c *gin.Context, targetType string) {
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := readDB(c).Model(&models.Reaction{}).Where("target_type = ? AND target_id = ?", targetType, targetID)
+	if emoji := c.Query("emoji"); emoji != "" {
+		query = query.Where("emoji = ?", emoji)
+	}
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count reactions"})
+		return
+	}
+
+	list := []models.Reaction{}
+	if err := query.Preload("User").Order("created_at DESC").Order("user_id DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	c.JSON(http.StatusOK, ReactionListResponse{Data: list, Pagination: pagination})
+}
+
+// @Summary Vote on a post
+// @Description Upvote (1), downvote (-1) or clear (0) the current user's vote on a post. Each user has at most one vote per post
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param vote body VoteRequest true "Vote value: 1, -1 or 0"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid post ID or vote value"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 409 {object} map[string]string "Vote was changed concurrently"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/vote [put]
+func (rc *ReactionController) VotePost(c *gin.Context) {
+	rc.vote(c, models.TargetPost)
+}
+
+// @Summary Vote on a comment
+// @Description Upvote (1), downvote (-1) or clear (0) the current user's vote on a comment. Each user has at most one vote per comment
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param vote body VoteRequest true "Vote value: 1, -1 or 0"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid comment ID or vote value"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 409 {object} map[string]string "Vote was changed concurrently"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/vote [put]
+func (rc *ReactionController) VoteComment(c *gin.Context) {
+	rc.vote(c, models.TargetComment)
+}
+
+// @Summary React to a post
+// @Description Add an emoji reaction to a post. Each user can add each allowed emoji once; reacting again with the same emoji has no effect
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param reaction body ReactionRequest true "Emoji to react with"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid post ID or unsupported emoji"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/reactions [post]
+func (rc *ReactionController) ReactPost(c *gin.Context) {
+	rc.react(c, models.TargetPost)
+}
+
+// @Summary React to a comment
+// @Description Add an emoji reaction to a comment. Each user can add each allowed emoji once; reacting again with the same emoji has no effect
+// @Tags reactions
+// @Accept  json
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param reaction body ReactionRequest true "Emoji to react with"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid comment ID or unsupported emoji"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/reactions [post]
+func (rc *ReactionController) ReactComment(c *gin.Context) {
+	rc.react(c, models.TargetComment)
+}
+
+// @Summary Remove a reaction from a post
+// @Description Remove the current user's emoji reaction from a post. The emoji must be URL-encoded
+// @Tags reactions
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param emoji path string true "Emoji to remove"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid post ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Post or reaction not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/reactions/{emoji} [delete]
+func (rc *ReactionController) UnreactPost(c *gin.Context) {
+	rc.unreact(c, models.TargetPost)
+}
+
+// @Summary Remove a reaction from a comment
+// @Description Remove the current user's emoji reaction from a comment. The emoji must be URL-encoded
+// @Tags reactions
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param emoji path string true "Emoji to remove"
+// @Success 200 {object} models.ReactionSummary "Updated reaction and vote counts"
+// @Failure 400 {object} map[string]string "Invalid comment ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "Comment or reaction not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/reactions/{emoji} [delete]
+func (rc *ReactionController) UnreactComment(c *gin.Context) {
+	rc.unreact(c, models.TargetComment)
+}
+
+// @Summary List who reacted to a post
+// @Description Get the users who reacted to a post, newest first, optionally only those who used one emoji
+// @Tags reactions
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param emoji query string false "Only reactions with this emoji"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Reactions per page (1-100)" default(20)
+// @Success 200 {object} ReactionListResponse "Successfully retrieved reactions"
+// @Failure 400 {object} map[string]string "Invalid post ID or pagination"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/reactions [get]
+func (rc *ReactionController) GetPostReactions(c *gin.Context) {
+	rc.list(c, models.TargetPost)
+}
+
+// @Summary List who reacted to a comment
+// @Description Get the users who reacted to a comment, newest first, optionally only those who used one emoji
+// @Tags reactions
+// @Produce  json
+// @Param id path int true "Comment ID"
+// @Param emoji query string false "Only reactions with this emoji"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Reactions per page (1-100)" default(20)
+// @Success 200 {object} ReactionListResponse "Successfully retrieved reactions"
+// @Failure 400 {object} map[string]string "Invalid comment ID or pagination"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/reactions [get]
+func (rc *ReactionController) GetCommentReactions(c *gin.Context) {
+	rc.list(c, models.TargetComment)
+}
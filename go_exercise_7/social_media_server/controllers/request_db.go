@@ -0,0 +1,20 @@
+package controllers
+
+import (
+	"social_media_server/config"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// requestDB gắn context của request vào truy vấn: client ngắt kết nối thì truy vấn bị huỷ,
+// ngoài ra vẫn chịu DB_QUERY_TIMEOUT
+func requestDB(c *gin.Context) *gorm.DB {
+	return config.DB.WithContext(c.Request.Context())
+}
+
+// readDB như requestDB nhưng đọc từ read replica (nếu có). Chỉ dùng cho endpoint danh sách, không
+// dùng ngay sau khi ghi trong cùng request vì replica có thể chưa kịp đồng bộ.
+func readDB(c *gin.Context) *gorm.DB {
+	return config.ReadDB.WithContext(c.Request.Context())
+}
@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"social_media_server/audit"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"social_media_server/revision"
+	"social_media_server/tagging"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevisionController struct{}
+
+type RevisionListResponse struct {
+	Data       []models.Revision `json:"data"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+type RevisionDiffResponse struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+func NewRevisionController() *RevisionController {
+	return &RevisionController{}
+}
+
+// saveEdit lưu nội dung đã sửa và ghi revision mới nếu nội dung thực sự thay đổi. Nội dung cũ
+// chưa có lịch sử (tạo trước khi có tính năng này hoặc được import) được ghi lại làm version 1.
+// Hashtag và mention được cập nhật theo nội dung mới; trả về những người vừa được mention thêm.
+func saveEdit(db *gorm.DB, model interface{}, editedAt **time.Time, original, edited models.Revision, authorID *uint, createdAt time.Time) ([]uint, error) {
+	changed := !revision.SameContent(original, edited)
+	if changed {
+		now := time.Now()
+		*editedAt = &now
+	}
+	var mentioned []uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// comment_count/last_comment_at của post do poststats cập nhật, không ghi đè bằng giá trị đã đọc;
+		// comment và file đính kèm không bao giờ được lưu kèm khi sửa
+		if err := tx.Omit(clause.Associations, "comment_count", "last_comment_at").Save(model).Error; err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		if err := revision.EnsureInitial(tx, original, authorID, createdAt); err != nil {
+			return err
+		}
+		if err := revision.Append(tx, &edited); err != nil {
+			return err
+		}
+		var err error
+		mentioned, err = tagging.Sync(tx, edited.TargetType, edited.TargetID, edited.Content)
+		return err
+	})
+	return mentioned, err
+}
+
+func targetName(targetType string) string {
+	if targetType == models.TargetComment {
+		return "Comment"
+	}
+	return "Post"
+}
+
+// findTarget kiểm tra post/comment trong :id tồn tại và người xem được phép thấy nó
+func findTarget(c *gin.Context, targetType string) (uint, bool) {
+	name := targetName(targetType)
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " ID"})
+		return 0, false
+	}
+
+	var target interface{} = &models.Post{}
+	query := visiblePosts(c, requestDB(c), models.PostPublished, models.PostArchived)
+	if targetType == models.TargetComment {
+		target = &models.Comment{}
+		query = visibleComments(c, requestDB(c))
+	}
+	if err := query.First(target, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": name + " not found"})
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func findRevision(c *gin.Context, targetType string, targetID uint, version int) (*models.Revision, bool) {
+	var rev models.Revision
+	if err := requestDB(c).Where("target_type = ? AND target_id = ? AND version = ?", targetType, targetID, version).
+		First(&rev).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revision"})
+		return nil, false
+	}
+	return &rev, true
+}
+
+func parseVersion(v string) (int, error) {
+	version, err := strconv.Atoi(v)
+	if err != nil || version < 1 {
+		return 0, errors.New("version must be a positive integer")
+	}
+	return version, nil
+}
+
+// @Summary List revisions of a post
+// @Description Get the edit history of a post, newest version first. Version 1 is the original content
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Revisions per page (1-100)" default(20)
+// @Success 200 {object} RevisionListResponse "Successfully retrieved revisions"
+// @Failure 400 {object} map[string]string "Invalid post ID or query parameters"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/revisions [get]
+func (rc *RevisionController) GetPostRevisions(c *gin.Context) {
+	rc.listRevisions(c, models.TargetPost)
+}
+
+// @Summary List revisions of a comment
+// @Description Get the edit history of a comment, newest version first. Version 1 is the original content
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Comment ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Revisions per page (1-100)" default(20)
+// @Success 200 {object} RevisionListResponse "Successfully retrieved revisions"
+// @Failure 400 {object} map[string]string "Invalid comment ID or query parameters"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/revisions [get]
+func (rc *RevisionController) GetCommentRevisions(c *gin.Context) {
+	rc.listRevisions(c, models.TargetComment)
+}
+
+func (rc *RevisionController) listRevisions(c *gin.Context, targetType string) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+
+	query := requestDB(c).Model(&models.Revision{}).Where("target_type = ? AND target_id = ?", targetType, targetID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count revisions"})
+		return
+	}
+
+	revisions := []models.Revision{}
+	if err := query.Order("version DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, RevisionListResponse{Data: revisions, Pagination: pagination})
+}
+
+// @Summary Get a revision of a post
+// @Description Get the content of a post as it was at a specific version
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.Revision "Successfully retrieved revision"
+// @Failure 400 {object} map[string]string "Invalid post ID or version"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/revisions/{version} [get]
+func (rc *RevisionController) GetPostRevision(c *gin.Context) {
+	rc.getRevision(c, models.TargetPost)
+}
+
+// @Summary Get a revision of a comment
+// @Description Get the content of a comment as it was at a specific version
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Comment ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} models.Revision "Successfully retrieved revision"
+// @Failure 400 {object} map[string]string "Invalid comment ID or version"
+// @Failure 404 {object} map[string]string "Comment or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/revisions/{version} [get]
+func (rc *RevisionController) GetCommentRevision(c *gin.Context) {
+	rc.getRevision(c, models.TargetComment)
+}
+
+func (rc *RevisionController) getRevision(c *gin.Context, targetType string) {
+	version, err := parseVersion(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+	rev, ok := findRevision(c, targetType, targetID, version)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, rev)
+}
+
+// @Summary Diff two revisions of a post
+// @Description Get a unified diff between two versions of a post; the first line of each version is its title. Defaults to the latest version compared with the one before it
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Post ID"
+// @Param from query int false "Older version (default: to - 1)"
+// @Param to query int false "Newer version (default: latest)"
+// @Success 200 {object} RevisionDiffResponse "Unified diff, empty when the versions are identical"
+// @Failure 400 {object} map[string]string "Invalid post ID or version"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/revisions/diff [get]
+func (rc *RevisionController) DiffPostRevisions(c *gin.Context) {
+	rc.diffRevisions(c, models.TargetPost)
+}
+
+// @Summary Diff two revisions of a comment
+// @Description Get a unified diff between two versions of a comment. Defaults to the latest version compared with the one before it
+// @Tags revisions
+// @Produce  json
+// @Param id path int true "Comment ID"
+// @Param from query int false "Older version (default: to - 1)"
+// @Param to query int false "Newer version (default: latest)"
+// @Success 200 {object} RevisionDiffResponse "Unified diff, empty when the versions are identical"
+// @Failure 400 {object} map[string]string "Invalid comment ID or version"
+// @Failure 404 {object} map[string]string "Comment or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/revisions/diff [get]
+func (rc *RevisionController) DiffCommentRevisions(c *gin.Context) {
+	rc.diffRevisions(c, models.TargetComment)
+}
+
+func (rc *RevisionController) diffRevisions(c *gin.Context, targetType string) {
+	targetID, ok := findTarget(c, targetType)
+	if !ok {
+		return
+	}
+
+	var to, from int
+	var err error
+	if v := c.Query("to"); v != "" {
+		if to, err = parseVersion(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to: " + err.Error()})
+			return
+		}
+	} else if err := requestDB(c).Model(&models.Revision{}).
+		Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Select("COALESCE(MAX(version), 0)").Scan(&to).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve revisions"})
+		return
+	}
+	if v := c.Query("from"); v != "" {
+		if from, err = parseVersion(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from: " + err.Error()})
+			return
+		}
+	} else {
+		from = max(to-1, 1)
+	}
+	if to == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+
+	fromRev, ok := findRevision(c, targetType, targetID, from)
+	if !ok {
+		return
+	}
+	toRev, ok := findRevision(c, targetType, targetID, to)
+	if !ok {
+		return
+	}
+
+	diff := revision.Unified(
+		fmt.Sprintf("%s/%d@v%d", targetType, targetID, from), fmt.Sprintf("%s/%d@v%d", targetType, targetID, to),
+		revision.Document(*fromRev), revision.Document(*toRev))
+	c.JSON(http.StatusOK, RevisionDiffResponse{From: from, To: to, Diff: diff})
+}
+
+// @Summary Revert a post to a previous revision
+// @Description Restore the title and content of an earlier version. The revert is stored as a new revision, so history is never rewritten. Allowed for the author and moderators
+// @Tags revisions
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Post ID"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} models.Post "Successfully reverted post"
+// @Failure 400 {object} map[string]string "Invalid post ID or version"
+// @Failure 403 {object} map[string]string "Not the author of the post or a moderator"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /posts/{id}/revisions/{version}/revert [post]
+func (rc *RevisionController) RevertPost(c *gin.Context) {
+	version, err := parseVersion(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetID, ok := findTarget(c, models.TargetPost)
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := requestDB(c).First(&post, targetID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post"})
+		return
+	}
+	if !canModerate(c, post.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to revert this post"})
+		return
+	}
+	rev, ok := findRevision(c, models.TargetPost, post.ID, version)
+	if !ok {
+		return
+	}
+
+	before := audit.Snapshot(post)
+	original := revision.FromPost(&post)
+	post.Title, post.Content, post.ContentFormat = rev.Title, rev.Content, rev.ContentFormat
+	if revision.SameContent(original, revision.FromPost(&post)) {
+		c.JSON(http.StatusOK, post)
+		return
+	}
+
+	edited := revision.FromPost(&post)
+	edited.EditorID = middleware.CurrentUserID(c)
+	edited.RevertedFrom = &rev.Version
+	mentioned, err := saveEdit(requestDB(c), &post, &post.EditedAt, original, edited, post.UserID, post.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert post"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, models.TargetPost, post.ID, before, post)
+	if post.Status == models.PostPublished {
+		notifyMentioned(c, mentioned, &post.ID, nil)
+	}
+	c.JSON(http.StatusOK, post)
+}
+
+// @Summary Revert a comment to a previous revision
+// @Description Restore the content of an earlier version. The revert is stored as a new revision, so history is never rewritten. Allowed for the author and moderators
+// @Tags revisions
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "Comment ID"
+// @Param version path int true "Version to restore"
+// @Success 200 {object} models.Comment "Successfully reverted comment"
+// @Failure 400 {object} map[string]string "Invalid comment ID or version"
+// @Failure 403 {object} map[string]string "Not the author of the comment or a moderator"
+// @Failure 404 {object} map[string]string "Comment or revision not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /comments/{id}/revisions/{version}/revert [post]
+func (rc *RevisionController) RevertComment(c *gin.Context) {
+	version, err := parseVersion(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetID, ok := findTarget(c, models.TargetComment)
+	if !ok {
+		return
+	}
+
+	var comment models.Comment
+	if err := requestDB(c).First(&comment, targetID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comment"})
+		return
+	}
+	if !canModerate(c, comment.UserID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to revert this comment"})
+		return
+	}
+	rev, ok := findRevision(c, models.TargetComment, comment.ID, version)
+	if !ok {
+		return
+	}
+
+	before := audit.Snapshot(comment)
+	original := revision.FromComment(&comment)
+	comment.Content, comment.ContentFormat = rev.Content, rev.ContentFormat
+	if revision.SameContent(original, revision.FromComment(&comment)) {
+		c.JSON(http.StatusOK, comment)
+		return
+	}
+
+	edited := revision.FromComment(&comment)
+	edited.EditorID = middleware.CurrentUserID(c)
+	edited.RevertedFrom = &rev.Version
+	mentioned, err := saveEdit(requestDB(c), &comment, &comment.EditedAt, original, edited, comment.UserID, comment.CreatedAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revert comment"})
+		return
+	}
+	recordAudit(c, models.AuditUpdate, models.TargetComment, comment.ID, before, comment)
+	notifyMentioned(c, mentioned, &comment.PostID, &comment.ID)
+	c.JSON(http.StatusOK, comment)
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"social_media_server/render"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 30 * 24 * time.Hour
+	defaultTrendingLimit  = 10
+)
+
+type TagController struct{}
+
+// TrendingTag: số post đã xuất bản trong khoảng thời gian có gắn tag
+type TrendingTag struct {
+	Tag       string `json:"tag"`
+	PostCount int64  `json:"post_count"`
+}
+
+type TrendingTagsResponse struct {
+	Data   []TrendingTag `json:"data"`
+	Window string        `json:"window"`
+}
+
+func NewTagController() *TagController {
+	return &TagController{}
+}
+
+// notifyMentioned báo cho những người vừa được mention thêm khi sửa nội dung; lỗi chỉ được log
+func notifyMentioned(c *gin.Context, userIDs []uint, postID, commentID *uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+	if err := config.Notifier.Mentioned(c.Request.Context(), userIDs, middleware.CurrentUserID(c), postID, commentID); err != nil {
+		log.Printf("Failed to send mention notifications for post %d: %v", *postID, err)
+	}
+}
+
+// @Summary List posts with a hashtag
+// @Description Get the published posts tagged with a hashtag, newest first unless sort says otherwise. The tag is matched case-insensitively and may be given with or without the leading #. Accepts the same status, sort, window, include and comments_limit parameters as the main post listing
+// @Tags tags
+// @Produce  json
+// @Param tag path string true "Hashtag"
+// @Param status query string false "Only posts with this status" Enums(draft, scheduled, published, archived)
+// @Param sort query string false "Ordering: new (newest first), hot (recent and popular), top (highest score), discussed (most comments) or active (latest comment first)" Enums(new, hot, top, discussed, active) default(new)
+// @Param window query string false "Only posts published within this window, for sort=top and sort=discussed" Enums(day, week, month, all) default(day)
+// @Param include query string false "Comma-separated relations to embed: comments, attachments (default: all)"
+// @Param comments_limit query int false "Embed at most this many of the latest comments per post (1-100)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Posts per page (1-100)" default(20)
+// @Success 200 {object} PostListResponse "Successfully retrieved posts"
+// @Failure 400 {object} map[string]string "Invalid tag or query parameters"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags/{tag}/posts [get]
+func (tc *TagController) GetTagPosts(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inc, err := parsePostIncludes(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := parsePostSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tag := render.NormalizeHashtag(c.Param("tag"))
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hashtag"})
+		return
+	}
+
+	tagged := readDB(c).Model(&models.Tagging{}).
+		Select("taggings.target_id").
+		Joins("JOIN tags ON tags.id = taggings.tag_id").
+		Where("tags.name = ? AND taggings.target_type = ?", tag, models.TargetPost)
+	query, err := postListQuery(c, readDB(c).Model(&models.Post{}).Where("id IN (?)", tagged))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query = sort.filter(query)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count posts"})
+		return
+	}
+
+	posts := []models.Post{}
+	if err := sort.order(inc.preload(query)).
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts"})
+		return
+	}
+	if err := inc.loadLimited(readDB(c), posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comments"})
+		return
+	}
+	if err := loadPostReactions(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reactions"})
+		return
+	}
+	if err := loadPostBookmarks(c, posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookmarks"})
+		return
+	}
+	for i := range posts {
+		signAttachmentURLs(c, posts[i].Attachments)
+	}
+
+	c.JSON(http.StatusOK, PostListResponse{Data: posts, Pagination: pagination})
+}
+
+// @Summary List trending hashtags
+// @Description Get the hashtags used by the most published posts within a recent time window, most used first
+// @Tags tags
+// @Produce  json
+// @Param window query string false "Time window as a Go duration, up to 720h" default(24h)
+// @Param limit query int false "Maximum number of tags (1-100)" default(10)
+// @Success 200 {object} TrendingTagsResponse "Successfully retrieved trending tags"
+// @Failure 400 {object} map[string]string "Invalid window or limit"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /tags/trending [get]
+func (tc *TagController) GetTrendingTags(c *gin.Context) {
+	window := defaultTrendingWindow
+	if v := c.Query("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > maxTrendingWindow {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "window must be a duration between 1s and 720h"})
+			return
+		}
+		window = d
+	}
+	limit := defaultTrendingLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and " + strconv.Itoa(maxPageSize)})
+			return
+		}
+		limit = n
+	}
+
+	trending := []TrendingTag{}
+	if err := readDB(c).Model(&models.Tagging{}).
+		Select("tags.name AS tag, COUNT(DISTINCT posts.id) AS post_count").
+		Joins("JOIN tags ON tags.id = taggings.tag_id").
+		Joins("JOIN posts ON posts.id = taggings.target_id").
+		Where("taggings.target_type = ?", models.TargetPost).
+		Where("posts.status = ? AND posts.hidden = ? AND posts.deleted_at IS NULL AND posts.created_at >= ?",
+			models.PostPublished, false, time.Now().Add(-window)).
+		Group("tags.name").
+		Order("post_count DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&trending).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trending tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, TrendingTagsResponse{Data: trending, Window: window.String()})
+}
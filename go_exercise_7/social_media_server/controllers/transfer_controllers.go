@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/models"
+	"social_media_server/transfer"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransferController struct{}
+
+// ImportErrorResponse kèm report khi lỗi xảy ra giữa chừng, các batch trước đó đã được lưu
+type ImportErrorResponse struct {
+	Error  string                 `json:"error"`
+	Report *transfer.ImportReport `json:"report,omitempty"`
+}
+
+func NewTransferController() *TransferController {
+	return &TransferController{}
+}
+
+// @Summary Export all posts and comments
+// @Description Stream every post with its comments as NDJSON (one post per line), a JSON array, or CSV (a "post" row followed by its "comment" rows). Includes drafts and hidden content, so only admins can export
+// @Tags transfer
+// @Produce  json
+// @Produce  plain
+// @Security ApiKeyAuth
+// @Param format query string false "Export format" Enums(ndjson, json, csv) default(ndjson)
+// @Success 200 {array} transfer.PostRecord "Exported posts"
+// @Failure 400 {object} map[string]string "Invalid format"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Admin role required"
+// @Router /export [get]
+func (tc *TransferController) ExportData(c *gin.Context) {
+	format := c.DefaultQuery("format", transfer.FormatNDJSON)
+	if !transfer.ValidFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected ndjson, json or csv"})
+		return
+	}
+
+	filename := fmt.Sprintf("social-media-export-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", transfer.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// Header đã gửi đi nên lỗi giữa chừng chỉ có thể log lại
+	if count, err := transfer.Export(c.Request.Context(), config.DB, c.Writer, format); err != nil {
+		log.Printf("Export failed after %d posts: %v", count, err)
+	}
+}
+
+// @Summary Import posts and comments
+// @Description Import posts with their comments from a request body in the same formats produced by the export endpoint. Records are validated and written in batches, each batch in its own transaction; invalid records are skipped and reported. Only admins can import.
+// @Tags transfer
+// @Accept  json
+// @Accept  plain
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param format query string false "Import format" Enums(ndjson, json, csv) default(ndjson)
+// @Param preserve_ids query bool false "Keep IDs from the file instead of assigning new ones"
+// @Param preserve_timestamps query bool false "Keep created_at/updated_at from the file"
+// @Param batch_size query int false "Number of posts per transaction" default(100)
+// @Param data body []transfer.PostRecord true "Records to import"
+// @Success 200 {object} transfer.ImportReport "Import report with per-record errors"
+// @Failure 400 {object} ImportErrorResponse "Invalid parameters or malformed input"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 403 {object} map[string]string "Admin role required"
+// @Router /import [post]
+func (tc *TransferController) ImportData(c *gin.Context) {
+	format := c.DefaultQuery("format", transfer.FormatNDJSON)
+	if !transfer.ValidFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected ndjson, json or csv"})
+		return
+	}
+
+	opts := transfer.ImportOptions{BatchSize: transfer.DefaultBatchSize}
+	var err error
+	if v := c.Query("preserve_ids"); v != "" {
+		if opts.PreserveIDs, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preserve_ids value"})
+			return
+		}
+	}
+	if v := c.Query("preserve_timestamps"); v != "" {
+		if opts.PreserveTimestamps, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid preserve_timestamps value"})
+			return
+		}
+	}
+	if v := c.Query("batch_size"); v != "" {
+		if opts.BatchSize, err = strconv.Atoi(v); err != nil || opts.BatchSize <= 0 || opts.BatchSize > 1000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "batch_size must be between 1 and 1000"})
+			return
+		}
+	}
+
+	report, err := transfer.Import(c.Request.Context(), config.DB, c.Request.Body, format, opts)
+	if report != nil && report.PostsImported+report.CommentsImported > 0 {
+		// chỉ ghi tóm tắt, từng post được nhập không có bản ghi riêng
+		recordAudit(c, models.AuditCreate, resourceImport, 0, nil, gin.H{
+			"format":            format,
+			"posts_imported":    report.PostsImported,
+			"comments_imported": report.CommentsImported,
+			"failed":            report.Failed,
+		})
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ImportErrorResponse{Error: err.Error(), Report: report})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserController struct{}
+
+type CreateUserRequest struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+type CreateUserResponse struct {
+	User   models.User `json:"user"`
+	APIKey string      `json:"api_key"`
+}
+
+type UserListResponse struct {
+	Data       []models.User `json:"data"`
+	Pagination Pagination    `json:"pagination"`
+}
+
+func NewUserController() *UserController {
+	return &UserController{}
+}
+
+func loadFollowCounts(db *gorm.DB, user *models.User) error {
+	if err := db.Model(&models.Follow{}).Where("followee_id = ?", user.ID).Count(&user.FollowerCount).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Follow{}).Where("follower_id = ?", user.ID).Count(&user.FollowingCount).Error
+}
+
+// @Summary Register a new user
+// @Description Create a user account. The returned api_key is shown only once and must be sent as "Authorization: Bearer <api_key>"
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param user body CreateUserRequest true "Username (3-32 letters, digits or underscores) and optional display name"
+// @Success 201 {object} CreateUserResponse "Successfully created user"
+// @Failure 400 {object} map[string]string "Invalid username"
+// @Failure 409 {object} map[string]string "Username already taken"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users [post]
+func (uc *UserController) CreateUser(c *gin.Context) {
+	var req CreateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !models.ValidUsername(req.Username) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Username must be 3-32 letters, digits or underscores"})
+		return
+	}
+
+	var existing int64
+	if err := requestDB(c).Unscoped().Model(&models.User{}).Where("username = ?", req.Username).Count(&existing).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		return
+	}
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already taken"})
+		return
+	}
+
+	apiKey, hash, err := middleware.NewAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+		return
+	}
+
+	user := models.User{Username: req.Username, DisplayName: req.DisplayName, APIKeyHash: hash}
+	if user.DisplayName == "" {
+		user.DisplayName = user.Username
+	}
+	if err := requestDB(c).Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		return
+	}
+
+	recordAudit(c, models.AuditCreate, resourceUser, user.ID, nil, user)
+	c.JSON(http.StatusCreated, CreateUserResponse{User: user, APIKey: apiKey})
+}
+
+// @Summary Get the current user
+// @Description Get the profile of the authenticated user with follower and following counts
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.User "Successfully retrieved user"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/me [get]
+func (uc *UserController) GetMe(c *gin.Context) {
+	user, _ := middleware.CurrentUser(c)
+	if err := loadFollowCounts(requestDB(c), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count followers"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// @Summary Get a user by ID
+// @Description Get a user profile with follower and following counts
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User "Successfully retrieved user"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id} [get]
+func (uc *UserController) GetUser(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if err := loadFollowCounts(requestDB(c), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count followers"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// findUserParam đọc user theo :id và tự trả lỗi cho client nếu không tìm được
+func findUserParam(c *gin.Context) (*models.User, bool) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return nil, false
+	}
+
+	var user models.User
+	if err := requestDB(c).First(&user, uint(id)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// @Summary Follow a user
+// @Description Start following a user so their posts appear in the home feed. Following someone twice has no effect
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID to follow"
+// @Success 200 {object} map[string]string "Message: Now following user"
+// @Failure 400 {object} map[string]string "Invalid user ID or following yourself"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/follow [post]
+func (uc *UserController) FollowUser(c *gin.Context) {
+	current, _ := middleware.CurrentUser(c)
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if target.ID == current.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+		return
+	}
+
+	follow := models.Follow{FollowerID: current.ID, FolloweeID: target.ID}
+	result := requestDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+
+	if result.RowsAffected > 0 {
+		recordAudit(c, models.AuditCreate, resourceFollow, target.ID, nil, follow)
+		if err := config.Timeline.Followed(c.Request.Context(), current.ID, target.ID); err != nil {
+			log.Printf("Failed to update timeline of user %d: %v", current.ID, err)
+		}
+		if err := config.Notifier.Followed(c.Request.Context(), current.ID, target.ID); err != nil {
+			log.Printf("Failed to send follow notification to user %d: %v", target.ID, err)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Now following user"})
+}
+
+// @Summary Unfollow a user
+// @Description Stop following a user
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID to unfollow"
+// @Success 200 {object} map[string]string "Message: User unfollowed"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "User not found or not followed"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/follow [delete]
+func (uc *UserController) UnfollowUser(c *gin.Context) {
+	current, _ := middleware.CurrentUser(c)
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	result := requestDB(c).Where("follower_id = ? AND followee_id = ?", current.ID, target.ID).Delete(&models.Follow{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not following this user"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceFollow, target.ID, models.Follow{FollowerID: current.ID, FolloweeID: target.ID}, nil)
+
+	if err := config.Timeline.Unfollowed(c.Request.Context(), current.ID, target.ID); err != nil {
+		log.Printf("Failed to update timeline of user %d: %v", current.ID, err)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed"})
+}
+
+// @Summary List followers of a user
+// @Description Get the users following a user, most recent first
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Users per page (1-100)" default(20)
+// @Success 200 {object} UserListResponse "Successfully retrieved followers"
+// @Failure 400 {object} map[string]string "Invalid user ID or query parameters"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/followers [get]
+func (uc *UserController) GetFollowers(c *gin.Context) {
+	uc.listFollows(c, "followee_id", "follower_id")
+}
+
+// @Summary List users followed by a user
+// @Description Get the users a user is following, most recent first
+// @Tags users
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Users per page (1-100)" default(20)
+// @Success 200 {object} UserListResponse "Successfully retrieved followed users"
+// @Failure 400 {object} map[string]string "Invalid user ID or query parameters"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/following [get]
+func (uc *UserController) GetFollowing(c *gin.Context) {
+	uc.listFollows(c, "follower_id", "followee_id")
+}
+
+func (uc *UserController) listFollows(c *gin.Context, matchColumn, userColumn string) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	follows := requestDB(c).Model(&models.Follow{}).Where(matchColumn+" = ?", user.ID)
+	if err := follows.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users"})
+		return
+	}
+
+	users := []models.User{}
+	if err := requestDB(c).Joins("JOIN follows ON follows."+userColumn+" = users.id").
+		Where("follows."+matchColumn+" = ?", user.ID).
+		Order("follows.created_at DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, UserListResponse{Data: users, Pagination: pagination})
+}
+
+type BlockListResponse struct {
+	Data       []models.Block `json:"data"`
+	Pagination Pagination     `json:"pagination"`
+}
+
+// blocked cho biết giữa hai người dùng có ai chặn ai không
+func blocked(db *gorm.DB, a, b uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// @Summary Block a user
+// @Description Block a user. Neither of you can start a direct conversation or send direct messages to the other while the block exists. Blocking someone twice has no effect
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID to block"
+// @Success 200 {object} map[string]string "Message: User blocked"
+// @Failure 400 {object} map[string]string "Invalid user ID or blocking yourself"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/block [post]
+func (uc *UserController) BlockUser(c *gin.Context) {
+	current, _ := middleware.CurrentUser(c)
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if target.ID == current.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+
+	block := models.Block{BlockerID: current.ID, BlockedID: target.ID}
+	result := requestDB(c).Clauses(clause.OnConflict{DoNothing: true}).Create(&block)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+	if result.RowsAffected > 0 {
+		recordAudit(c, models.AuditCreate, resourceBlock, target.ID, nil, block)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+// @Summary Unblock a user
+// @Description Remove a block on a user
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param id path int true "User ID to unblock"
+// @Success 200 {object} map[string]string "Message: User unblocked"
+// @Failure 400 {object} map[string]string "Invalid user ID"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 404 {object} map[string]string "User not found or not blocked"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/{id}/block [delete]
+func (uc *UserController) UnblockUser(c *gin.Context) {
+	current, _ := middleware.CurrentUser(c)
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	result := requestDB(c).Where("blocker_id = ? AND blocked_id = ?", current.ID, target.ID).Delete(&models.Block{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You have not blocked this user"})
+		return
+	}
+	recordAudit(c, models.AuditDelete, resourceBlock, target.ID, models.Block{BlockerID: current.ID, BlockedID: target.ID}, nil)
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// @Summary List blocked users
+// @Description Get the users blocked by the current user, most recent first
+// @Tags users
+// @Produce  json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Users per page (1-100)" default(20)
+// @Success 200 {object} BlockListResponse "Successfully retrieved blocked users"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 401 {object} map[string]string "Authentication required"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /users/me/blocks [get]
+func (uc *UserController) GetBlocks(c *gin.Context) {
+	pagination, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	current, _ := middleware.CurrentUser(c)
+
+	query := requestDB(c).Model(&models.Block{}).Where("blocker_id = ?", current.ID)
+	if err := query.Count(&pagination.Total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count blocked users"})
+		return
+	}
+
+	blocks := []models.Block{}
+	if err := query.Preload("Blocked").
+		Order("created_at DESC").
+		Offset(pagination.Offset()).Limit(pagination.PageSize).
+		Find(&blocks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, BlockListResponse{Data: blocks, Pagination: pagination})
+}
@@ -0,0 +1,65 @@
+package deletion
+
+import (
+	"context"
+	"log"
+	"social_media_server/models"
+	"social_media_server/reactions"
+	"social_media_server/storage"
+
+	"gorm.io/gorm"
+)
+
+// Post xoá một post như khi tác giả hoặc moderator xoá: xoá mềm post và comment của nó, xoá hẳn
+// reaction/vote, bookmark và bản ghi file đính kèm. Phải gọi trong transaction; file đính kèm được
+// trả về để người gọi xoá blob bằng Blobs sau khi transaction commit.
+func Post(tx *gorm.DB, postID uint) ([]models.Attachment, error) {
+	var commentIDs []uint
+	if err := tx.Model(&models.Comment{}).Where("post_id = ?", postID).Pluck("id", &commentIDs).Error; err != nil {
+		return nil, err
+	}
+	if err := reactions.Delete(tx, models.TargetComment, commentIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
+		return nil, err
+	}
+
+	var attachments []models.Attachment
+	if err := tx.Where("post_id = ?", postID).Find(&attachments).Error; err != nil {
+		return nil, err
+	}
+	if len(attachments) > 0 {
+		if err := tx.Unscoped().Where("post_id = ?", postID).Delete(&models.Attachment{}).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Delete(&models.Post{}, postID).Error; err != nil {
+		return nil, err
+	}
+	if err := reactions.Delete(tx, models.TargetPost, []uint{postID}); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.Bookmark{}).Error; err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// Blobs xoá file của các đính kèm trong storage, lỗi chỉ được log vì bản ghi DB mới là nguồn chính
+func Blobs(ctx context.Context, blobs storage.BlobStore, attachments []models.Attachment) {
+	if blobs == nil {
+		return
+	}
+	for _, a := range attachments {
+		for _, key := range []string{a.StorageKey, a.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := blobs.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"social_media_server/config"
+	"social_media_server/storage"
+	"social_media_server/store"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// runDoctor kiểm tra các phụ thuộc bên ngoài với cấu hình hiện tại và thoát với mã 1 nếu có
+// phần lỗi. Không migrate hay sửa dữ liệu, chỉ ghi rồi xoá một object thử trong storage.
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 30*time.Second, "time limit for each check")
+	fs.Parse(args)
+
+	failed := false
+	check := func(name string, run func(ctx context.Context) (string, error)) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		defer cancel()
+		detail, err := run(ctx)
+		if err != nil {
+			failed = true
+			fmt.Printf("FAIL  %-9s %v\n", name, err)
+			return false
+		}
+		fmt.Printf("OK    %-9s %s\n", name, detail)
+		return true
+	}
+
+	if check("database", checkDatabase) {
+		if config.ReplicaCount > 0 {
+			check("replicas", checkReplicas)
+		}
+		check("schema", checkSchema)
+	}
+	if config.RedisInUse() || os.Getenv("REDIS_ADDR") != "" {
+		check("redis", checkRedis)
+	}
+	check("storage", checkStorage)
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func checkDatabase(ctx context.Context) (string, error) {
+	if err := config.TryOpenDB(ctx); err != nil {
+		return "", err
+	}
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s, ping %s", config.DBDriver, time.Since(start).Round(time.Microsecond)), nil
+}
+
+// checkReplicas chạy một truy vấn cho mỗi replica; có nhiều replica thì truy vấn được chia lần lượt
+// nên mỗi replica nhận đúng một lần
+func checkReplicas(ctx context.Context) (string, error) {
+	for i := 0; i < config.ReplicaCount; i++ {
+		var one int
+		if err := config.ReadDB.WithContext(ctx).Raw("SELECT 1").Scan(&one).Error; err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d reachable", config.ReplicaCount), nil
+}
+
+func checkSchema(ctx context.Context) (string, error) {
+	db := config.DB.WithContext(ctx)
+	var missing []string
+	for _, model := range store.Models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return "", err
+		}
+		if !db.Migrator().HasTable(stmt.Schema.Table) {
+			missing = append(missing, stmt.Schema.Table)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%d of %d tables missing (%s), run the migrate command",
+			len(missing), len(store.Models()), strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("%d tables present", len(store.Models())), nil
+}
+
+func checkRedis(ctx context.Context) (string, error) {
+	rdb := config.NewRedisClient()
+	defer rdb.Close()
+	start := time.Now()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s, ping %s", rdb.Options().Addr, time.Since(start).Round(time.Microsecond)), nil
+}
+
+// checkStorage ghi, đọc lại rồi xoá một object thử để kiểm tra cả quyền ghi lẫn quyền xoá
+func checkStorage(ctx context.Context) (string, error) {
+	blobs, driver, err := config.OpenStorage(ctx)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := "doctor/" + hex.EncodeToString(b)
+	payload := []byte("social_media_server doctor " + time.Now().UTC().Format(time.RFC3339))
+
+	if err := blobs.Put(ctx, key, bytes.NewReader(payload), int64(len(payload)), "text/plain"); err != nil {
+		return "", fmt.Errorf("write: %w", err)
+	}
+	readErr := readBack(ctx, blobs, key, payload)
+	if err := blobs.Delete(ctx, key); err != nil {
+		if readErr != nil {
+			log.Printf("Failed to delete probe object %s: %v", key, err)
+			return "", readErr
+		}
+		return "", fmt.Errorf("delete: %w", err)
+	}
+	if readErr != nil {
+		return "", readErr
+	}
+	return driver + ", write/read/delete", nil
+}
+
+func readBack(ctx context.Context, blobs storage.BlobStore, key string, want []byte) error {
+	r, err := blobs.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return errors.New("read: object differs from what was written")
+	}
+	return nil
+}
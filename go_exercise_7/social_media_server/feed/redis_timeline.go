@@ -0,0 +1,206 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"social_media_server/models"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+const DefaultTimelineLength = 800
+
+// RedisTimeline (fan-out-on-write) đẩy ID post mới vào sorted set timeline:<user> của từng follower,
+// đọc feed chỉ cần một lệnh ZREVRANGEBYSCORE. Timeline chưa có trong Redis thì đọc từ DB như ReadTimeline.
+type RedisTimeline struct {
+	db       *gorm.DB
+	rdb      *redis.Client
+	maxLen   int64
+	fallback *ReadTimeline
+}
+
+func NewRedisTimeline(db *gorm.DB, rdb *redis.Client, maxLen int64) *RedisTimeline {
+	if maxLen <= 0 {
+		maxLen = DefaultTimelineLength
+	}
+	return &RedisTimeline{db: db, rdb: rdb, maxLen: maxLen, fallback: NewReadTimeline(db)}
+}
+
+func timelineKey(userID uint) string {
+	return fmt.Sprintf("timeline:%d", userID)
+}
+
+func (t *RedisTimeline) Page(ctx context.Context, userID uint, cursor *Cursor, limit int) ([]models.Post, *Cursor, error) {
+	key := timelineKey(userID)
+	exists, err := t.rdb.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists == 0 {
+		if err := t.rebuild(ctx, userID); err != nil {
+			return nil, nil, err
+		}
+		// Không theo dõi ai hoặc chưa có post nào thì timeline vẫn trống, đọc từ DB cho chắc chắn
+		if exists, err = t.rdb.Exists(ctx, key).Result(); err != nil || exists == 0 {
+			return t.fallback.Page(ctx, userID, cursor, limit)
+		}
+	}
+
+	max := "+inf"
+	if cursor != nil {
+		max = strconv.FormatInt(cursor.CreatedAt.UnixMicro(), 10)
+	}
+	// Lấy dư để bỏ được các phần tử cùng score đã trả ở trang trước và các post đã bị xoá
+	entries, err := t.rdb.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   max,
+		Count: int64(limit) * 2,
+	}).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		id, err := strconv.ParseUint(fmt.Sprint(entry.Member), 10, 32)
+		if err != nil {
+			continue
+		}
+		if cursor != nil && int64(entry.Score) == cursor.CreatedAt.UnixMicro() && uint(id) >= cursor.ID {
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	if len(ids) == 0 {
+		return []models.Post{}, nil, nil
+	}
+
+	var posts []models.Post
+	if err := t.db.WithContext(ctx).Preload("Attachments").
+		Where("id IN ? AND hidden = ? AND status = ?", ids, false, models.PostPublished).
+		Order("created_at DESC").Order("id DESC").
+		Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(posts) > limit+1 {
+		posts = posts[:limit+1]
+	}
+	page, next, err := paginate(posts, limit)
+	// Redis trả đủ số phần tử đã xin thì có thể vẫn còn trang sau dù DB lọc bớt post đã xoá
+	if next == nil && len(page) > 0 && int64(len(entries)) == int64(limit)*2 {
+		last := page[len(page)-1]
+		next = &Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return page, next, err
+}
+
+func (t *RedisTimeline) PostCreated(ctx context.Context, post *models.Post) error {
+	if post.UserID == nil {
+		return nil
+	}
+
+	var followerIDs []uint
+	if err := t.db.WithContext(ctx).Model(&models.Follow{}).
+		Where("followee_id = ?", *post.UserID).
+		Pluck("follower_id", &followerIDs).Error; err != nil {
+		return err
+	}
+
+	if len(followerIDs) == 0 {
+		return nil
+	}
+
+	// Chỉ đẩy vào timeline đã tồn tại; timeline chưa có sẽ được dựng lại đầy đủ từ DB khi đọc
+	existsCmds := make([]*redis.IntCmd, len(followerIDs))
+	if _, err := t.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, followerID := range followerIDs {
+			existsCmds[i] = pipe.Exists(ctx, timelineKey(followerID))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	member := &redis.Z{Score: float64(post.CreatedAt.UnixMicro()), Member: post.ID}
+	_, err := t.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, followerID := range followerIDs {
+			if existsCmds[i].Val() == 0 {
+				continue
+			}
+			key := timelineKey(followerID)
+			pipe.ZAdd(ctx, key, member)
+			pipe.ZRemRangeByRank(ctx, key, 0, -t.maxLen-1)
+		}
+		return nil
+	})
+	return err
+}
+
+// rebuild dựng timeline của user từ các post gần nhất của những người user đang theo dõi
+func (t *RedisTimeline) rebuild(ctx context.Context, userID uint) error {
+	followees := t.db.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", userID)
+
+	var posts []models.Post
+	if err := t.db.WithContext(ctx).Select("id", "created_at").
+		Where("user_id IN (?) AND hidden = ? AND status = ?", followees, false, models.PostPublished).
+		Order("created_at DESC").Limit(int(t.maxLen)).
+		Find(&posts).Error; err != nil {
+		return err
+	}
+	return t.addPosts(ctx, timelineKey(userID), posts)
+}
+
+func (t *RedisTimeline) addPosts(ctx context.Context, key string, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	members := make([]*redis.Z, len(posts))
+	for i, post := range posts {
+		members[i] = &redis.Z{Score: float64(post.CreatedAt.UnixMicro()), Member: post.ID}
+	}
+	_, err := t.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, members...)
+		pipe.ZRemRangeByRank(ctx, key, 0, -t.maxLen-1)
+		return nil
+	})
+	return err
+}
+
+// Followed chép các post gần đây của người được theo dõi vào timeline (nếu timeline đã được dựng)
+func (t *RedisTimeline) Followed(ctx context.Context, followerID, followeeID uint) error {
+	key := timelineKey(followerID)
+	exists, err := t.rdb.Exists(ctx, key).Result()
+	if err != nil || exists == 0 {
+		return err
+	}
+
+	var posts []models.Post
+	if err := t.db.WithContext(ctx).Select("id", "created_at").
+		Where("user_id = ?", followeeID).
+		Order("created_at DESC").Limit(int(t.maxLen)).
+		Find(&posts).Error; err != nil {
+		return err
+	}
+	return t.addPosts(ctx, key, posts)
+}
+
+func (t *RedisTimeline) Unfollowed(ctx context.Context, followerID, followeeID uint) error {
+	var postIDs []uint
+	if err := t.db.WithContext(ctx).Model(&models.Post{}).
+		Where("user_id = ?", followeeID).
+		Order("created_at DESC").Limit(int(t.maxLen)).
+		Pluck("id", &postIDs).Error; err != nil {
+		return err
+	}
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(postIDs))
+	for i, id := range postIDs {
+		members[i] = id
+	}
+	return t.rdb.ZRem(ctx, timelineKey(followerID), members...).Err()
+}
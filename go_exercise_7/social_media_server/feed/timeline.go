@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"context"
+	"social_media_server/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Timeline xây dựng home feed: các post của những người mà user đang theo dõi, mới nhất trước
+type Timeline interface {
+	Page(ctx context.Context, userID uint, cursor *Cursor, limit int) ([]models.Post, *Cursor, error)
+	PostCreated(ctx context.Context, post *models.Post) error
+	Followed(ctx context.Context, followerID, followeeID uint) error
+	Unfollowed(ctx context.Context, followerID, followeeID uint) error
+}
+
+// ReadTimeline (fan-out-on-read) query trực tiếp từ bảng posts mỗi lần đọc feed
+type ReadTimeline struct {
+	db *gorm.DB
+}
+
+func NewReadTimeline(db *gorm.DB) *ReadTimeline {
+	return &ReadTimeline{db: db}
+}
+
+func (t *ReadTimeline) Page(ctx context.Context, userID uint, cursor *Cursor, limit int) ([]models.Post, *Cursor, error) {
+	followees := t.db.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", userID)
+
+	query := t.db.WithContext(ctx).Preload("Attachments").
+		Where("user_id IN (?) AND hidden = ? AND status = ?", followees, false, models.PostPublished).
+		Order("created_at DESC").Order("id DESC").
+		Limit(limit + 1)
+	if cursor != nil {
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	}
+
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	return paginate(posts, limit)
+}
+
+func (t *ReadTimeline) PostCreated(ctx context.Context, post *models.Post) error { return nil }
+
+func (t *ReadTimeline) Followed(ctx context.Context, followerID, followeeID uint) error { return nil }
+
+func (t *ReadTimeline) Unfollowed(ctx context.Context, followerID, followeeID uint) error { return nil }
+
+// paginate cắt kết quả (đã lấy dư 1 phần tử) về đúng limit và tính cursor cho trang sau
+func paginate(posts []models.Post, limit int) ([]models.Post, *Cursor, error) {
+	if len(posts) <= limit {
+		return posts, nil, nil
+	}
+	posts = posts[:limit]
+	last := posts[len(posts)-1]
+	return posts, &Cursor{CreatedAt: last.CreatedAt.Truncate(time.Microsecond), ID: last.ID}, nil
+}
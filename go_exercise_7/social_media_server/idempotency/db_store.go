@@ -0,0 +1,75 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"social_media_server/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBStore lưu key trong bảng idempotency_keys, unique index trên key đảm bảo chỉ một request giữ được key
+type DBStore struct {
+	db *gorm.DB
+}
+
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+	// Xoá bản ghi cũ đã hết hạn để key được dùng lại
+	if err := db.Where(map[string]interface{}{"key": key}).Where("expires_at <= ?", now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, err
+	}
+
+	row := models.IdempotencyKey{Key: key, Fingerprint: fingerprint, ExpiresAt: now.Add(ttl)}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := db.Where(map[string]interface{}{"key": key}).First(&existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Bản ghi vừa bị request kia xoá (Release), thử giữ lại
+			return s.Reserve(ctx, key, fingerprint, ttl)
+		}
+		return nil, err
+	}
+	return &Response{
+		Fingerprint: existing.Fingerprint,
+		Status:      existing.Status,
+		ContentType: existing.ContentType,
+		Body:        existing.Body,
+	}, nil
+}
+
+func (s *DBStore) Complete(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	result := s.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where(map[string]interface{}{"key": key, "status": 0}).
+		Updates(map[string]interface{}{
+			"status":       resp.Status,
+			"content_type": resp.ContentType,
+			"body":         resp.Body,
+			"expires_at":   time.Now().Add(ttl),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotReserved
+	}
+	return nil
+}
+
+func (s *DBStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where(map[string]interface{}{"key": key, "status": 0}).Delete(&models.IdempotencyKey{}).Error
+}
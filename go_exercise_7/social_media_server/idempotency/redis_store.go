@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisKeyPrefix = "idempotency:"
+
+// Chỉ xoá key khi request đầu tiên chưa xong, không xoá response đã lưu
+var releaseScript = redis.NewScript(`
+local v = redis.call("GET", KEYS[1])
+if v and cjson.decode(v).status == 0 then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisStore lưu key dưới dạng JSON với TTL, SET NX đảm bảo chỉ một request giữ được key
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(rdb *redis.Client) *RedisStore {
+	return &RedisStore{rdb: rdb}
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error) {
+	data, err := json.Marshal(Response{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.rdb.SetNX(ctx, redisKeyPrefix+key, data, ttl).Result()
+	if err != nil || ok {
+		return nil, err
+	}
+
+	raw, err := s.rdb.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Key vừa hết hạn hoặc bị Release, thử giữ lại
+		return s.Reserve(ctx, key, fingerprint, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var existing Response
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func (s *RedisStore) Complete(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	ok, err := s.rdb.SetXX(ctx, redisKeyPrefix+key, data, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotReserved
+	}
+	return nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	return releaseScript.Run(ctx, s.rdb, []string{redisKeyPrefix + key}).Err()
+}
@@ -0,0 +1,41 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultTTL = 24 * time.Hour
+	// LockTTL là thời gian giữ key khi request đầu tiên đang chạy; nếu instance chết giữa chừng
+	// thì key được giải phóng sau khoảng này thay vì bị khoá suốt TTL
+	LockTTL = time.Minute
+)
+
+// ErrNotReserved: key không còn do request này giữ (đã hết hạn hoặc bị xoá)
+var ErrNotReserved = errors.New("idempotency key is not reserved")
+
+// Response là response đã lưu của request đầu tiên. Status = 0 khi request đó chưa xong.
+type Response struct {
+	Fingerprint string `json:"fingerprint"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+func (r *Response) Completed() bool {
+	return r.Status != 0
+}
+
+// Store giữ key giữa các instance. Reserve là thao tác nguyên tử: chỉ một request giành được key,
+// các request sau nhận lại bản ghi hiện có.
+type Store interface {
+	// Reserve giữ key cho request có fingerprint này; trả về (nil, nil) nếu giữ thành công,
+	// ngược lại trả về bản ghi của request trước
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error)
+	// Complete lưu response cho key đã giữ, giữ tới hết ttl
+	Complete(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+	// Release bỏ key khi request lỗi để client có thể thử lại với cùng key
+	Release(ctx context.Context, key string) error
+}
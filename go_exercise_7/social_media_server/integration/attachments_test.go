@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for x := 0; x < 640; x++ {
+		img.Set(x, x%480, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAttachments(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	id := alice.createPost("Post", "content")
+	base := fmt.Sprintf("/posts/%d/attachments", id)
+
+	bob.upload(http.StatusForbidden, base, "a.txt", []byte("hello"))
+	alice.upload(http.StatusBadRequest, "/posts/abc/attachments", "a.txt", []byte("hello"))
+	alice.upload(http.StatusNotFound, "/posts/999/attachments", "a.txt", []byte("hello"))
+	alice.upload(http.StatusUnsupportedMediaType, base, "page.html", []byte("<html><body>hi</body></html>"))
+	alice.upload(http.StatusRequestEntityTooLarge, base, "big.txt", bytes.Repeat([]byte("a"), 2<<20))
+	alice.expect(http.StatusBadRequest, http.MethodPost, base, nil)
+
+	var uploaded struct {
+		ID           uint   `json:"ID"`
+		ContentType  string `json:"content_type"`
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+	}
+	alice.upload(http.StatusCreated, base, "pixel.png", pngImage(t)).decode(t, &uploaded)
+	if uploaded.ContentType != "image/png" || uploaded.URL == "" || uploaded.ThumbnailURL == "" {
+		t.Fatalf("uploaded image: %+v", uploaded)
+	}
+	textID := alice.upload(http.StatusCreated, base, "notes.txt", []byte("plain notes")).idOf(t)
+
+	var list []struct {
+		URL string `json:"url"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base, nil).decode(t, &list)
+	if len(list) != 2 {
+		t.Fatalf("attachments: %+v", list)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/posts/abc/attachments", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/posts/999/attachments", nil)
+
+	download := guest.expect(http.StatusOK, http.MethodGet, strings.TrimPrefix(uploaded.URL, apiPrefix), nil)
+	if !bytes.HasPrefix(download.Body, []byte("\x89PNG")) {
+		t.Fatalf("downloaded %d bytes that are not a PNG", len(download.Body))
+	}
+	thumb := guest.expect(http.StatusOK, http.MethodGet, strings.TrimPrefix(uploaded.ThumbnailURL, apiPrefix), nil)
+	if thumb.Header.Get("Content-Type") != "image/jpeg" {
+		t.Fatalf("thumbnail content type %q", thumb.Header.Get("Content-Type"))
+	}
+
+	downloadPath := fmt.Sprintf("/attachments/%d/download", uploaded.ID)
+	guest.expect(http.StatusForbidden, http.MethodGet, downloadPath, nil)
+	guest.expect(http.StatusForbidden, http.MethodGet, downloadPath+"?expires=1&signature=bad", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/attachments/abc/download", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/attachments/abc/thumbnail", nil)
+	guest.expect(http.StatusForbidden, http.MethodGet, fmt.Sprintf("/attachments/%d/thumbnail", textID), nil)
+
+	attachmentPath := fmt.Sprintf("/attachments/%d", uploaded.ID)
+	bob.expect(http.StatusForbidden, http.MethodDelete, attachmentPath, nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/attachments/abc", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, attachmentPath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, attachmentPath, nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, strings.TrimPrefix(uploaded.URL, apiPrefix), nil)
+}
+
+// File đính kèm của post chưa xuất bản hoặc bị ẩn chỉ người thấy được post mới xem được
+func TestAttachmentsFollowPostVisibility(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	mod := s.registerWithRole("mod", "moderator")
+	guest := s.anonymous()
+
+	draft := alice.expect(http.StatusCreated, http.MethodPost, "/posts",
+		gin.H{"title": "Draft", "content": "not yet", "status": "draft"}).idOf(t)
+	draftBase := fmt.Sprintf("/posts/%d/attachments", draft)
+	var uploaded struct {
+		URL string `json:"url"`
+	}
+	alice.upload(http.StatusCreated, draftBase, "notes.txt", []byte("draft notes")).decode(t, &uploaded)
+	downloadPath := strings.TrimPrefix(uploaded.URL, apiPrefix)
+
+	alice.expect(http.StatusOK, http.MethodGet, draftBase, nil)
+	alice.expect(http.StatusOK, http.MethodGet, downloadPath, nil)
+	bob.expect(http.StatusNotFound, http.MethodGet, draftBase, nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, draftBase, nil)
+	bob.expect(http.StatusNotFound, http.MethodGet, downloadPath, nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, downloadPath, nil)
+
+	// Link đã phát ra trước khi post bị ẩn cũng không còn dùng được
+	published := alice.createPost("Post", "content")
+	base := fmt.Sprintf("/posts/%d/attachments", published)
+	alice.upload(http.StatusCreated, base, "notes.txt", []byte("public notes")).decode(t, &uploaded)
+	downloadPath = strings.TrimPrefix(uploaded.URL, apiPrefix)
+	guest.expect(http.StatusOK, http.MethodGet, downloadPath, nil)
+
+	mod.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/posts/%d/hide", published), nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, base, nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, downloadPath, nil)
+	mod.expect(http.StatusOK, http.MethodGet, downloadPath, nil)
+}
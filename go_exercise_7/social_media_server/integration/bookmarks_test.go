@@ -0,0 +1,69 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBookmarks(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	id := bob.createPost("Post", "content")
+	bookmarkPath := fmt.Sprintf("/posts/%d/bookmark", id)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/bookmarks/collections", nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/bookmarks/collections", gin.H{"name": ""})
+	collectionID := alice.expect(http.StatusCreated, http.MethodPost, "/bookmarks/collections", gin.H{"name": "Reading"}).idOf(t)
+	alice.expect(http.StatusConflict, http.MethodPost, "/bookmarks/collections", gin.H{"name": "Reading"})
+	alice.expect(http.StatusOK, http.MethodGet, "/bookmarks/collections", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/bookmarks/collections?page=x", nil)
+
+	collectionPath := fmt.Sprintf("/bookmarks/collections/%d", collectionID)
+	otherID := alice.expect(http.StatusCreated, http.MethodPost, "/bookmarks/collections", gin.H{"name": "Later"}).idOf(t)
+	alice.expect(http.StatusConflict, http.MethodPut, collectionPath, gin.H{"name": "Later"})
+	alice.expect(http.StatusBadRequest, http.MethodPut, "/bookmarks/collections/abc", gin.H{"name": "x"})
+	bob.expect(http.StatusNotFound, http.MethodPut, collectionPath, gin.H{"name": "Mine now"})
+	alice.expect(http.StatusOK, http.MethodPut, collectionPath, gin.H{"name": "To read"})
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, bookmarkPath, nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/posts/abc/bookmark", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, "/posts/999/bookmark", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, bookmarkPath, gin.H{"collection_id": 999})
+	alice.expect(http.StatusCreated, http.MethodPost, bookmarkPath, gin.H{"collection_id": collectionID})
+	alice.expect(http.StatusOK, http.MethodPost, bookmarkPath, gin.H{"collection_id": otherID})
+
+	var post postBody
+	alice.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/posts/%d", id), nil).decode(t, &post)
+	if !post.Bookmarked || post.BookmarkCount != 1 {
+		t.Fatalf("bookmarked post: %+v", post)
+	}
+
+	var bookmarks struct {
+		Data []struct {
+			PostID       uint  `json:"post_id"`
+			CollectionID *uint `json:"collection_id"`
+		} `json:"data"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/bookmarks?collection_id=%d", otherID), nil).decode(t, &bookmarks)
+	if len(bookmarks.Data) != 1 || bookmarks.Data[0].PostID != id {
+		t.Fatalf("bookmarks in collection: %+v", bookmarks)
+	}
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/bookmarks?collection_id=abc", nil)
+	alice.expect(http.StatusNotFound, http.MethodGet, "/bookmarks?collection_id=999", nil)
+
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/bookmarks/collections/abc", nil)
+	bob.expect(http.StatusNotFound, http.MethodDelete, fmt.Sprintf("/bookmarks/collections/%d", otherID), nil)
+	alice.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/bookmarks/collections/%d", otherID), nil)
+	alice.expect(http.StatusOK, http.MethodGet, "/bookmarks", nil).decode(t, &bookmarks)
+	if len(bookmarks.Data) != 1 || bookmarks.Data[0].CollectionID != nil {
+		t.Fatalf("bookmarks after deleting their collection: %+v", bookmarks)
+	}
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodDelete, bookmarkPath, nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/posts/abc/bookmark", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, bookmarkPath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, bookmarkPath, nil)
+}
@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCommunities(t *testing.T) {
+	s := newServer(t)
+	owner, alice, bob := s.register("owner"), s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, "/communities", gin.H{"name": "Gophers", "slug": "gophers"})
+	owner.expect(http.StatusBadRequest, http.MethodPost, "/communities", gin.H{"name": "Gophers", "slug": "Not A Slug"})
+	owner.expect(http.StatusBadRequest, http.MethodPost, "/communities", gin.H{"name": "Gophers", "slug": "gophers", "join_policy": "invite"})
+	owner.expect(http.StatusCreated, http.MethodPost, "/communities", gin.H{"name": "Gophers", "slug": "gophers"})
+	owner.expect(http.StatusConflict, http.MethodPost, "/communities", gin.H{"name": "Other", "slug": "gophers"})
+
+	guest.expect(http.StatusOK, http.MethodGet, "/communities", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/communities?page_size=0", nil)
+	guest.expect(http.StatusOK, http.MethodGet, "/communities/gophers", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/communities/nowhere", nil)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPut, "/communities/gophers", gin.H{"name": "Go"})
+	alice.expect(http.StatusForbidden, http.MethodPut, "/communities/gophers", gin.H{"name": "Mine"})
+	owner.expect(http.StatusBadRequest, http.MethodPut, "/communities/gophers", gin.H{"join_policy": "invite"})
+	owner.expect(http.StatusNotFound, http.MethodPut, "/communities/nowhere", gin.H{"name": "Go"})
+	owner.expect(http.StatusOK, http.MethodPut, "/communities/gophers", gin.H{"description": "All things Go"})
+
+	// Chỉ thành viên mới đăng bài vào community
+	var community struct {
+		ID uint `json:"ID"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, "/communities/gophers", nil).decode(t, &community)
+	post := gin.H{"title": "Hi", "content": "hello gophers", "community_id": community.ID}
+	alice.expect(http.StatusForbidden, http.MethodPost, "/posts", post)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, "/communities/gophers/join", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, "/communities/nowhere/join", nil)
+	alice.expect(http.StatusOK, http.MethodPost, "/communities/gophers/join", nil)
+	alice.expect(http.StatusConflict, http.MethodPost, "/communities/gophers/join", nil)
+	alice.expect(http.StatusCreated, http.MethodPost, "/posts", post)
+
+	var posts postList
+	guest.expect(http.StatusOK, http.MethodGet, "/communities/gophers/posts", nil).decode(t, &posts)
+	if posts.Pagination.Total != 1 {
+		t.Fatalf("community posts: %+v", posts)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/communities/gophers/posts?sort=oldest", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/communities/nowhere/posts", nil)
+
+	var members struct {
+		Data []struct {
+			UserID uint   `json:"user_id"`
+			Role   string `json:"role"`
+		} `json:"data"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, "/communities/gophers/members?role=member", nil).decode(t, &members)
+	if len(members.Data) != 1 || members.Data[0].UserID != alice.ID {
+		t.Fatalf("community members: %+v", members)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/communities/gophers/members?role=king", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/communities/nowhere/members", nil)
+
+	alicePath := fmt.Sprintf("/communities/gophers/members/%d", alice.ID)
+	guest.expect(http.StatusUnauthorized, http.MethodPut, alicePath+"/role", gin.H{"role": "moderator"})
+	alice.expect(http.StatusForbidden, http.MethodPut, alicePath+"/role", gin.H{"role": "moderator"})
+	owner.expect(http.StatusBadRequest, http.MethodPut, alicePath+"/role", gin.H{"role": "owner"})
+	owner.expect(http.StatusBadRequest, http.MethodPut, "/communities/gophers/members/abc/role", gin.H{"role": "moderator"})
+	owner.expect(http.StatusNotFound, http.MethodPut, fmt.Sprintf("/communities/gophers/members/%d/role", bob.ID), gin.H{"role": "moderator"})
+	owner.expect(http.StatusOK, http.MethodPut, alicePath+"/role", gin.H{"role": "moderator"})
+
+	bob.expect(http.StatusOK, http.MethodPost, "/communities/gophers/join", nil)
+	bobPath := fmt.Sprintf("/communities/gophers/members/%d", bob.ID)
+	guest.expect(http.StatusUnauthorized, http.MethodDelete, bobPath, nil)
+	bob.expect(http.StatusForbidden, http.MethodDelete, alicePath, nil)
+	alice.expect(http.StatusForbidden, http.MethodDelete, fmt.Sprintf("/communities/gophers/members/%d", owner.ID), nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/communities/gophers/members/abc", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, bobPath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, bobPath, nil)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, "/communities/gophers/leave", nil)
+	owner.expect(http.StatusBadRequest, http.MethodPost, "/communities/gophers/leave", nil)
+	bob.expect(http.StatusNotFound, http.MethodPost, "/communities/gophers/leave", nil)
+	alice.expect(http.StatusOK, http.MethodPost, "/communities/gophers/leave", nil)
+}
+
+func TestCommunityJoinRequests(t *testing.T) {
+	s := newServer(t)
+	owner, alice, bob := s.register("owner"), s.register("alice"), s.register("bob")
+	owner.expect(http.StatusCreated, http.MethodPost, "/communities",
+		gin.H{"name": "Private", "slug": "private", "join_policy": "approval"})
+
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/communities/private/join", "{")
+	var request struct {
+		ID     uint   `json:"id"`
+		Status string `json:"status"`
+	}
+	alice.expect(http.StatusAccepted, http.MethodPost, "/communities/private/join", gin.H{"message": "let me in"}).decode(t, &request)
+	if request.Status != "pending" {
+		t.Fatalf("join request: %+v", request)
+	}
+	alice.expect(http.StatusConflict, http.MethodPost, "/communities/private/join", nil)
+	var rejected struct {
+		ID uint `json:"id"`
+	}
+	bob.expect(http.StatusAccepted, http.MethodPost, "/communities/private/join", nil).decode(t, &rejected)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/communities/private/join_requests", nil)
+	alice.expect(http.StatusForbidden, http.MethodGet, "/communities/private/join_requests", nil)
+	owner.expect(http.StatusBadRequest, http.MethodGet, "/communities/private/join_requests?status=maybe", nil)
+	owner.expect(http.StatusNotFound, http.MethodGet, "/communities/nowhere/join_requests", nil)
+	var pending struct {
+		Data []struct {
+			ID uint `json:"id"`
+		} `json:"data"`
+	}
+	owner.expect(http.StatusOK, http.MethodGet, "/communities/private/join_requests", nil).decode(t, &pending)
+	if len(pending.Data) != 2 {
+		t.Fatalf("pending join requests: %+v", pending)
+	}
+
+	approvePath := fmt.Sprintf("/communities/private/join_requests/%d/approve", request.ID)
+	rejectPath := fmt.Sprintf("/communities/private/join_requests/%d/reject", rejected.ID)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, approvePath, nil)
+	alice.expect(http.StatusForbidden, http.MethodPost, approvePath, nil)
+	owner.expect(http.StatusBadRequest, http.MethodPost, "/communities/private/join_requests/abc/approve", nil)
+	owner.expect(http.StatusNotFound, http.MethodPost, "/communities/private/join_requests/999/approve", nil)
+	owner.expect(http.StatusOK, http.MethodPost, approvePath, nil)
+	owner.expect(http.StatusConflict, http.MethodPost, approvePath, nil)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, rejectPath, nil)
+	bob.expect(http.StatusForbidden, http.MethodPost, rejectPath, nil)
+	owner.expect(http.StatusBadRequest, http.MethodPost, "/communities/private/join_requests/abc/reject", nil)
+	owner.expect(http.StatusNotFound, http.MethodPost, "/communities/private/join_requests/999/reject", nil)
+	owner.expect(http.StatusOK, http.MethodPost, rejectPath, nil)
+	owner.expect(http.StatusConflict, http.MethodPost, rejectPath, nil)
+
+	// Yêu cầu bị từ chối có thể gửi lại
+	bob.expect(http.StatusAccepted, http.MethodPost, "/communities/private/join", nil)
+	alice.expect(http.StatusConflict, http.MethodPost, "/communities/private/join", nil)
+}
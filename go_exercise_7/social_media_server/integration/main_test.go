@@ -0,0 +1,252 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"social_media_server/config"
+	"social_media_server/models"
+	"social_media_server/routes"
+	"social_media_server/store"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/logger"
+)
+
+const apiPrefix = "/api/v1"
+
+var api *contract
+
+// TestMain nạp swagger một lần cho cả bộ test. Khi chạy toàn bộ (không có -run), mọi operation
+// trong swagger phải được ít nhất một test gọi tới.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	gin.SetMode(gin.TestMode)
+	if !testing.Verbose() {
+		gin.DefaultWriter = io.Discard
+		log.SetOutput(io.Discard)
+	}
+
+	var err error
+	api, err = loadContract(swaggerFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	if code == 0 && flag.Lookup("test.run").Value.String() == "" {
+		if missing := api.missing(); len(missing) > 0 {
+			fmt.Fprintf(os.Stderr, "documented operations not exercised by any test:\n  %s\n", strings.Join(missing, "\n  "))
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+// newServer dựng server như main.go nhưng với SQLite trong bộ nhớ và thư mục upload tạm, mỗi
+// test có database riêng
+func newServer(t *testing.T) *testServer {
+	t.Helper()
+	env := map[string]string{
+		"DB_DRIVER":              store.SQLite,
+		"DB_URL":                 ":memory:",
+		"STORAGE_DRIVER":         "local",
+		"STORAGE_LOCAL_DIR":      t.TempDir(),
+		"ATTACHMENT_SIGNING_KEY": "integration-test-signing-key",
+		"ATTACHMENT_MAX_BYTES":   "1048576",
+		"FEED_STRATEGY":          "read",
+		"NOTIFICATION_BROKER":    "memory",
+		"IDEMPOTENCY_STORE":      "db",
+		"MESSAGE_ENCRYPTION_KEY": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=",
+		"CONTENT_FILTER_FILE":    "",
+		"ADMIN_USERNAMES":        "",
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	config.ConnectDB()
+	config.ConnectStorage()
+	config.SetupFeed()
+	config.SetupNotifications()
+	config.SetupModeration()
+	config.SetupContentCheck()
+	config.SetupIdempotency()
+	config.SetupMessaging()
+	config.SetupReactions()
+	if !testing.Verbose() {
+		config.DB.Logger = logger.Discard
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := config.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return &testServer{t: t, router: routes.SetupRouter()}
+}
+
+type response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+func (r *response) decode(t *testing.T, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		t.Fatalf("decode %s: %v", r.Body, err)
+	}
+}
+
+// client gửi request với API key của một người dùng (rỗng là khách)
+type client struct {
+	s   *testServer
+	key string
+}
+
+func (s *testServer) anonymous() *client {
+	return &client{s: s}
+}
+
+type user struct {
+	*client
+	ID       uint
+	Username string
+}
+
+// register tạo người dùng qua API và trả về client đã đăng nhập
+func (s *testServer) register(username string) *user {
+	s.t.Helper()
+	var created struct {
+		User   struct{ ID uint }
+		APIKey string `json:"api_key"`
+	}
+	s.anonymous().expect(http.StatusCreated, http.MethodPost, "/users", gin.H{"username": username}).decode(s.t, &created)
+	return &user{client: &client{s: s, key: created.APIKey}, ID: created.User.ID, Username: username}
+}
+
+// registerWithRole tạo người dùng rồi đặt role trực tiếp trong DB
+func (s *testServer) registerWithRole(username, role string) *user {
+	s.t.Helper()
+	u := s.register(username)
+	if err := config.DB.Model(&models.User{}).Where("id = ?", u.ID).Update("role", role).Error; err != nil {
+		s.t.Fatal(err)
+	}
+	return u
+}
+
+// do gửi request tới path (không có tiền tố /api/v1); body là string, []byte hoặc giá trị được
+// encode JSON. Mọi response đều được kiểm tra theo swagger.
+func (c *client) do(method, path string, body interface{}, header ...string) *response {
+	c.s.t.Helper()
+	var reader io.Reader
+	contentType := ""
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader, contentType = strings.NewReader(b), "application/json"
+	case []byte:
+		reader, contentType = bytes.NewReader(b), "application/json"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			c.s.t.Fatal(err)
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+	req := httptest.NewRequest(method, apiPrefix+path, reader)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	return c.send(req)
+}
+
+func (c *client) send(req *http.Request) *response {
+	c.s.t.Helper()
+	if c.key != "" && req.Header.Get("Authorization") == "" {
+		req.Header.Set("Authorization", "Bearer "+c.key)
+	}
+	w := httptest.NewRecorder()
+	c.s.router.ServeHTTP(w, req)
+
+	resp := &response{Code: w.Code, Header: w.Header(), Body: w.Body.Bytes()}
+	path := strings.TrimPrefix(req.URL.Path, apiPrefix)
+	if err := api.check(req.Method, path, resp.Code, resp.Header.Get("Content-Type"), resp.Body); err != nil {
+		c.s.t.Errorf("contract: %v\nbody: %s", err, resp.Body)
+	}
+	return resp
+}
+
+// expect giống do nhưng dừng test nếu status khác mong đợi
+func (c *client) expect(status int, method, path string, body interface{}, header ...string) *response {
+	c.s.t.Helper()
+	resp := c.do(method, path, body, header...)
+	if resp.Code != status {
+		c.s.t.Fatalf("%s %s: expected status %d, got %d: %s", method, path, status, resp.Code, resp.Body)
+	}
+	return resp
+}
+
+// upload gửi file dạng multipart/form-data trong field "file"
+func (c *client) upload(status int, path, filename string, data []byte) *response {
+	c.s.t.Helper()
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		c.s.t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, apiPrefix+path, &buf)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp := c.send(req)
+	if resp.Code != status {
+		c.s.t.Fatalf("POST %s: expected status %d, got %d: %s", path, status, resp.Code, resp.Body)
+	}
+	return resp
+}
+
+// errorMessage trả về field "error" của response lỗi
+func (r *response) errorMessage(t *testing.T) string {
+	t.Helper()
+	var body struct {
+		Error string `json:"error"`
+	}
+	r.decode(t, &body)
+	return body.Error
+}
+
+// idOf đọc field id (hoặc ID với model nhúng gorm.Model) từ response
+func (r *response) idOf(t *testing.T) uint {
+	t.Helper()
+	var body struct {
+		ID      uint `json:"ID"`
+		LowerID uint `json:"id"`
+	}
+	r.decode(t, &body)
+	if body.ID != 0 {
+		return body.ID
+	}
+	return body.LowerID
+}
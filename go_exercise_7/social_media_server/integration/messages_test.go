@@ -0,0 +1,159 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type unreadCount struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func TestConversations(t *testing.T) {
+	s := newServer(t)
+	alice, bob, carol := s.register("alice"), s.register("bob"), s.register("carol")
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/conversations", nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{}})
+	alice.expect(http.StatusNotFound, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{999}})
+
+	direct := alice.expect(http.StatusCreated, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{bob.ID}}).idOf(t)
+	if again := alice.expect(http.StatusOK, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{bob.ID}}).idOf(t); again != direct {
+		t.Fatalf("starting the same direct conversation returned %d, want %d", again, direct)
+	}
+	base := fmt.Sprintf("/conversations/%d", direct)
+
+	alice.expect(http.StatusBadRequest, http.MethodPost, base+"/messages", gin.H{"body": ""})
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/conversations/abc/messages", gin.H{"body": "hi"})
+	carol.expect(http.StatusNotFound, http.MethodPost, base+"/messages", gin.H{"body": "hi"})
+	var message struct {
+		ID   uint   `json:"id"`
+		Body string `json:"body"`
+	}
+	alice.expect(http.StatusCreated, http.MethodPost, base+"/messages", gin.H{"body": "hi bob"}).decode(t, &message)
+	if message.Body != "hi bob" {
+		t.Fatalf("sent message: %+v", message)
+	}
+
+	var unread unreadCount
+	bob.expect(http.StatusOK, http.MethodGet, "/conversations/unread_count", nil).decode(t, &unread)
+	if unread.UnreadCount != 1 {
+		t.Fatalf("bob's unread count: %+v", unread)
+	}
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/conversations/unread_count", nil)
+
+	bob.expect(http.StatusOK, http.MethodGet, "/conversations", nil)
+	bob.expect(http.StatusBadRequest, http.MethodGet, "/conversations?page=abc", nil)
+	bob.expect(http.StatusOK, http.MethodGet, base, nil)
+	bob.expect(http.StatusBadRequest, http.MethodGet, "/conversations/abc", nil)
+	carol.expect(http.StatusNotFound, http.MethodGet, base, nil)
+
+	var messages struct {
+		Data []struct {
+			Body string `json:"body"`
+		} `json:"data"`
+	}
+	bob.expect(http.StatusOK, http.MethodGet, base+"/messages", nil).decode(t, &messages)
+	if len(messages.Data) != 1 || messages.Data[0].Body != "hi bob" {
+		t.Fatalf("messages: %+v", messages)
+	}
+	bob.expect(http.StatusBadRequest, http.MethodGet, base+"/messages?page_size=500", nil)
+	carol.expect(http.StatusNotFound, http.MethodGet, base+"/messages", nil)
+
+	bob.expect(http.StatusBadRequest, http.MethodPost, base+"/read", gin.H{"message_id": "latest"})
+	bob.expect(http.StatusNotFound, http.MethodPost, base+"/read", gin.H{"message_id": 999})
+	carol.expect(http.StatusNotFound, http.MethodPost, base+"/read", nil)
+	bob.expect(http.StatusOK, http.MethodPost, base+"/read", nil)
+	bob.expect(http.StatusOK, http.MethodGet, "/conversations/unread_count", nil).decode(t, &unread)
+	if unread.UnreadCount != 0 {
+		t.Fatalf("bob's unread count after reading: %+v", unread)
+	}
+
+	messagePath := fmt.Sprintf("/messages/%d", message.ID)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPut, messagePath, gin.H{"body": "edited"})
+	bob.expect(http.StatusForbidden, http.MethodPut, messagePath, gin.H{"body": "edited"})
+	alice.expect(http.StatusBadRequest, http.MethodPut, messagePath, gin.H{"body": ""})
+	alice.expect(http.StatusBadRequest, http.MethodPut, "/messages/abc", gin.H{"body": "edited"})
+	alice.expect(http.StatusNotFound, http.MethodPut, "/messages/999", gin.H{"body": "edited"})
+	alice.expect(http.StatusOK, http.MethodPut, messagePath, gin.H{"body": "hello bob"})
+
+	bob.expect(http.StatusForbidden, http.MethodDelete, messagePath, nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/messages/abc", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, messagePath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, messagePath, nil)
+
+	alice.expect(http.StatusBadRequest, http.MethodPost, base+"/leave", nil)
+	group := alice.expect(http.StatusCreated, http.MethodPost, "/conversations",
+		gin.H{"participant_ids": []uint{bob.ID, carol.ID}, "title": "Team"}).idOf(t)
+	groupPath := fmt.Sprintf("/conversations/%d", group)
+	carol.expect(http.StatusOK, http.MethodPost, groupPath+"/leave", nil)
+	carol.expect(http.StatusNotFound, http.MethodPost, groupPath+"/leave", nil)
+	carol.expect(http.StatusBadRequest, http.MethodPost, "/conversations/abc/leave", nil)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, groupPath+"/leave", nil)
+
+	// Không nhắn (hay sửa tin đã gửi) được khi một bên đã chặn bên kia
+	aliceMessage := alice.expect(http.StatusCreated, http.MethodPost, base+"/messages", gin.H{"body": "see you"}).idOf(t)
+	bobMessage := bob.expect(http.StatusCreated, http.MethodPost, base+"/messages", gin.H{"body": "bye"}).idOf(t)
+	bob.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/users/%d/block", alice.ID), nil)
+	alice.expect(http.StatusForbidden, http.MethodPost, base+"/messages", gin.H{"body": "are you there?"})
+	alice.expect(http.StatusForbidden, http.MethodPut, fmt.Sprintf("/messages/%d", aliceMessage), gin.H{"body": "are you there?"})
+	bob.expect(http.StatusForbidden, http.MethodPut, fmt.Sprintf("/messages/%d", bobMessage), gin.H{"body": "go away"})
+	alice.expect(http.StatusForbidden, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{bob.ID}})
+}
+
+// Mọi thay đổi trong tin nhắn đều có audit log, nhưng nội dung tin nhắn không bao giờ được ghi
+func TestMessagesAreAudited(t *testing.T) {
+	s := newServer(t)
+	alice, bob, carol := s.register("alice"), s.register("bob"), s.register("carol")
+	admin := s.registerWithRole("admin", "admin")
+
+	direct := alice.expect(http.StatusCreated, http.MethodPost, "/conversations", gin.H{"participant_ids": []uint{bob.ID}}).idOf(t)
+	base := fmt.Sprintf("/conversations/%d", direct)
+	id := alice.expect(http.StatusCreated, http.MethodPost, base+"/messages", gin.H{"body": "secret plans"}).idOf(t)
+	messagePath := fmt.Sprintf("/messages/%d", id)
+	alice.expect(http.StatusOK, http.MethodPut, messagePath, gin.H{"body": "edited secret"})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/read", nil)
+	bob.expect(http.StatusOK, http.MethodPost, base+"/read", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, messagePath, nil)
+
+	group := alice.expect(http.StatusCreated, http.MethodPost, "/conversations",
+		gin.H{"participant_ids": []uint{bob.ID, carol.ID}, "title": "Team"}).idOf(t)
+	carol.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/conversations/%d/leave", group), nil)
+
+	var logs struct {
+		Data []struct {
+			ActorID    *uint  `json:"actor_id"`
+			Action     string `json:"action"`
+			ResourceID uint   `json:"resource_id"`
+		} `json:"data"`
+	}
+	tests := []struct {
+		resource   string
+		actions    []string
+		actors     []uint
+		resourceID []uint
+	}{
+		{"message", []string{"delete", "update", "create"}, []uint{alice.ID, alice.ID, alice.ID}, []uint{id, id, id}},
+		// Đánh dấu đã đọc lần hai không đổi gì nên không được ghi
+		{"conversation_participant", []string{"delete", "update"}, []uint{carol.ID, bob.ID}, []uint{group, direct}},
+	}
+	for _, tt := range tests {
+		res := admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs?resource_type="+tt.resource, nil)
+		if strings.Contains(string(res.Body), "secret") {
+			t.Fatalf("%s audit log contains the message body: %s", tt.resource, res.Body)
+		}
+		res.decode(t, &logs)
+		if len(logs.Data) != len(tt.actions) {
+			t.Fatalf("%s audit log: %+v", tt.resource, logs.Data)
+		}
+		for i, entry := range logs.Data {
+			if entry.Action != tt.actions[i] || entry.ActorID == nil || *entry.ActorID != tt.actors[i] || entry.ResourceID != tt.resourceID[i] {
+				t.Fatalf("%s audit entry %d: %+v", tt.resource, i, entry)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReports(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	mod := s.registerWithRole("mod", "moderator")
+	id := alice.createPost("Post", "content")
+	report := gin.H{"target_type": "post", "target_id": id, "reason": "spam"}
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, "/reports", report)
+	bob.expect(http.StatusBadRequest, http.MethodPost, "/reports", gin.H{"target_type": "user", "target_id": id, "reason": "spam"})
+	bob.expect(http.StatusNotFound, http.MethodPost, "/reports", gin.H{"target_type": "post", "target_id": 999, "reason": "spam"})
+	reportID := bob.expect(http.StatusCreated, http.MethodPost, "/reports", report).idOf(t)
+	bob.expect(http.StatusConflict, http.MethodPost, "/reports", report)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/moderation/reports", nil)
+	bob.expect(http.StatusForbidden, http.MethodGet, "/moderation/reports", nil)
+	mod.expect(http.StatusBadRequest, http.MethodGet, "/moderation/reports?status=pending", nil)
+	var reports struct {
+		Data []struct {
+			ID       uint   `json:"id"`
+			TargetID uint   `json:"target_id"`
+			Status   string `json:"status"`
+		} `json:"data"`
+	}
+	mod.expect(http.StatusOK, http.MethodGet, "/moderation/reports?target_type=post", nil).decode(t, &reports)
+	if len(reports.Data) != 1 || reports.Data[0].TargetID != id || reports.Data[0].Status != "open" {
+		t.Fatalf("open reports: %+v", reports)
+	}
+
+	reportPath := fmt.Sprintf("/moderation/reports/%d", reportID)
+	bob.expect(http.StatusForbidden, http.MethodGet, reportPath, nil)
+	mod.expect(http.StatusBadRequest, http.MethodGet, "/moderation/reports/abc", nil)
+	mod.expect(http.StatusNotFound, http.MethodGet, "/moderation/reports/999", nil)
+	mod.expect(http.StatusOK, http.MethodGet, reportPath, nil)
+
+	bob.expect(http.StatusForbidden, http.MethodPost, reportPath+"/resolve", gin.H{"action": "hide"})
+	mod.expect(http.StatusBadRequest, http.MethodPost, reportPath+"/resolve", gin.H{"action": "ban"})
+	mod.expect(http.StatusBadRequest, http.MethodPost, "/moderation/reports/abc/resolve", gin.H{"action": "hide"})
+	mod.expect(http.StatusNotFound, http.MethodPost, "/moderation/reports/999/resolve", gin.H{"action": "hide"})
+	mod.expect(http.StatusOK, http.MethodPost, reportPath+"/resolve", gin.H{"action": "hide", "note": "spam"})
+	mod.expect(http.StatusConflict, http.MethodPost, reportPath+"/resolve", gin.H{"action": "dismiss"})
+
+	// Post bị ẩn không còn hiện với người dùng thường
+	bob.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/posts/%d", id), nil)
+}
+
+func TestModerationHide(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	mod := s.registerWithRole("mod", "moderator")
+	postID := alice.createPost("Post", "content")
+	commentID := alice.createComment(postID, "comment")
+	postPath := fmt.Sprintf("/moderation/posts/%d", postID)
+	commentPath := fmt.Sprintf("/moderation/comments/%d", commentID)
+
+	for _, action := range []string{"/hide", "/unhide"} {
+		s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, postPath+action, nil)
+		bob.expect(http.StatusForbidden, http.MethodPost, postPath+action, nil)
+		mod.expect(http.StatusBadRequest, http.MethodPost, "/moderation/posts/abc"+action, nil)
+		mod.expect(http.StatusNotFound, http.MethodPost, "/moderation/posts/999"+action, nil)
+		bob.expect(http.StatusForbidden, http.MethodPost, commentPath+action, nil)
+		mod.expect(http.StatusBadRequest, http.MethodPost, "/moderation/comments/abc"+action, nil)
+		mod.expect(http.StatusNotFound, http.MethodPost, "/moderation/comments/999"+action, nil)
+	}
+
+	mod.expect(http.StatusBadRequest, http.MethodPost, postPath+"/hide", "{")
+	mod.expect(http.StatusOK, http.MethodPost, postPath+"/hide", gin.H{"note": "off topic"})
+	bob.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/posts/%d", postID), nil)
+	mod.expect(http.StatusOK, http.MethodPost, postPath+"/unhide", nil)
+	bob.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/posts/%d", postID), nil)
+
+	mod.expect(http.StatusOK, http.MethodPost, commentPath+"/hide", nil)
+	mod.expect(http.StatusOK, http.MethodPost, commentPath+"/unhide", nil)
+
+	bob.expect(http.StatusForbidden, http.MethodGet, "/moderation/actions", nil)
+	mod.expect(http.StatusBadRequest, http.MethodGet, "/moderation/actions?moderator_id=abc", nil)
+	var actions struct {
+		Data []struct {
+			Action string `json:"action"`
+			Note   string `json:"note"`
+		} `json:"data"`
+	}
+	mod.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/moderation/actions?target_type=post&target_id=%d", postID), nil).decode(t, &actions)
+	if len(actions.Data) != 2 || actions.Data[1].Note != "off topic" {
+		t.Fatalf("moderation actions on the post: %+v", actions)
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	mod := s.registerWithRole("mod", "moderator")
+	admin := s.registerWithRole("admin", "admin")
+	rolePath := fmt.Sprintf("/admin/users/%d/role", alice.ID)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPut, rolePath, gin.H{"role": "moderator"})
+	mod.expect(http.StatusForbidden, http.MethodPut, rolePath, gin.H{"role": "moderator"})
+	admin.expect(http.StatusBadRequest, http.MethodPut, rolePath, gin.H{"role": "owner"})
+	admin.expect(http.StatusBadRequest, http.MethodPut, "/admin/users/abc/role", gin.H{"role": "moderator"})
+	admin.expect(http.StatusNotFound, http.MethodPut, "/admin/users/999/role", gin.H{"role": "moderator"})
+	admin.expect(http.StatusOK, http.MethodPut, rolePath, gin.H{"role": "moderator"})
+	alice.expect(http.StatusOK, http.MethodGet, "/moderation/reports", nil)
+
+	mod.expect(http.StatusForbidden, http.MethodGet, "/admin/audit_logs", nil)
+	admin.expect(http.StatusBadRequest, http.MethodGet, "/admin/audit_logs?since=yesterday", nil)
+	var logs struct {
+		Data []struct {
+			ActorID      *uint  `json:"actor_id"`
+			Action       string `json:"action"`
+			ResourceType string `json:"resource_type"`
+			ResourceID   uint   `json:"resource_id"`
+		} `json:"data"`
+	}
+	filter := fmt.Sprintf("resource_type=user&resource_id=%d", alice.ID)
+	admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs?"+filter, nil).decode(t, &logs)
+	if len(logs.Data) == 0 || logs.Data[0].Action != "update" || logs.Data[0].ActorID == nil || *logs.Data[0].ActorID != admin.ID {
+		t.Fatalf("audit log for the role change: %+v", logs)
+	}
+
+	mod.expect(http.StatusForbidden, http.MethodGet, "/admin/audit_logs/export", nil)
+	admin.expect(http.StatusBadRequest, http.MethodGet, "/admin/audit_logs/export?format=xml", nil)
+	export := admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs/export?"+filter, nil)
+	lines := 0
+	for sc := bufio.NewScanner(bytes.NewReader(export.Body)); sc.Scan(); lines++ {
+	}
+	if lines != len(logs.Data) {
+		t.Fatalf("exported %d audit entries, want %d", lines, len(logs.Data))
+	}
+	csv := admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs/export?format=csv", nil)
+	if !bytes.HasPrefix(csv.Body, []byte("id,")) {
+		t.Fatalf("CSV export starts with %q", bytes.SplitN(csv.Body, []byte("\n"), 2)[0])
+	}
+}
+
+func TestModerationDeleteCleansUp(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	mod := s.registerWithRole("mod", "moderator")
+	id := alice.createPost("Post", "content")
+	collectionID := bob.expect(http.StatusCreated, http.MethodPost, "/bookmarks/collections", gin.H{"name": "Saved"}).idOf(t)
+	bob.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/posts/%d/bookmark", id), gin.H{"collection_id": collectionID})
+	bob.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/posts/%d/vote", id), gin.H{"value": 1})
+
+	reportID := bob.expect(http.StatusCreated, http.MethodPost, "/reports", gin.H{"target_type": "post", "target_id": id, "reason": "spam"}).idOf(t)
+	mod.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/reports/%d/resolve", reportID), gin.H{"action": "delete"})
+	bob.expect(http.StatusNotFound, http.MethodGet, fmt.Sprintf("/posts/%d", id), nil)
+
+	// Bookmark của post bị xoá không còn được tính vào collection
+	var collections struct {
+		Data []struct {
+			ID            uint  `json:"id"`
+			BookmarkCount int64 `json:"bookmark_count"`
+		} `json:"data"`
+	}
+	bob.expect(http.StatusOK, http.MethodGet, "/bookmarks/collections", nil).decode(t, &collections)
+	if len(collections.Data) != 1 || collections.Data[0].BookmarkCount != 0 {
+		t.Fatalf("collections after the post was deleted: %+v", collections)
+	}
+}
@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNotifications(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	id := alice.createPost("Post", "content")
+	bob.createComment(id, "nice post @alice")
+
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/notifications", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/notifications?unread=maybe", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/notifications?page_size=0", nil)
+	var list struct {
+		Data []struct {
+			ID      uint   `json:"id"`
+			Type    string `json:"type"`
+			ActorID *uint  `json:"actor_id"`
+		} `json:"data"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/notifications?unread=true", nil).decode(t, &list)
+	if len(list.Data) == 0 || list.Data[0].ActorID == nil || *list.Data[0].ActorID != bob.ID {
+		t.Fatalf("alice's notifications: %+v", list)
+	}
+
+	var unread unreadCount
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/notifications/unread_count", nil)
+	alice.expect(http.StatusOK, http.MethodGet, "/notifications/unread_count", nil).decode(t, &unread)
+	if unread.UnreadCount != int64(len(list.Data)) {
+		t.Fatalf("unread count %d, want %d", unread.UnreadCount, len(list.Data))
+	}
+
+	readPath := fmt.Sprintf("/notifications/%d/read", list.Data[0].ID)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/notifications/abc/read", nil)
+	bob.expect(http.StatusNotFound, http.MethodPost, readPath, nil)
+	alice.expect(http.StatusOK, http.MethodPost, readPath, nil)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, "/notifications/read_all", nil)
+	alice.expect(http.StatusOK, http.MethodPost, "/notifications/read_all", nil)
+	alice.expect(http.StatusOK, http.MethodGet, "/notifications/unread_count", nil).decode(t, &unread)
+	if unread.UnreadCount != 0 {
+		t.Fatalf("unread count after reading all: %+v", unread)
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/notifications/preferences", nil)
+	var prefs struct {
+		Comments bool `json:"comments"`
+		Follows  bool `json:"follows"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/notifications/preferences", nil).decode(t, &prefs)
+	if !prefs.Comments || !prefs.Follows {
+		t.Fatalf("default preferences: %+v", prefs)
+	}
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPut, "/notifications/preferences", gin.H{"follows": false})
+	alice.expect(http.StatusBadRequest, http.MethodPut, "/notifications/preferences", "{")
+	alice.expect(http.StatusOK, http.MethodPut, "/notifications/preferences",
+		gin.H{"comments": true, "replies": true, "mentions": true, "follows": false})
+
+	// Tắt thông báo follow thì follow mới không tạo notification
+	bob.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/users/%d/follow", alice.ID), nil)
+	var unread unreadCount
+	alice.expect(http.StatusOK, http.MethodGet, "/notifications/unread_count", nil).decode(t, &unread)
+	if unread.UnreadCount != 0 {
+		t.Fatalf("follow notification despite preferences: %+v", unread)
+	}
+}
+
+func TestNotificationStream(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	// đăng ký Close trước để nó chạy sau khi các stream đã bị huỷ
+	srv := httptest.NewServer(s.router)
+	t.Cleanup(srv.Close)
+
+	stream := func(query string) *http.Response {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+apiPrefix+"/notifications/stream"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		var body []byte
+		if resp.StatusCode != http.StatusOK {
+			if body, err = io.ReadAll(resp.Body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := api.check(http.MethodGet, "/notifications/stream", resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+			t.Errorf("contract: %v", err)
+		}
+		return resp
+	}
+
+	if resp := stream(""); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("stream without key: status %d", resp.StatusCode)
+	}
+
+	resp := stream("?api_key=" + alice.key)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("stream: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(line) != "event:unread_count" {
+		t.Fatalf("first stream line %q", line)
+	}
+}
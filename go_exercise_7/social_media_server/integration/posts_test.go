@@ -0,0 +1,233 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type postBody struct {
+	ID            uint   `json:"ID"`
+	Title         string `json:"title"`
+	Content       string `json:"content"`
+	ContentHTML   string `json:"content_html"`
+	Status        string `json:"status"`
+	UserID        *uint  `json:"user_id"`
+	Edited        bool   `json:"edited"`
+	CommentCount  int64  `json:"comment_count"`
+	BookmarkCount int64  `json:"bookmark_count"`
+	Bookmarked    bool   `json:"bookmarked"`
+	Comments      []struct {
+		ID uint `json:"ID"`
+	} `json:"comments"`
+	Reactions struct {
+		Score  int64          `json:"score"`
+		MyVote int            `json:"my_vote"`
+		Emoji  map[string]int `json:"emoji"`
+	} `json:"reactions"`
+}
+
+type postList struct {
+	Data       []postBody `json:"data"`
+	Pagination struct {
+		Total int64 `json:"total"`
+	} `json:"pagination"`
+}
+
+// createPost tạo post đã xuất bản và trả về ID của nó
+func (c *client) createPost(title, content string) uint {
+	c.s.t.Helper()
+	return c.expect(http.StatusCreated, http.MethodPost, "/posts", gin.H{"title": title, "content": content}).idOf(c.s.t)
+}
+
+func (c *client) createComment(postID uint, content string) uint {
+	c.s.t.Helper()
+	return c.expect(http.StatusCreated, http.MethodPost, "/comments", gin.H{"post_id": postID, "content": content}).idOf(c.s.t)
+}
+
+func TestPosts(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/posts", "{")
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/posts", gin.H{"title": "t", "content": "c", "content_format": "rtf"})
+
+	var created postBody
+	alice.expect(http.StatusCreated, http.MethodPost, "/posts",
+		gin.H{"title": "Hello", "content": "**bold** #golang", "content_format": "markdown"}).decode(t, &created)
+	if created.UserID == nil || *created.UserID != alice.ID || created.ContentHTML == "" {
+		t.Fatalf("created post: %+v", created)
+	}
+	postPath := fmt.Sprintf("/posts/%d", created.ID)
+
+	var list []postBody
+	guest.expect(http.StatusOK, http.MethodGet, "/posts", nil).decode(t, &list)
+	if len(list) != 1 || list[0].ID != created.ID {
+		t.Fatalf("GET /posts: %+v", list)
+	}
+	for _, query := range []string{"status=deleted", "sort=random", "window=year", "include=votes", "comments_limit=0"} {
+		guest.expect(http.StatusBadRequest, http.MethodGet, "/posts?"+query, nil)
+	}
+	for _, query := range []string{"sort=hot", "sort=top&window=all", "sort=discussed", "sort=active", "include=comments&comments_limit=5"} {
+		guest.expect(http.StatusOK, http.MethodGet, "/posts?"+query, nil)
+	}
+
+	guest.expect(http.StatusOK, http.MethodGet, postPath, nil)
+	guest.expect(http.StatusOK, http.MethodGet, postPath+"?include=attachments", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, postPath+"?comments_limit=abc", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/posts/abc", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/posts/999", nil)
+
+	bob.expect(http.StatusForbidden, http.MethodPut, postPath, gin.H{"title": "Hijacked", "content": "x"})
+	alice.expect(http.StatusBadRequest, http.MethodPut, postPath, "{")
+	alice.expect(http.StatusBadRequest, http.MethodPut, "/posts/abc", gin.H{"title": "t", "content": "c"})
+	alice.expect(http.StatusNotFound, http.MethodPut, "/posts/999", gin.H{"title": "t", "content": "c"})
+	var updated postBody
+	alice.expect(http.StatusOK, http.MethodPut, postPath, gin.H{"title": "Hello again", "content": "edited"}).decode(t, &updated)
+	if updated.Title != "Hello again" || !updated.Edited {
+		t.Fatalf("updated post: %+v", updated)
+	}
+
+	bob.expect(http.StatusForbidden, http.MethodDelete, postPath, nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/posts/abc", nil)
+	alice.expect(http.StatusOK, http.MethodDelete, postPath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, postPath, nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, postPath, nil)
+}
+
+func TestPostDraftsAreOnlyVisibleToTheirOwner(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+
+	id := alice.expect(http.StatusCreated, http.MethodPost, "/posts",
+		gin.H{"title": "Draft", "content": "not yet", "status": "draft"}).idOf(t)
+	postPath := fmt.Sprintf("/posts/%d", id)
+
+	alice.expect(http.StatusOK, http.MethodGet, postPath, nil)
+	bob.expect(http.StatusNotFound, http.MethodGet, postPath, nil)
+	s.anonymous().expect(http.StatusNotFound, http.MethodGet, postPath, nil)
+
+	var list []postBody
+	alice.expect(http.StatusOK, http.MethodGet, "/posts?status=draft", nil).decode(t, &list)
+	if len(list) != 1 {
+		t.Fatalf("alice's drafts: %+v", list)
+	}
+	bob.expect(http.StatusOK, http.MethodGet, "/posts?status=draft", nil).decode(t, &list)
+	if len(list) != 0 {
+		t.Fatalf("bob sees alice's drafts: %+v", list)
+	}
+
+	// Với người khác, post chưa xuất bản như không tồn tại
+	bob.expect(http.StatusNotFound, http.MethodPost, "/comments", gin.H{"post_id": id, "content": "first"})
+}
+
+func TestPostIdempotency(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	body := gin.H{"title": "Once", "content": "only once"}
+
+	first := alice.expect(http.StatusCreated, http.MethodPost, "/posts", body, "Idempotency-Key", "post-1")
+	replay := alice.expect(http.StatusCreated, http.MethodPost, "/posts", body, "Idempotency-Key", "post-1")
+	if replay.idOf(t) != first.idOf(t) || replay.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replayed response: %s %v", replay.Body, replay.Header)
+	}
+	alice.expect(http.StatusUnprocessableEntity, http.MethodPost, "/posts",
+		gin.H{"title": "Other", "content": "different"}, "Idempotency-Key", "post-1")
+
+	var list []postBody
+	alice.expect(http.StatusOK, http.MethodGet, "/posts", nil).decode(t, &list)
+	if len(list) != 1 {
+		t.Fatalf("expected one post after a replay, got %d", len(list))
+	}
+}
+
+// Comment và file đính kèm gửi kèm post bị bỏ qua, không được lưu dưới tên người khác
+func TestPostIgnoresNestedAssociations(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	nested := gin.H{
+		"comments":    []gin.H{{"content": "impersonated", "user_id": alice.ID}},
+		"attachments": []gin.H{{"file_name": "secret.png", "content_type": "image/png", "size": 1}},
+	}
+
+	body := gin.H{"title": "Hello", "content": "plain"}
+	for k, v := range nested {
+		body[k] = v
+	}
+	id := bob.expect(http.StatusCreated, http.MethodPost, "/posts", body).idOf(t)
+	postPath := fmt.Sprintf("/posts/%d", id)
+	body["title"] = "Hello again"
+	bob.expect(http.StatusOK, http.MethodPut, postPath, body)
+
+	var post struct {
+		postBody
+		Attachments []struct{} `json:"attachments"`
+	}
+	bob.expect(http.StatusOK, http.MethodGet, postPath, nil).decode(t, &post)
+	if len(post.Comments) != 0 || len(post.Attachments) != 0 || post.CommentCount != 0 {
+		t.Fatalf("nested associations were saved: %+v", post)
+	}
+}
+
+func TestComments(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	postID := alice.createPost("Post", "content")
+	otherPostID := alice.createPost("Other", "content")
+
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/comments", "[]")
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/comments", gin.H{"post_id": postID, "content": ""})
+	guest.expect(http.StatusNotFound, http.MethodPost, "/comments", gin.H{"post_id": 999, "content": "hi"})
+
+	commentID := bob.createComment(postID, "First! @alice")
+	otherComment := bob.createComment(otherPostID, "elsewhere")
+	bob.expect(http.StatusBadRequest, http.MethodPost, "/comments",
+		gin.H{"post_id": postID, "parent_id": otherComment, "content": "wrong thread"})
+	replyID := alice.expect(http.StatusCreated, http.MethodPost, "/comments",
+		gin.H{"post_id": postID, "parent_id": commentID, "content": "reply"}).idOf(t)
+
+	var post postBody
+	guest.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/posts/%d", postID), nil).decode(t, &post)
+	if post.CommentCount != 2 || len(post.Comments) != 2 {
+		t.Fatalf("post after two comments: %+v", post)
+	}
+
+	commentsPath := fmt.Sprintf("/posts/%d/comments", postID)
+	var comments struct {
+		Data []struct {
+			ID uint `json:"ID"`
+		} `json:"data"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, commentsPath+"?order=desc", nil).decode(t, &comments)
+	if len(comments.Data) != 2 || comments.Data[0].ID != replyID {
+		t.Fatalf("comments newest first: %+v", comments)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, commentsPath+"?order=sideways", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/posts/abc/comments", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/posts/999/comments", nil)
+
+	commentPath := fmt.Sprintf("/comments/%d", commentID)
+	guest.expect(http.StatusOK, http.MethodGet, commentPath, nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/comments/abc", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/comments/999", nil)
+
+	alice.expect(http.StatusForbidden, http.MethodPut, commentPath, gin.H{"content": "not mine"})
+	bob.expect(http.StatusBadRequest, http.MethodPut, commentPath, gin.H{"content": ""})
+	bob.expect(http.StatusBadRequest, http.MethodPut, "/comments/abc", gin.H{"content": "x"})
+	bob.expect(http.StatusNotFound, http.MethodPut, "/comments/999", gin.H{"content": "x"})
+	bob.expect(http.StatusOK, http.MethodPut, commentPath, gin.H{"content": "First, edited"})
+
+	alice.expect(http.StatusForbidden, http.MethodDelete, commentPath, nil)
+	bob.expect(http.StatusBadRequest, http.MethodDelete, "/comments/abc", nil)
+	bob.expect(http.StatusOK, http.MethodDelete, commentPath, nil)
+	bob.expect(http.StatusNotFound, http.MethodDelete, commentPath, nil)
+
+	guest.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/posts/%d", postID), nil).decode(t, &post)
+	if post.CommentCount != 1 {
+		t.Fatalf("comment_count after delete: %d", post.CommentCount)
+	}
+}
@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const thumbsUp = "👍"
+
+type reactionSummary struct {
+	Upvotes     int64            `json:"upvotes"`
+	Downvotes   int64            `json:"downvotes"`
+	Score       int64            `json:"score"`
+	Emoji       map[string]int64 `json:"emoji"`
+	MyVote      int              `json:"my_vote"`
+	MyReactions []string         `json:"my_reactions"`
+}
+
+func TestPostReactions(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	id := alice.createPost("Post", "content")
+	base := fmt.Sprintf("/posts/%d", id)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	bob.expect(http.StatusBadRequest, http.MethodPut, base+"/vote", gin.H{"value": 2})
+	bob.expect(http.StatusBadRequest, http.MethodPut, "/posts/abc/vote", gin.H{"value": 1})
+	bob.expect(http.StatusNotFound, http.MethodPut, "/posts/999/vote", gin.H{"value": 1})
+	var summary reactionSummary
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 1}).decode(t, &summary)
+	if summary.Score != 1 || summary.MyVote != 1 {
+		t.Fatalf("after upvote: %+v", summary)
+	}
+	alice.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": -1}).decode(t, &summary)
+	if summary.Score != 0 || summary.Upvotes != 1 || summary.Downvotes != 1 {
+		t.Fatalf("after downvote: %+v", summary)
+	}
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusBadRequest, http.MethodPost, base+"/reactions", gin.H{"emoji": "🦄"})
+	bob.expect(http.StatusBadRequest, http.MethodPost, "/posts/abc/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusNotFound, http.MethodPost, "/posts/999/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp}).decode(t, &summary)
+	if summary.Emoji[thumbsUp] != 1 || len(summary.MyReactions) != 1 {
+		t.Fatalf("reacting twice counts once: %+v", summary)
+	}
+
+	var who struct {
+		Data []struct {
+			UserID uint   `json:"user_id"`
+			Emoji  string `json:"emoji"`
+		} `json:"data"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base+"/reactions?emoji="+url.QueryEscape(thumbsUp), nil).decode(t, &who)
+	if len(who.Data) != 1 || who.Data[0].UserID != bob.ID {
+		t.Fatalf("who reacted: %+v", who)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"/reactions?page_size=1000", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/posts/abc/reactions", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/posts/999/reactions", nil)
+
+	emojiPath := base + "/reactions/" + url.PathEscape(thumbsUp)
+	guest.expect(http.StatusUnauthorized, http.MethodDelete, emojiPath, nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, emojiPath, nil)
+	bob.expect(http.StatusBadRequest, http.MethodDelete, "/posts/abc/reactions/"+url.PathEscape(thumbsUp), nil)
+	var removed reactionSummary
+	bob.expect(http.StatusOK, http.MethodDelete, emojiPath, nil).decode(t, &removed)
+	if removed.Emoji[thumbsUp] != 0 {
+		t.Fatalf("after removing reaction: %+v", removed)
+	}
+}
+
+func TestReactionsAreAudited(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	admin := s.registerWithRole("admin", "admin")
+	id := alice.createPost("Post", "content")
+	base := fmt.Sprintf("/posts/%d", id)
+
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": -1})
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 0})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusOK, http.MethodDelete, base+"/reactions/"+url.PathEscape(thumbsUp), nil)
+
+	var logs struct {
+		Data []struct {
+			ActorID    *uint  `json:"actor_id"`
+			Action     string `json:"action"`
+			ResourceID uint   `json:"resource_id"`
+			Before     *struct {
+				Value int    `json:"value"`
+				Emoji string `json:"emoji"`
+			} `json:"before"`
+			After *struct {
+				Value int    `json:"value"`
+				Emoji string `json:"emoji"`
+			} `json:"after"`
+		} `json:"data"`
+	}
+	// Vote hay reaction không đổi gì thì không được ghi lại
+	for resource, want := range map[string][]string{"vote": {"delete", "update", "create"}, "reaction": {"delete", "create"}} {
+		admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs?resource_type="+resource, nil).decode(t, &logs)
+		if len(logs.Data) != len(want) {
+			t.Fatalf("%s audit log: %+v", resource, logs.Data)
+		}
+		for i, entry := range logs.Data {
+			if entry.Action != want[i] || entry.ResourceID != id || entry.ActorID == nil || *entry.ActorID != bob.ID {
+				t.Fatalf("%s audit entry %d: %+v, want action %s", resource, i, entry, want[i])
+			}
+		}
+	}
+	admin.expect(http.StatusOK, http.MethodGet, "/admin/audit_logs?resource_type=vote&action=update", nil).decode(t, &logs)
+	if len(logs.Data) != 1 || logs.Data[0].Before == nil || logs.Data[0].Before.Value != 1 ||
+		logs.Data[0].After == nil || logs.Data[0].After.Value != -1 {
+		t.Fatalf("vote update snapshots: %+v", logs.Data)
+	}
+}
+
+func TestCommentReactions(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	id := alice.createComment(alice.createPost("Post", "content"), "comment")
+	base := fmt.Sprintf("/comments/%d", id)
+
+	guest.expect(http.StatusUnauthorized, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	bob.expect(http.StatusBadRequest, http.MethodPut, base+"/vote", "{")
+	bob.expect(http.StatusNotFound, http.MethodPut, "/comments/999/vote", gin.H{"value": 1})
+	var summary reactionSummary
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	bob.expect(http.StatusOK, http.MethodPut, base+"/vote", gin.H{"value": 0}).decode(t, &summary)
+	if summary.Score != 0 || summary.MyVote != 0 {
+		t.Fatalf("after clearing vote: %+v", summary)
+	}
+
+	guest.expect(http.StatusUnauthorized, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusBadRequest, http.MethodPost, base+"/reactions", gin.H{})
+	bob.expect(http.StatusNotFound, http.MethodPost, "/comments/999/reactions", gin.H{"emoji": thumbsUp})
+	bob.expect(http.StatusOK, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+
+	guest.expect(http.StatusOK, http.MethodGet, base+"/reactions", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/comments/abc/reactions", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/comments/999/reactions", nil)
+
+	emojiPath := base + "/reactions/" + url.PathEscape(thumbsUp)
+	guest.expect(http.StatusUnauthorized, http.MethodDelete, emojiPath, nil)
+	bob.expect(http.StatusBadRequest, http.MethodDelete, "/comments/abc/reactions/"+url.PathEscape(thumbsUp), nil)
+	bob.expect(http.StatusOK, http.MethodDelete, emojiPath, nil)
+	bob.expect(http.StatusNotFound, http.MethodDelete, emojiPath, nil)
+}
+
+func TestTags(t *testing.T) {
+	s := newServer(t)
+	alice := s.register("alice")
+	guest := s.anonymous()
+	alice.createPost("Go", "Learning #golang today")
+	alice.createPost("More Go", "#GoLang and #gin")
+
+	var posts postList
+	guest.expect(http.StatusOK, http.MethodGet, "/tags/GOLANG/posts", nil).decode(t, &posts)
+	if posts.Pagination.Total != 2 {
+		t.Fatalf("posts tagged golang: %+v", posts)
+	}
+	guest.expect(http.StatusOK, http.MethodGet, "/tags/gin/posts?sort=top&window=week", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/tags/"+url.PathEscape("not a tag")+"/posts", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/tags/golang/posts?page=abc", nil)
+
+	var trending struct {
+		Data []struct {
+			Tag       string `json:"tag"`
+			PostCount int64  `json:"post_count"`
+		} `json:"data"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, "/tags/trending?window=168h", nil).decode(t, &trending)
+	if len(trending.Data) != 2 || trending.Data[0].Tag != "golang" || trending.Data[0].PostCount != 2 {
+		t.Fatalf("trending tags: %+v", trending)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/tags/trending?window=forever", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/tags/trending?limit=0", nil)
+}
@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPostRevisions(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	id := alice.createPost("v1", "first")
+	base := fmt.Sprintf("/posts/%d/revisions", id)
+
+	alice.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/posts/%d", id), gin.H{"title": "v2", "content": "second"})
+
+	var history struct {
+		Data []struct {
+			Version int `json:"version"`
+		} `json:"data"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base, nil).decode(t, &history)
+	if len(history.Data) != 2 || history.Data[0].Version != 2 {
+		t.Fatalf("post history: %+v", history)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"?page=-1", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/posts/abc/revisions", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/posts/999/revisions", nil)
+
+	var revision struct {
+		Content string `json:"content"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base+"/1", nil).decode(t, &revision)
+	if revision.Content != "first" {
+		t.Fatalf("version 1: %+v", revision)
+	}
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"/abc", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, base+"/9", nil)
+
+	var diff struct {
+		Diff string `json:"diff"`
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base+"/diff", nil).decode(t, &diff)
+	if !strings.Contains(diff.Diff, "-first") || !strings.Contains(diff.Diff, "+second") {
+		t.Fatalf("diff: %q", diff.Diff)
+	}
+	guest.expect(http.StatusOK, http.MethodGet, base+"/diff?from=2&to=1", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"/diff?from=x", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, base+"/diff?from=1&to=9", nil)
+
+	bob.expect(http.StatusForbidden, http.MethodPost, base+"/1/revert", nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, base+"/abc/revert", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, base+"/9/revert", nil)
+	var post postBody
+	alice.expect(http.StatusOK, http.MethodPost, base+"/1/revert", nil).decode(t, &post)
+	if post.Title != "v1" || post.Content != "first" {
+		t.Fatalf("reverted post: %+v", post)
+	}
+}
+
+func TestCommentRevisions(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	guest := s.anonymous()
+	commentID := alice.createComment(alice.createPost("Post", "content"), "first")
+	base := fmt.Sprintf("/comments/%d/revisions", commentID)
+
+	alice.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/comments/%d", commentID), gin.H{"content": "second"})
+
+	guest.expect(http.StatusOK, http.MethodGet, base, nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/comments/abc/revisions", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/comments/999/revisions", nil)
+
+	guest.expect(http.StatusOK, http.MethodGet, base+"/1", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"/0", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, base+"/9", nil)
+
+	guest.expect(http.StatusOK, http.MethodGet, base+"/diff", nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, base+"/diff?to=abc", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/comments/999/revisions/diff", nil)
+
+	bob.expect(http.StatusForbidden, http.MethodPost, base+"/1/revert", nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/comments/abc/revisions/1/revert", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, base+"/9/revert", nil)
+	var comment struct {
+		Content string `json:"content"`
+	}
+	alice.expect(http.StatusOK, http.MethodPost, base+"/1/revert", nil).decode(t, &comment)
+	if comment.Content != "first" {
+		t.Fatalf("reverted comment: %+v", comment)
+	}
+}
+
+// Comment của post bị ẩn cũng bị ẩn theo: không xem được revision, comment hay reaction của nó
+func TestCommentsOfHiddenPosts(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	mod := s.registerWithRole("mod", "moderator")
+	guest := s.anonymous()
+	postID := alice.createPost("Post", "content")
+	commentID := bob.createComment(postID, "first")
+	base := fmt.Sprintf("/comments/%d", commentID)
+
+	mod.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/moderation/posts/%d/hide", postID), nil)
+
+	for _, path := range []string{base, base + "/revisions", base + "/revisions/1", base + "/revisions/diff", base + "/reactions"} {
+		guest.expect(http.StatusNotFound, http.MethodGet, path, nil)
+		bob.expect(http.StatusNotFound, http.MethodGet, path, nil)
+		mod.expect(http.StatusOK, http.MethodGet, path, nil)
+	}
+	alice.expect(http.StatusNotFound, http.MethodPut, base+"/vote", gin.H{"value": 1})
+	alice.expect(http.StatusNotFound, http.MethodPost, base+"/reactions", gin.H{"emoji": thumbsUp})
+}
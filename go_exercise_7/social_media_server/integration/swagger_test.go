@@ -0,0 +1,245 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const swaggerFile = "../docs/v1/v1_swagger.json"
+
+// Chỉ những phần của Swagger 2.0 mà swag sinh ra cho API này
+type swaggerSpec struct {
+	BasePath    string                                 `json:"basePath"`
+	Paths       map[string]map[string]swaggerOperation `json:"paths"`
+	Definitions map[string]*schema                     `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Responses map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"responses"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+}
+
+// contract kiểm tra response thật theo swagger và ghi lại các operation đã được gọi
+type contract struct {
+	spec *swaggerSpec
+
+	mu        sync.Mutex
+	exercised map[string]bool
+}
+
+func loadContract(path string) (*contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec swaggerSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &contract{spec: &spec, exercised: map[string]bool{}}, nil
+}
+
+func operationKey(method, template string) string {
+	return strings.ToUpper(method) + " " + template
+}
+
+// match tìm template của path (không có basePath); khi nhiều template khớp, template có nhiều
+// đoạn cố định hơn thắng, nên /posts/1/revisions/diff khớp .../diff chứ không phải .../{version}
+func (ct *contract) match(method, path string) (string, *swaggerOperation, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestLiterals := "", -1
+	var bestOp *swaggerOperation
+	for template, ops := range ct.spec.Paths {
+		op, ok := ops[strings.ToLower(method)]
+		if !ok {
+			continue
+		}
+		parts := strings.Split(strings.Trim(template, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		literals := 0
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") {
+				continue
+			}
+			if part != segments[i] {
+				literals = -1
+				break
+			}
+			literals++
+		}
+		if literals > bestLiterals {
+			op := op
+			best, bestLiterals, bestOp = template, literals, &op
+		}
+	}
+	return best, bestOp, bestOp != nil
+}
+
+// check trả lỗi nếu status không được tài liệu hoá cho operation hoặc body JSON không khớp schema
+func (ct *contract) check(method, path string, status int, contentType string, body []byte) error {
+	template, op, ok := ct.match(method, path)
+	if !ok {
+		return nil
+	}
+	ct.mu.Lock()
+	ct.exercised[operationKey(method, template)] = true
+	ct.mu.Unlock()
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not documented for %s", method, path, status, template)
+	}
+	if resp.Schema == nil || resp.Schema.Type == "file" || !strings.HasPrefix(contentType, "application/json") {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("%s %s: invalid JSON body: %w", method, path, err)
+	}
+	if value == nil {
+		return fmt.Errorf("%s %s: body is null", method, path)
+	}
+	if err := ct.validate(value, resp.Schema, "$"); err != nil {
+		return fmt.Errorf("%s %s (%d): %w", method, path, status, err)
+	}
+	return nil
+}
+
+func (ct *contract) resolve(s *schema) (*schema, error) {
+	for s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/definitions/")
+		def, ok := ct.spec.Definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown definition %s", s.Ref)
+		}
+		s = def
+	}
+	return s, nil
+}
+
+// validate so value với schema. Swagger 2.0 không có nullable và swag không đánh dấu field con
+// trỏ, nên null được chấp nhận ở mọi field. Object có properties không được chứa field lạ.
+func (ct *contract) validate(value interface{}, s *schema, at string) error {
+	s, err := ct.resolve(s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", at, err)
+	}
+	if value == nil {
+		return nil
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %s", at, describe(value))
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				if len(s.Properties) == 0 {
+					continue
+				}
+				return fmt.Errorf("%s: undocumented property %q", at, key)
+			}
+			if err := ct.validate(obj[key], prop, at+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %s", at, describe(value))
+		}
+		if s.Items == nil {
+			return nil
+		}
+		for i, item := range arr {
+			if err := ct.validate(item, s.Items, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %s", at, describe(value))
+		}
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: expected integer, got %s", at, describe(value))
+		}
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("%s: expected integer, got %s", at, n)
+		}
+	case "number":
+		if _, ok := value.(json.Number); !ok {
+			return fmt.Errorf("%s: expected number, got %s", at, describe(value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %s", at, describe(value))
+		}
+	}
+	return nil
+}
+
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return "number " + v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// missing trả về các operation trong swagger chưa được test nào gọi tới
+func (ct *contract) missing() []string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	var out []string
+	for template, ops := range ct.spec.Paths {
+		for method := range ops {
+			key := operationKey(method, template)
+			if !ct.exercised[key] {
+				out = append(out, key)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	s := newServer(t)
+	alice, admin := s.register("alice"), s.registerWithRole("admin", "admin")
+	guest := s.anonymous()
+	id := alice.createPost("Exported", "content")
+	alice.createComment(id, "first comment")
+
+	// Chỉ admin được export/import
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/export", nil)
+	alice.expect(http.StatusForbidden, http.MethodGet, "/export", nil)
+	guest.expect(http.StatusUnauthorized, http.MethodPost, "/import", "")
+	alice.expect(http.StatusForbidden, http.MethodPost, "/import", "")
+
+	admin.expect(http.StatusBadRequest, http.MethodGet, "/export?format=xml", nil)
+	var records []struct {
+		ID       uint   `json:"id"`
+		Title    string `json:"title"`
+		Comments []struct {
+			Content string `json:"content"`
+		} `json:"comments"`
+	}
+	export := admin.expect(http.StatusOK, http.MethodGet, "/export?format=json", nil)
+	export.decode(t, &records)
+	if len(records) != 1 || records[0].Title != "Exported" || len(records[0].Comments) != 1 {
+		t.Fatalf("exported records: %+v", records)
+	}
+	ndjson := admin.expect(http.StatusOK, http.MethodGet, "/export", nil)
+	if n := strings.Count(strings.TrimSpace(string(ndjson.Body)), "\n") + 1; n != 1 {
+		t.Fatalf("NDJSON export has %d lines, want 1", n)
+	}
+
+	admin.expect(http.StatusBadRequest, http.MethodPost, "/import?format=xml", "")
+	admin.expect(http.StatusBadRequest, http.MethodPost, "/import?preserve_ids=maybe", "")
+	admin.expect(http.StatusBadRequest, http.MethodPost, "/import?batch_size=0", "")
+	admin.expect(http.StatusBadRequest, http.MethodPost, "/import?format=json", "{")
+
+	var report struct {
+		PostsImported    int `json:"posts_imported"`
+		CommentsImported int `json:"comments_imported"`
+		Failed           int `json:"failed"`
+	}
+	admin.expect(http.StatusOK, http.MethodPost, "/import?format=json", export.Body).decode(t, &report)
+	if report.PostsImported != 1 || report.CommentsImported != 1 || report.Failed != 0 {
+		t.Fatalf("import report: %+v", report)
+	}
+
+	// Bản ghi hỏng chỉ làm hỏng dòng đó, các dòng còn lại vẫn được nhập
+	body := string(ndjson.Body) + "not json\n"
+	admin.expect(http.StatusOK, http.MethodPost, "/import", body).decode(t, &report)
+	if report.PostsImported != 1 || report.Failed != 1 {
+		t.Fatalf("import report with a broken line: %+v", report)
+	}
+
+	var posts []postBody
+	admin.expect(http.StatusOK, http.MethodGet, "/posts", nil).decode(t, &posts)
+	if len(posts) != 3 {
+		t.Fatalf("%d posts after importing twice, want 3", len(posts))
+	}
+}
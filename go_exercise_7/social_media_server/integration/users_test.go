@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUsers(t *testing.T) {
+	s := newServer(t)
+	guest := s.anonymous()
+
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/users", gin.H{"username": "x"})
+	guest.expect(http.StatusBadRequest, http.MethodPost, "/users", "{not json")
+	alice := s.register("alice")
+	guest.expect(http.StatusConflict, http.MethodPost, "/users", gin.H{"username": "alice"})
+	bob := s.register("bob")
+
+	var me struct {
+		ID       uint
+		Username string `json:"username"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/users/me", nil).decode(t, &me)
+	if me.ID != alice.ID || me.Username != "alice" {
+		t.Fatalf("GET /users/me returned %+v", me)
+	}
+	guest.expect(http.StatusUnauthorized, http.MethodGet, "/users/me", nil)
+	(&client{s: s, key: "not-a-key"}).expect(http.StatusUnauthorized, http.MethodGet, "/users/me", nil)
+
+	guest.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/users/%d", bob.ID), nil)
+	guest.expect(http.StatusBadRequest, http.MethodGet, "/users/abc", nil)
+	guest.expect(http.StatusNotFound, http.MethodGet, "/users/999", nil)
+}
+
+func TestFollows(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	bobPath := fmt.Sprintf("/users/%d", bob.ID)
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, bobPath+"/follow", nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, fmt.Sprintf("/users/%d/follow", alice.ID), nil)
+	alice.expect(http.StatusBadRequest, http.MethodPost, "/users/abc/follow", nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, "/users/999/follow", nil)
+	alice.expect(http.StatusOK, http.MethodPost, bobPath+"/follow", nil)
+	alice.expect(http.StatusOK, http.MethodPost, bobPath+"/follow", nil)
+
+	var followers struct {
+		Data []struct {
+			ID uint
+		} `json:"data"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, bobPath+"/followers", nil).decode(t, &followers)
+	if followers.Pagination.Total != 1 || followers.Data[0].ID != alice.ID {
+		t.Fatalf("followers of bob: %+v", followers)
+	}
+	alice.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/users/%d/following", alice.ID), nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, bobPath+"/followers?page=0", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/users/abc/following", nil)
+	alice.expect(http.StatusNotFound, http.MethodGet, "/users/999/followers", nil)
+	alice.expect(http.StatusNotFound, http.MethodGet, "/users/999/following", nil)
+
+	alice.expect(http.StatusOK, http.MethodDelete, bobPath+"/follow", nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, bobPath+"/follow", nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/users/abc/follow", nil)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodDelete, bobPath+"/follow", nil)
+}
+
+func TestBlocks(t *testing.T) {
+	s := newServer(t)
+	alice, bob := s.register("alice"), s.register("bob")
+	bobPath := fmt.Sprintf("/users/%d", bob.ID)
+
+	alice.expect(http.StatusBadRequest, http.MethodPost, fmt.Sprintf("/users/%d/block", alice.ID), nil)
+	alice.expect(http.StatusNotFound, http.MethodPost, "/users/999/block", nil)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodPost, bobPath+"/block", nil)
+	alice.expect(http.StatusOK, http.MethodPost, bobPath+"/block", nil)
+
+	var blocks struct {
+		Data []struct {
+			BlockedID uint `json:"blocked_id"`
+		} `json:"data"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/users/me/blocks", nil).decode(t, &blocks)
+	if len(blocks.Data) != 1 || blocks.Data[0].BlockedID != bob.ID {
+		t.Fatalf("blocks of alice: %+v", blocks)
+	}
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/users/me/blocks?page_size=abc", nil)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/users/me/blocks", nil)
+
+	alice.expect(http.StatusOK, http.MethodDelete, bobPath+"/block", nil)
+	alice.expect(http.StatusNotFound, http.MethodDelete, bobPath+"/block", nil)
+	alice.expect(http.StatusBadRequest, http.MethodDelete, "/users/abc/block", nil)
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodDelete, bobPath+"/block", nil)
+}
+
+func TestFeed(t *testing.T) {
+	s := newServer(t)
+	alice, bob, carol := s.register("alice"), s.register("bob"), s.register("carol")
+	alice.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/users/%d/follow", bob.ID), nil)
+	first := bob.createPost("First", "content")
+	second := bob.createPost("Second", "content")
+	carol.createPost("Not followed", "content")
+
+	s.anonymous().expect(http.StatusUnauthorized, http.MethodGet, "/feed", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/feed?limit=0", nil)
+	alice.expect(http.StatusBadRequest, http.MethodGet, "/feed?cursor=garbage", nil)
+
+	var page struct {
+		Data       []postBody `json:"data"`
+		NextCursor string     `json:"next_cursor"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/feed?limit=1", nil).decode(t, &page)
+	if len(page.Data) != 1 || page.Data[0].ID != second || page.NextCursor == "" {
+		t.Fatalf("first feed page: %+v", page)
+	}
+	var next struct {
+		Data       []postBody `json:"data"`
+		NextCursor string     `json:"next_cursor"`
+	}
+	alice.expect(http.StatusOK, http.MethodGet, "/feed?limit=1&cursor="+url.QueryEscape(page.NextCursor), nil).decode(t, &next)
+	if len(next.Data) != 1 || next.Data[0].ID != first {
+		t.Fatalf("second feed page: %+v", next)
+	}
+}
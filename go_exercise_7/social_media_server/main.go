@@ -1,17 +1,17 @@
 package main
 
 import (
+	"fmt"
+	"io"
 	"log"
 	"os"
-	"social_media_server/config"
-	_ "social_media_server/docs"
-	"social_media_server/routes"
+	"strings"
 
 	"github.com/joho/godotenv"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+//go:generate go run github.com/swaggo/swag/cmd/swag init --parseDependency --propertyStrategy pascalcase --instanceName v1 --output docs/v1
+
 // @title Social Media API
 // @version 1.0
 // @description This is a sample server for a social media application.
@@ -25,7 +25,7 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host localhost:8080
-// @BasePath /
+// @BasePath /api/v1
 // @schemes http https
 
 // @securityDefinitions.apikey ApiKeyAuth
@@ -36,21 +36,55 @@ func main() {
 		log.Println("No .env file found or error loading, relying on environment variables")
 	}
 
-	config.ConnectDB()
-	// config.ConnectRedis() 
-
-	router := routes.SetupRouter()
+	name, args := "serve", []string(nil)
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(args)
+			return
+		}
+	}
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	log.Fatalf("Unknown command %q (available: %s)", name, strings.Join(names, ", "))
+}
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+// command là một lệnh con; mọi lệnh đọc cấu hình từ .env/biến môi trường giống server
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
+var commands = []command{
+	{"serve", "run the HTTP API server (default)", runServe},
+	{"migrate", "create or update the database schema", runMigrate},
+	{"seed", "insert sample users, a community, posts, comments and reactions", runSeed},
+	{"create-user", "create a user and print its API key", runCreateUser},
+	{"grant-role", "change the role of a user (user, moderator or admin)", runGrantRole},
+	{"purge", "permanently delete soft-deleted posts, comments and messages", runPurge},
+	{"rebuild", "rebuild tags, mentions and denormalized counters", runRebuild},
+	{"repair-counts", "recompute comment counts of every post", runRepairCounts},
+	{"export", "export posts with their comments", runExport},
+	{"import", "import posts exported by the export command", runImport},
+	{"doctor", "check database, replicas, schema, Redis and storage connectivity", runDoctor},
+}
 
-	log.Printf("Server starting on port %s", port)
-	log.Printf("Swagger UI available at http://localhost:%s/swagger/index.html", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Failed to run server:", err)
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: social_media_server [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
 	}
-}
\ No newline at end of file
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run \"social_media_server <command> -h\" for the flags of a command.")
+}
@@ -0,0 +1,245 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errMalformedImage = errors.New("media: malformed image data")
+
+// StripMetadata xoá EXIF/XMP/IPTC và comment khỏi ảnh JPEG, PNG, WebP, GIF mà không encode lại ảnh.
+// Các định dạng khác được trả về nguyên vẹn.
+func StripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	case "image/gif":
+		return stripGIF(data)
+	default:
+		return data, nil
+	}
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF || i+1 >= len(data) {
+			return nil, errMalformedImage
+		}
+		marker := data[i+1]
+
+		// Byte đệm 0xFF giữa các segment
+		if marker == 0xFF {
+			i++
+			continue
+		}
+		// Từ SOS trở đi là dữ liệu ảnh, giữ nguyên phần còn lại
+		if marker == 0xDA {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		// Marker không có payload
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, errMalformedImage
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, errMalformedImage
+		}
+
+		// APP1 (EXIF, XMP), APP13 (IPTC) và COM bị bỏ; APP2 (ICC profile) được giữ để màu hiển thị đúng
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return out.Bytes(), nil
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	i := len(pngSignature)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		chunkType := string(data[i+4 : i+8])
+		// 4 byte độ dài + 4 byte loại + dữ liệu + 4 byte CRC
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[i:end])
+		}
+		i = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// Cờ trong chunk VP8X báo ảnh có chunk EXIF/XMP, phải tắt khi bỏ các chunk đó
+const (
+	vp8xFlagXMP  = 0x04
+	vp8xFlagEXIF = 0x08
+)
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		fourCC := string(data[i : i+4])
+		length := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		// 4 byte loại + 4 byte độ dài + dữ liệu, đệm thêm 1 byte nếu độ dài lẻ
+		end := i + 8 + length + length%2
+		if length < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if length > 0 {
+				chunk[8] &^= vp8xFlagEXIF | vp8xFlagXMP
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:8], uint32(len(stripped)-8))
+	return stripped, nil
+}
+
+// Application extension điều khiển số lần lặp của ảnh động, được giữ lại
+var gifLoopExtensions = map[string]bool{
+	"NETSCAPE2.0": true,
+	"ANIMEXTS1.0": true,
+}
+
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, errMalformedImage
+	}
+
+	// Header, logical screen descriptor và bảng màu toàn cục (nếu có)
+	i := 13
+	if packed := data[10]; packed&0x80 != 0 {
+		i += 3 << ((packed & 0x07) + 1)
+	}
+	if i > len(data) {
+		return nil, errMalformedImage
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:i])
+
+	for i < len(data) {
+		start := i
+		switch data[i] {
+		case 0x3B: // trailer
+			out.WriteByte(0x3B)
+			return out.Bytes(), nil
+		case 0x21: // extension
+			if i+2 > len(data) {
+				return nil, errMalformedImage
+			}
+			label := data[i+1]
+			end, err := skipGIFSubBlocks(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+			// Bỏ comment (0xFE) và application extension (0xFF, ví dụ XMP) trừ khi là cấu hình lặp
+			keep := label != 0xFE && label != 0xFF
+			if label == 0xFF && i+14 <= len(data) && data[i+2] == 11 {
+				keep = gifLoopExtensions[string(data[i+3:i+14])]
+			}
+			if keep {
+				out.Write(data[start:end])
+			}
+			i = end
+		case 0x2C: // image descriptor
+			if i+10 > len(data) {
+				return nil, errMalformedImage
+			}
+			i += 10
+			if packed := data[i-1]; packed&0x80 != 0 {
+				i += 3 << ((packed & 0x07) + 1)
+			}
+			// Byte LZW minimum code size rồi tới dữ liệu ảnh
+			end, err := skipGIFSubBlocks(data, i+1)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[start:end])
+			i = end
+		default:
+			return nil, errMalformedImage
+		}
+	}
+	// Thiếu trailer: nhiều công cụ ghi GIF như vậy và trình duyệt vẫn hiển thị được
+	return out.Bytes(), nil
+}
+
+// skipGIFSubBlocks trả về vị trí ngay sau chuỗi sub-block bắt đầu tại i (kết thúc bằng block rỗng)
+func skipGIFSubBlocks(data []byte, i int) (int, error) {
+	for {
+		if i >= len(data) {
+			return 0, errMalformedImage
+		}
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+}
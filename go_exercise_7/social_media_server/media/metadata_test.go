@@ -0,0 +1,144 @@
+package media
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// Ảnh WebP lossless 1x1
+const tinyWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+const gpsMarker = "GPSLatitude 21.0285"
+
+func riffChunk(fourCC string, payload []byte) []byte {
+	chunk := append([]byte(fourCC), make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(chunk[4:], uint32(len(payload)))
+	chunk = append(chunk, payload...)
+	if len(payload)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+// webpWithMetadata dựng ảnh WebP dạng mở rộng (VP8X) từ tinyWebP kèm chunk EXIF và XMP
+func webpWithMetadata(t *testing.T) []byte {
+	t.Helper()
+	simple, err := base64.StdEncoding.DecodeString(tinyWebP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vp8x := make([]byte, 10) // cờ, 3 byte dự trữ, chiều rộng-1 và chiều cao-1 (24 bit)
+	vp8x[0] = vp8xFlagEXIF | vp8xFlagXMP
+
+	body := []byte("WEBP")
+	body = append(body, riffChunk("VP8X", vp8x)...)
+	body = append(body, simple[12:]...) // chunk VP8L
+	body = append(body, riffChunk("EXIF", []byte("Exif\x00\x00"+gpsMarker))...)
+	body = append(body, riffChunk("XMP ", []byte("<x:xmpmeta>"+gpsMarker+"</x:xmpmeta>"))...)
+	return append(riffChunk("RIFF", body)[:8], body...)
+}
+
+// gifWithMetadata mã hoá một ảnh động lặp vô hạn rồi chèn comment và application extension XMP
+// ngay sau bảng màu toàn cục
+func gifWithMetadata(t *testing.T) []byte {
+	t.Helper()
+	palette := color.Palette{color.Black, color.White}
+	frame := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:     []*image.Paletted{frame, frame},
+		Delay:     []int{10, 10},
+		LoopCount: 0,
+		Config:    image.Config{ColorModel: palette, Width: 2, Height: 2},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	header := 13
+	if packed := data[10]; packed&0x80 != 0 {
+		header += 3 << ((packed & 0x07) + 1)
+	}
+	comment := append([]byte{0x21, 0xFE, byte(len(gpsMarker))}, gpsMarker...)
+	comment = append(comment, 0)
+	xmp := append([]byte{0x21, 0xFF, 11}, "XMP DataXMP"...)
+	xmp = append(xmp, byte(len(gpsMarker)))
+	xmp = append(xmp, gpsMarker...)
+	xmp = append(xmp, 0)
+
+	out := append([]byte(nil), data[:header]...)
+	out = append(out, comment...)
+	out = append(out, xmp...)
+	return append(out, data[header:]...)
+}
+
+func TestStripMetadataWebP(t *testing.T) {
+	data := webpWithMetadata(t)
+	if _, err := webp.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("fixture does not decode: %v", err)
+	}
+
+	stripped, err := StripMetadata("image/webp", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte(gpsMarker)) || bytes.Contains(stripped, []byte("EXIF")) || bytes.Contains(stripped, []byte("XMP ")) {
+		t.Fatalf("metadata left in %q", stripped)
+	}
+	if size := binary.LittleEndian.Uint32(stripped[4:8]); int(size) != len(stripped)-8 {
+		t.Fatalf("RIFF size %d, want %d", size, len(stripped)-8)
+	}
+	if flags := stripped[20]; flags&(vp8xFlagEXIF|vp8xFlagXMP) != 0 {
+		t.Fatalf("VP8X still flags metadata: %#x", flags)
+	}
+	img, err := webp.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 1 || b.Dy() != 1 {
+		t.Fatalf("stripped image bounds %v", b)
+	}
+}
+
+func TestStripMetadataGIF(t *testing.T) {
+	stripped, err := StripMetadata("image/gif", gifWithMetadata(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stripped, []byte(gpsMarker)) {
+		t.Fatalf("metadata left in %q", stripped)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(stripped))
+	if err != nil {
+		t.Fatalf("stripped image does not decode: %v", err)
+	}
+	if len(g.Image) != 2 || g.LoopCount != 0 {
+		t.Fatalf("stripped animation has %d frames, loop count %d", len(g.Image), g.LoopCount)
+	}
+}
+
+func TestStripMetadataMalformed(t *testing.T) {
+	tests := []struct {
+		contentType string
+		data        string
+	}{
+		{"image/webp", "RIFF\x04\x00\x00\x00WAVE"},
+		{"image/webp", "RIFF\x20\x00\x00\x00WEBPVP8L\xff\x00\x00\x00"},
+		{"image/gif", "GIF89"},
+		{"image/gif", "GIF89a\x01\x00\x01\x00\x00\x00\x00\x21\xfe\x05ab"},
+		{"image/jpeg", "\xff\xd8\xff\xe1\x00"},
+		{"image/png", "not a png"},
+	}
+	for _, tt := range tests {
+		if _, err := StripMetadata(tt.contentType, []byte(tt.data)); err == nil {
+			t.Errorf("StripMetadata(%s, %q) succeeded, want an error", tt.contentType, tt.data)
+		}
+	}
+}
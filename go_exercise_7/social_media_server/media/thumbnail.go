@@ -0,0 +1,56 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+)
+
+const maxSourcePixels = 40_000_000
+
+var ErrImageTooLarge = errors.New("media: image dimensions are too large")
+
+// Thumbnail thu nhỏ ảnh sao cho cạnh dài nhất không vượt quá maxDim và encode lại dưới dạng JPEG.
+// Ảnh được encode lại nên thumbnail không mang theo metadata nào của ảnh gốc.
+func Thumbnail(data []byte, maxDim int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// Chặn ảnh "decompression bomb" trước khi decode toàn bộ
+	if cfg.Width*cfg.Height > maxSourcePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w > maxDim || h > maxDim {
+		if w >= h {
+			h = h * maxDim / w
+			w = maxDim
+		} else {
+			w = w * maxDim / h
+			h = maxDim
+		}
+	}
+	w, h = max(w, 1), max(h, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
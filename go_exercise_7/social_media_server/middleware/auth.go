@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/models"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const currentUserKey = "currentUser"
+
+// NewAPIKey sinh API key mới, trả về key (chỉ hiện cho người dùng một lần) và hash để lưu DB
+func NewAPIKey() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key := hex.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate xác định người dùng từ header "Authorization: Bearer <api key>" nếu có.
+// Request không có header vẫn đi tiếp như khách; header sai thì bị từ chối.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		authenticateKey(c, strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+	}
+}
+
+// QueryAPIKey cho phép truyền API key qua query string, chỉ dùng cho route mà client không đặt được header (EventSource)
+func QueryAPIKey(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Query(param)
+		if _, ok := CurrentUser(c); ok || key == "" {
+			c.Next()
+			return
+		}
+		authenticateKey(c, key)
+	}
+}
+
+func authenticateKey(c *gin.Context, key string) {
+	var user models.User
+	if err := config.DB.WithContext(c.Request.Context()).Where("api_key_hash = ?", HashAPIKey(key)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate"})
+		return
+	}
+
+	c.Set(currentUserKey, &user)
+	c.Next()
+}
+
+// RequireAuth chặn các request chưa đăng nhập, phải đặt sau Authenticate
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := CurrentUser(c); !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole chỉ cho phép người dùng có một trong các role đã cho, phải đặt sau Authenticate
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := CurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+	}
+}
+
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	v, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*models.User)
+	return user, ok
+}
+
+// CurrentUserID trả về nil cho khách, dùng để gán trực tiếp vào các cột user_id
+func CurrentUserID(c *gin.Context) *uint {
+	if user, ok := CurrentUser(c); ok {
+		id := user.ID
+		return &id
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationPolicy mô tả một phiên bản API cũ: ngày ngừng khuyến khích dùng, ngày tắt hẳn (Sunset,
+// zero nếu chưa định) và tiền tố path của phiên bản thay thế
+type DeprecationPolicy struct {
+	DeprecatedAt    time.Time
+	Sunset          time.Time
+	Prefix          string
+	SuccessorPrefix string
+}
+
+// Deprecation gắn header Deprecation (RFC 9745), Sunset (RFC 8594) và Link tới cùng tài nguyên
+// ở phiên bản thay thế vào mọi response của phiên bản cũ
+func Deprecation(policy DeprecationPolicy) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(policy.DeprecatedAt.Unix(), 10)
+	var sunset string
+	if !policy.Sunset.IsZero() {
+		sunset = policy.Sunset.UTC().Format(http.TimeFormat)
+	}
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if sunset != "" {
+			c.Header("Sunset", sunset)
+		}
+		if policy.SuccessorPrefix != "" {
+			successor := policy.SuccessorPrefix + strings.TrimPrefix(c.Request.URL.Path, policy.Prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
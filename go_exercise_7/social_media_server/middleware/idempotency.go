@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"social_media_server/config"
+	"social_media_server/idempotency"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// responseRecorder giữ lại body đã ghi để lưu cho các lần gửi lại
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func sha256Hex(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalBody chuẩn hoá body JSON (bỏ khoảng trắng, sắp xếp key) để cùng payload cho cùng fingerprint
+func canonicalBody(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return body
+	}
+	canonical, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return canonical
+}
+
+// Idempotent lưu response đầu tiên của request có header Idempotency-Key và trả lại nguyên văn
+// cho các lần gửi lại cùng key trong config.IdempotencyTTL. Key được tính riêng cho từng người dùng
+// (hoặc IP với khách) và từng route. Cùng key nhưng payload khác bị từ chối; response 5xx
+// không được lưu để client có thể thử lại.
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || config.Idempotency == nil {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength)})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := "ip:" + c.ClientIP()
+		if userID := CurrentUserID(c); userID != nil {
+			scope = fmt.Sprintf("user:%d", *userID)
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		storeKey := sha256Hex([]byte(scope), []byte(route), []byte(key))
+		// Fingerprint dùng path thật để cùng key trên hai tài nguyên khác nhau (ví dụ hai hội thoại) bị từ chối
+		fingerprint := sha256Hex([]byte(c.Request.Method+" "+c.Request.URL.Path), canonicalBody(body))
+
+		existing, err := config.Idempotency.Reserve(c.Request.Context(), storeKey, fingerprint, idempotency.LockTTL)
+		if err != nil {
+			log.Printf("Failed to reserve idempotency key: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key has already been used with a different request"})
+			case !existing.Completed():
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Không dùng context của request: client mất kết nối vẫn phải lưu được response để lần gửi lại nhận được
+		ctx := context.Background()
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := config.Idempotency.Release(ctx, storeKey); err != nil {
+				log.Printf("Failed to release idempotency key: %v", err)
+			}
+			return
+		}
+		resp := &idempotency.Response{
+			Fingerprint: fingerprint,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := config.Idempotency.Complete(ctx, storeKey, resp, config.IdempotencyTTL); err != nil {
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	}
+}
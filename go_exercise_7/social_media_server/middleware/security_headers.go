@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHeaderConfig là các header bảo mật gắn vào mọi response; giá trị rỗng/zero thì bỏ qua header đó
+type SecurityHeaderConfig struct {
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	ReferrerPolicy        string
+	// ContentSecurityPolicy cho API, SwaggerSecurityPolicy cho các trang Swagger UI (đường dẫn chứa /swagger/)
+	ContentSecurityPolicy string
+	SwaggerSecurityPolicy string
+}
+
+func SecurityHeaders(cfg SecurityHeaderConfig) gin.HandlerFunc {
+	var hsts string
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(cfg.HSTSMaxAge/time.Second), 10)
+		if cfg.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+	return func(c *gin.Context) {
+		h := c.Writer.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		if cfg.ReferrerPolicy != "" {
+			h.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		csp := cfg.ContentSecurityPolicy
+		if strings.Contains(c.Request.URL.Path, "/swagger/") {
+			csp = cfg.SwaggerSecurityPolicy
+		}
+		if csp != "" {
+			h.Set("Content-Security-Policy", csp)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"flag"
+	"social_media_server/config"
+)
+
+// runMigrate tạo hoặc cập nhật schema rồi thoát, để triển khai có thể migrate trước khi khởi động
+// các instance mới với DB_AUTO_MIGRATE=false
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Parse(args)
+
+	config.OpenDB()
+	config.MigrateDB()
+}
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+type Attachment struct {
+	gorm.Model
+	PostID       uint   `json:"post_id" gorm:"index"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	StorageKey   string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	URL          string `json:"url" gorm:"-"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty" gorm:"-"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditLog là bản ghi chỉ thêm, không bao giờ sửa hay xoá. Before/After là snapshot JSON
+// của tài nguyên (null khi tạo mới hoặc đã xoá); ActorID nil là khách chưa đăng nhập.
+type AuditLog struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time       `json:"created_at" gorm:"index"`
+	ActorID      *uint           `json:"actor_id" gorm:"index"`
+	Action       string          `json:"action" gorm:"size:16;index"`
+	ResourceType string          `json:"resource_type" gorm:"size:32;index:idx_audit_logs_resource"`
+	ResourceID   uint            `json:"resource_id" gorm:"index:idx_audit_logs_resource"`
+	Before       json.RawMessage `json:"before" swaggertype:"object"`
+	After        json.RawMessage `json:"after" swaggertype:"object"`
+	RequestID    string          `json:"request_id" gorm:"size:64;index"`
+	IP           string          `json:"ip" gorm:"size:64"`
+	UserAgent    string          `json:"user_agent" gorm:"size:255"`
+	Method       string          `json:"method" gorm:"size:8"`
+	Path         string          `json:"path" gorm:"size:255"`
+}
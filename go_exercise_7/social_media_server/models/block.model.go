@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Block: BlockerID chặn BlockedID, hai người không nhắn tin trực tiếp được với nhau theo cả hai chiều
+type Block struct {
+	BlockerID uint      `json:"blocker_id" gorm:"primaryKey"`
+	BlockedID uint      `json:"blocked_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+	Blocked   *User     `json:"blocked,omitempty" gorm:"foreignKey:BlockedID"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Collection là một nhóm bookmark có tên do người dùng tự đặt, tên không trùng nhau trong cùng một người dùng
+type Collection struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	UserID        uint      `json:"user_id" gorm:"uniqueIndex:idx_collections_user_name;not null"`
+	Name          string    `json:"name" gorm:"size:64;uniqueIndex:idx_collections_user_name;not null"`
+	BookmarkCount int64     `json:"bookmark_count" gorm:"-"`
+}
+
+// Bookmark: mỗi người dùng lưu một post tối đa một lần, có thể xếp vào một collection
+type Bookmark struct {
+	UserID       uint      `json:"user_id" gorm:"primaryKey"`
+	PostID       uint      `json:"post_id" gorm:"primaryKey;index"`
+	CollectionID *uint     `json:"collection_id" gorm:"index"`
+	CreatedAt    time.Time `json:"created_at"`
+	Post         *Post     `json:"post,omitempty" gorm:"foreignKey:PostID"`
+}
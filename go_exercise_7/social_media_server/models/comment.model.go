@@ -1,9 +1,50 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"social_media_server/render"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Comment struct {
 	gorm.Model
-	Content string `json:"content"`
-	PostID  uint   `json:"post_id"` 
-}
\ No newline at end of file
+	Content       string          `json:"content"`
+	ContentFormat string          `json:"content_format" gorm:"size:16;default:plain"`
+	ContentHTML   string          `json:"content_html"`
+	PostID        uint            `json:"post_id"`
+	ParentID      *uint           `json:"parent_id" gorm:"index"`
+	UserID        *uint           `json:"user_id" gorm:"index"`
+	Hidden        bool            `json:"hidden" gorm:"default:false;index"`
+	EditedAt      *time.Time      `json:"edited_at"`
+	Edited        bool            `json:"edited" gorm:"-"`
+	Entities      []render.Entity `json:"entities" gorm:"-"`
+	Reactions     ReactionSummary `json:"reactions" gorm:"-"`
+}
+
+func (cm *Comment) BeforeSave(tx *gorm.DB) error {
+	if cm.ContentFormat == "" {
+		cm.ContentFormat = render.FormatPlain
+	}
+	html, err := render.HTML(cm.ContentFormat, cm.Content)
+	if err != nil {
+		return err
+	}
+	cm.ContentHTML = html
+	cm.Edited = cm.EditedAt != nil
+	cm.Entities = render.Entities(cm.Content)
+	return nil
+}
+
+func (cm *Comment) AfterFind(tx *gorm.DB) error {
+	if cm.ContentHTML == "" && cm.Content != "" {
+		html, err := render.HTML(cm.ContentFormat, cm.Content)
+		if err != nil {
+			return err
+		}
+		cm.ContentHTML = html
+	}
+	cm.Edited = cm.EditedAt != nil
+	cm.Entities = render.Entities(cm.Content)
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// JoinOpen: ai cũng tham gia ngay; JoinApproval: cần moderator của community duyệt yêu cầu
+	JoinOpen     = "open"
+	JoinApproval = "approval"
+
+	CommunityMember    = "member"
+	CommunityModerator = "moderator"
+	CommunityOwner     = "owner"
+
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
+type Community struct {
+	gorm.Model
+	Name        string `json:"name" gorm:"size:100;not null"`
+	Slug        string `json:"slug" gorm:"size:64;uniqueIndex;not null"`
+	Description string `json:"description" gorm:"type:text"`
+	Rules       string `json:"rules" gorm:"type:text"`
+	JoinPolicy  string `json:"join_policy" gorm:"size:16;default:open"`
+	OwnerID     uint   `json:"owner_id" gorm:"index"`
+	MemberCount int64  `json:"member_count" gorm:"-"`
+}
+
+// CommunityMembership: mỗi user có một dòng cho mỗi community đã tham gia, role là member, moderator hoặc owner
+type CommunityMembership struct {
+	CommunityID uint      `json:"community_id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"primaryKey;index"`
+	Role        string    `json:"role" gorm:"size:16;default:member"`
+	CreatedAt   time.Time `json:"created_at"`
+	User        *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (m *CommunityMembership) IsModerator() bool {
+	return m.Role == CommunityModerator || m.Role == CommunityOwner
+}
+
+// CommunityJoinRequest: yêu cầu tham gia community cần duyệt. Mỗi user chỉ có một yêu cầu cho mỗi
+// community, gửi lại sau khi bị từ chối sẽ mở lại yêu cầu đó.
+type CommunityJoinRequest struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CommunityID  uint       `json:"community_id" gorm:"uniqueIndex:idx_join_requests_community_user"`
+	UserID       uint       `json:"user_id" gorm:"uniqueIndex:idx_join_requests_community_user"`
+	Message      string     `json:"message" gorm:"size:500"`
+	Status       string     `json:"status" gorm:"size:16;default:pending;index"`
+	ResolvedByID *uint      `json:"resolved_by_id"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+}
+
+func ValidSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+func ValidJoinPolicy(policy string) bool {
+	return policy == JoinOpen || policy == JoinApproval
+}
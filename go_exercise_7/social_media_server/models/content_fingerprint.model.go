@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ContentFingerprint lưu hash nội dung vừa gửi để phát hiện tin nhắn trùng lặp
+type ContentFingerprint struct {
+	ID        uint      `gorm:"primaryKey"`
+	CreatedAt time.Time `gorm:"index"`
+	Author    string    `gorm:"size:64;index:idx_content_fingerprints_author_hash"`
+	Hash      string    `gorm:"size:64;index:idx_content_fingerprints_author_hash"`
+}
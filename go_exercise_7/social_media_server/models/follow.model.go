@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+type Follow struct {
+	FollowerID uint      `json:"follower_id" gorm:"primaryKey"`
+	FolloweeID uint      `json:"followee_id" gorm:"primaryKey;index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// IdempotencyKey lưu response đầu tiên của một request có Idempotency-Key để trả lại khi client gửi lại.
+// Status = 0 nghĩa là request đầu tiên vẫn đang được xử lý.
+type IdempotencyKey struct {
+	ID          uint `gorm:"primaryKey"`
+	CreatedAt   time.Time
+	Key         string `gorm:"size:64;uniqueIndex"`
+	Fingerprint string `gorm:"size:64"`
+	Status      int
+	ContentType string `gorm:"size:128"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index"`
+}
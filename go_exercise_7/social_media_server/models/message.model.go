@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxGroupParticipants giới hạn số người trong một nhóm chat (tính cả người tạo)
+const MaxGroupParticipants = 20
+
+type Conversation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Title     string    `json:"title" gorm:"size:100"`
+	IsGroup   bool      `json:"is_group"`
+	// DirectKey chỉ có ở hội thoại 1-1 ("<id nhỏ>:<id lớn>") để mỗi cặp người dùng có đúng một hội thoại
+	DirectKey     *string                   `json:"-" gorm:"size:32;uniqueIndex"`
+	CreatorID     uint                      `json:"creator_id"`
+	LastMessageAt time.Time                 `json:"last_message_at" gorm:"index"`
+	Participants  []ConversationParticipant `json:"participants,omitempty"`
+	UnreadCount   int64                     `json:"unread_count" gorm:"-"`
+}
+
+// ConversationParticipant: LastReadMessageID là tin nhắn cuối người này đã đọc (read receipt),
+// tin nhắn có id lớn hơn của người khác được tính là chưa đọc
+type ConversationParticipant struct {
+	ConversationID    uint       `json:"conversation_id" gorm:"primaryKey"`
+	UserID            uint       `json:"user_id" gorm:"primaryKey;index"`
+	CreatedAt         time.Time  `json:"joined_at"`
+	LastReadMessageID uint       `json:"last_read_message_id"`
+	LastReadAt        *time.Time `json:"last_read_at"`
+	User              *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Message: Body được lưu đã mã hoá khi Encrypted = true và chỉ giải mã khi trả về cho người tham gia
+type Message struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
+	ConversationID uint           `json:"conversation_id" gorm:"index"`
+	SenderID       uint           `json:"sender_id"`
+	Body           string         `json:"body" gorm:"type:text"`
+	Encrypted      bool           `json:"-"`
+	EditedAt       *time.Time     `json:"edited_at"`
+}
+
+func DirectConversationKey(a, b uint) string {
+	if a > b {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d:%d", a, b)
+}
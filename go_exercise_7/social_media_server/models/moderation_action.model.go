@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+const (
+	ModerationHide     = "hide"
+	ModerationUnhide   = "unhide"
+	ModerationDelete   = "delete"
+	ModerationDismiss  = "dismiss"
+	ModerationAutoHide = "auto_hide"
+)
+
+// ModerationAction ghi lại mọi thao tác kiểm duyệt; ModeratorID nil nghĩa là hệ thống tự thực hiện
+type ModerationAction struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+	ModeratorID *uint     `json:"moderator_id" gorm:"index"`
+	Action      string    `json:"action" gorm:"size:16"`
+	TargetType  string    `json:"target_type" gorm:"size:16;index:idx_moderation_actions_target"`
+	TargetID    uint      `json:"target_id" gorm:"index:idx_moderation_actions_target"`
+	ReportID    *uint     `json:"report_id"`
+	Note        string    `json:"note"`
+}
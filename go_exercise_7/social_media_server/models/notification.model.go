@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+const (
+	NotificationComment = "comment"
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+	NotificationFollow  = "follow"
+)
+
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+	UserID    uint       `json:"user_id" gorm:"index:idx_notifications_user_read"`
+	ReadAt    *time.Time `json:"read_at" gorm:"index:idx_notifications_user_read"`
+	Type      string     `json:"type" gorm:"size:16"`
+	ActorID   *uint      `json:"actor_id"`
+	Actor     *User      `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	PostID    *uint      `json:"post_id"`
+	CommentID *uint      `json:"comment_id"`
+}
+
+// NotificationPreference lưu loại thông báo người dùng muốn nhận, chưa có bản ghi thì nhận tất cả
+type NotificationPreference struct {
+	UserID    uint      `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Comments  bool      `json:"comments"`
+	Replies   bool      `json:"replies"`
+	Mentions  bool      `json:"mentions"`
+	Follows   bool      `json:"follows"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func DefaultNotificationPreference(userID uint) NotificationPreference {
+	return NotificationPreference{UserID: userID, Comments: true, Replies: true, Mentions: true, Follows: true}
+}
+
+func (p NotificationPreference) Allows(notificationType string) bool {
+	switch notificationType {
+	case NotificationComment:
+		return p.Comments
+	case NotificationReply:
+		return p.Replies
+	case NotificationMention:
+		return p.Mentions
+	case NotificationFollow:
+		return p.Follows
+	default:
+		return true
+	}
+}
@@ -1,10 +1,78 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"social_media_server/render"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PostDraft     = "draft"
+	PostScheduled = "scheduled"
+	PostPublished = "published"
+	PostArchived  = "archived"
+)
 
 type Post struct {
 	gorm.Model
-	Title    string    `json:"title"`
-	Content  string    `json:"content"`
-	Comments []Comment `json:"comments" gorm:"foreignKey:PostID"` 
-}
\ No newline at end of file
+	Title         string          `json:"title"`
+	Content       string          `json:"content"`
+	ContentFormat string          `json:"content_format" gorm:"size:16;default:plain"`
+	ContentHTML   string          `json:"content_html"`
+	UserID        *uint           `json:"user_id" gorm:"index"`
+	Hidden        bool            `json:"hidden" gorm:"default:false;index"`
+	EditedAt      *time.Time      `json:"edited_at"`
+	Status        string          `json:"status" gorm:"size:16;default:published;index"`
+	PublishAt     *time.Time      `json:"publish_at" gorm:"index"`
+	CommunityID   *uint           `json:"community_id" gorm:"index"`
+	CommentCount  int64           `json:"comment_count" gorm:"not null;default:0;index"`
+	LastCommentAt *time.Time      `json:"last_comment_at" gorm:"index"`
+	Edited        bool            `json:"edited" gorm:"-"`
+	Entities      []render.Entity `json:"entities" gorm:"-"`
+	Reactions     ReactionSummary `json:"reactions" gorm:"-"`
+	Bookmarked    bool            `json:"bookmarked" gorm:"-"`
+	BookmarkCount int64           `json:"bookmark_count" gorm:"-"`
+	Comments      []Comment       `json:"comments" gorm:"foreignKey:PostID"`
+	Attachments   []Attachment    `json:"attachments" gorm:"foreignKey:PostID"`
+}
+
+// BeforeSave render lại HTML mỗi khi nội dung được lưu, client không thể tự gửi content_html
+func (p *Post) BeforeSave(tx *gorm.DB) error {
+	if p.ContentFormat == "" {
+		p.ContentFormat = render.FormatPlain
+	}
+	if p.Status == "" {
+		p.Status = PostPublished
+	}
+	html, err := render.HTML(p.ContentFormat, p.Content)
+	if err != nil {
+		return err
+	}
+	p.ContentHTML = html
+	p.Edited = p.EditedAt != nil
+	p.Entities = render.Entities(p.Content)
+	return nil
+}
+
+// AfterFind render cho các bản ghi cũ được tạo trước khi có cột content_html
+func (p *Post) AfterFind(tx *gorm.DB) error {
+	if p.ContentHTML == "" && p.Content != "" {
+		html, err := render.HTML(p.ContentFormat, p.Content)
+		if err != nil {
+			return err
+		}
+		p.ContentHTML = html
+	}
+	p.Edited = p.EditedAt != nil
+	p.Entities = render.Entities(p.Content)
+	return nil
+}
+
+func ValidPostStatus(status string) bool {
+	switch status {
+	case PostDraft, PostScheduled, PostPublished, PostArchived:
+		return true
+	}
+	return false
+}
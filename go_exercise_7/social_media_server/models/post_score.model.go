@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Các cách sắp xếp danh sách post (?sort=)
+const (
+	SortNew       = "new"
+	SortHot       = "hot"
+	SortTop       = "top"
+	SortDiscussed = "discussed"
+	SortActive    = "active"
+)
+
+// Khoảng thời gian của ?window= cho sort=top và sort=discussed
+const (
+	WindowDay   = "day"
+	WindowWeek  = "week"
+	WindowMonth = "month"
+	WindowAll   = "all"
+)
+
+// PostScore lưu điểm xếp hạng của một post đã xuất bản, được tính lại định kỳ để các danh sách
+// hot/top chỉ cần sắp xếp theo cột có index thay vì đếm vote mỗi request
+type PostScore struct {
+	PostID      uint      `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Score       int64     `json:"score" gorm:"index"`
+	Hot         float64   `json:"hot" gorm:"index"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+}
+
+func ValidPostSort(sort string) bool {
+	switch sort {
+	case SortNew, SortHot, SortTop, SortDiscussed, SortActive:
+		return true
+	}
+	return false
+}
+
+// WindowDuration trả về độ dài của window, 0 với WindowAll; ok là false nếu window không hợp lệ
+func WindowDuration(window string) (d time.Duration, ok bool) {
+	switch window {
+	case WindowDay:
+		return 24 * time.Hour, true
+	case WindowWeek:
+		return 7 * 24 * time.Hour, true
+	case WindowMonth:
+		return 30 * 24 * time.Hour, true
+	case WindowAll:
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+const (
+	VoteUp   = 1
+	VoteDown = -1
+
+	// ReactionCount.Reaction của bộ đếm vote, các giá trị còn lại là emoji
+	CountUpvote   = "+1"
+	CountDownvote = "-1"
+)
+
+// Reaction: mỗi người dùng có tối đa một reaction cho mỗi emoji trên một post/comment
+type Reaction struct {
+	UserID     uint      `json:"user_id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"primaryKey;size:16;index:idx_reactions_target"`
+	TargetID   uint      `json:"target_id" gorm:"primaryKey;index:idx_reactions_target"`
+	Emoji      string    `json:"emoji" gorm:"primaryKey;size:32"`
+	CreatedAt  time.Time `json:"created_at"`
+	User       *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// Vote: mỗi người dùng có tối đa một vote (VoteUp hoặc VoteDown) trên một post/comment
+type Vote struct {
+	UserID     uint      `json:"user_id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"primaryKey;size:16;index:idx_votes_target"`
+	TargetID   uint      `json:"target_id" gorm:"primaryKey;index:idx_votes_target"`
+	Value      int       `json:"value"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ReactionCount là bộ đếm cộng dồn theo post/comment, được tăng/giảm nguyên tử trong cùng transaction
+// với việc thêm/bỏ Reaction hoặc Vote để đọc số lượng không phải đếm lại
+type ReactionCount struct {
+	TargetType string `gorm:"primaryKey;size:16"`
+	TargetID   uint   `gorm:"primaryKey"`
+	Reaction   string `gorm:"primaryKey;size:32"`
+	Count      int64  `gorm:"not null;default:0"`
+}
+
+// ReactionSummary là số reaction/vote trả kèm post và comment. MyVote và MyReactions là của
+// người dùng hiện tại (0 và rỗng khi chưa đăng nhập).
+type ReactionSummary struct {
+	Upvotes     int64            `json:"upvotes"`
+	Downvotes   int64            `json:"downvotes"`
+	Score       int64            `json:"score"`
+	Emoji       map[string]int64 `json:"emoji"`
+	MyVote      int              `json:"my_vote"`
+	MyReactions []string         `json:"my_reactions"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	TargetPost    = "post"
+	TargetComment = "comment"
+
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+type Report struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	ReporterID   *uint      `json:"reporter_id" gorm:"index"`
+	TargetType   string     `json:"target_type" gorm:"size:16;index:idx_reports_target"`
+	TargetID     uint       `json:"target_id" gorm:"index:idx_reports_target"`
+	Reason       string     `json:"reason" gorm:"size:500"`
+	Status       string     `json:"status" gorm:"size:16;default:open;index"`
+	ResolvedByID *uint      `json:"resolved_by_id"`
+	ResolvedAt   *time.Time `json:"resolved_at"`
+}
+
+func ValidTargetType(targetType string) bool {
+	return targetType == TargetPost || targetType == TargetComment
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Revision là một phiên bản nội dung của post hoặc comment; version 1 là bản gốc.
+// Title chỉ có với post. RevertedFrom là version được khôi phục nếu bản này do revert tạo ra.
+type Revision struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time `json:"created_at"`
+	TargetType    string    `json:"target_type" gorm:"size:16;uniqueIndex:idx_revisions_target_version"`
+	TargetID      uint      `json:"target_id" gorm:"uniqueIndex:idx_revisions_target_version"`
+	Version       int       `json:"version" gorm:"uniqueIndex:idx_revisions_target_version"`
+	Title         string    `json:"title,omitempty"`
+	Content       string    `json:"content"`
+	ContentFormat string    `json:"content_format" gorm:"size:16"`
+	EditorID      *uint     `json:"editor_id"`
+	RevertedFrom  *int      `json:"reverted_from"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Tag: hashtag đã viết thường, không kèm dấu #
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `json:"name" gorm:"size:64;uniqueIndex;not null"`
+}
+
+// Tagging gắn tag với một post hoặc comment (TargetType là TargetPost hoặc TargetComment)
+type Tagging struct {
+	TagID      uint      `json:"tag_id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"primaryKey;size:16;index:idx_taggings_target"`
+	TargetID   uint      `json:"target_id" gorm:"primaryKey;index:idx_taggings_target"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Mention: người dùng UserID được @mention trong post hoặc comment
+type Mention struct {
+	UserID     uint      `json:"user_id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"primaryKey;size:16;index:idx_mentions_target"`
+	TargetID   uint      `json:"target_id" gorm:"primaryKey;index:idx_mentions_target"`
+	CreatedAt  time.Time `json:"created_at"`
+}
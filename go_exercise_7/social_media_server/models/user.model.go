@@ -0,0 +1,38 @@
+package models
+
+import (
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,32}$`)
+
+type User struct {
+	gorm.Model
+	Username       string `json:"username" gorm:"size:32;uniqueIndex;not null"`
+	DisplayName    string `json:"display_name"`
+	APIKeyHash     string `json:"-" gorm:"size:64;uniqueIndex"`
+	Role           string `json:"role" gorm:"size:16;default:user"`
+	FollowerCount  int64  `json:"follower_count" gorm:"-"`
+	FollowingCount int64  `json:"following_count" gorm:"-"`
+}
+
+// ValidUsername: 3-32 chữ cái, chữ số hoặc dấu gạch dưới
+func ValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
+func ValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+func (u *User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
@@ -0,0 +1,262 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"social_media_server/deletion"
+	"social_media_server/models"
+	"social_media_server/poststats"
+	"social_media_server/reactions"
+	"social_media_server/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const DefaultHideThreshold = 5
+
+var (
+	ErrTargetNotFound  = errors.New("moderation: reported content not found")
+	ErrAlreadyReported = errors.New("moderation: content already reported by this user")
+	ErrReportClosed    = errors.New("moderation: report is already closed")
+	ErrInvalidAction   = errors.New("moderation: invalid action")
+)
+
+// Moderator gom các thao tác kiểm duyệt để controller và bộ lọc nội dung dùng chung
+type Moderator struct {
+	db            *gorm.DB
+	blobs         storage.BlobStore // để xoá file đính kèm của post bị xoá
+	hideThreshold int
+}
+
+func New(db *gorm.DB, blobs storage.BlobStore, hideThreshold int) *Moderator {
+	if hideThreshold <= 0 {
+		hideThreshold = DefaultHideThreshold
+	}
+	return &Moderator{db: db, blobs: blobs, hideThreshold: hideThreshold}
+}
+
+func targetModel(targetType string) (interface{}, error) {
+	switch targetType {
+	case models.TargetPost:
+		return &models.Post{}, nil
+	case models.TargetComment:
+		return &models.Comment{}, nil
+	default:
+		return nil, fmt.Errorf("moderation: unknown target type %q", targetType)
+	}
+}
+
+func targetExists(tx *gorm.DB, targetType string, targetID uint) error {
+	model, err := targetModel(targetType)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := tx.Model(model).Where("id = ?", targetID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTargetNotFound
+	}
+	return nil
+}
+
+// Report tạo báo cáo mới; reporterID nil là báo cáo do hệ thống tạo (ví dụ bộ lọc nội dung).
+// Khi số người báo cáo đang mở đạt ngưỡng, nội dung bị ẩn tự động.
+func (m *Moderator) Report(ctx context.Context, reporterID *uint, targetType string, targetID uint, reason string) (*models.Report, error) {
+	report := models.Report{
+		ReporterID: reporterID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Status:     models.ReportOpen,
+	}
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := targetExists(tx, targetType, targetID); err != nil {
+			return err
+		}
+
+		if reporterID != nil {
+			var existing int64
+			if err := tx.Model(&models.Report{}).
+				Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status = ?", *reporterID, targetType, targetID, models.ReportOpen).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				return ErrAlreadyReported
+			}
+		}
+
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		return m.autoHide(tx, targetType, targetID, report.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (m *Moderator) autoHide(tx *gorm.DB, targetType string, targetID, reportID uint) error {
+	var reporters int64
+	if err := tx.Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ? AND reporter_id IS NOT NULL", targetType, targetID, models.ReportOpen).
+		Distinct("reporter_id").
+		Count(&reporters).Error; err != nil {
+		return err
+	}
+	if reporters < int64(m.hideThreshold) {
+		return nil
+	}
+
+	changed, err := setHidden(tx, targetType, targetID, true)
+	if err != nil || !changed {
+		return err
+	}
+	return tx.Create(&models.ModerationAction{
+		Action:     models.ModerationAutoHide,
+		TargetType: targetType,
+		TargetID:   targetID,
+		ReportID:   &reportID,
+		Note:       fmt.Sprintf("Hidden automatically after %d reports", reporters),
+	}).Error
+}
+
+// setHidden trả về false nếu nội dung đã ở trạng thái đó rồi
+func setHidden(tx *gorm.DB, targetType string, targetID uint, hidden bool) (bool, error) {
+	model, err := targetModel(targetType)
+	if err != nil {
+		return false, err
+	}
+	result := tx.Model(model).Where("id = ? AND hidden = ?", targetID, !hidden).Update("hidden", hidden)
+	if result.Error != nil || result.RowsAffected == 0 || targetType != models.TargetComment {
+		return result.RowsAffected > 0, result.Error
+	}
+
+	// Comment bị ẩn không được tính vào comment_count của post
+	var comment models.Comment
+	if err := tx.Select("id", "post_id", "created_at").First(&comment, targetID).Error; err != nil {
+		return true, err
+	}
+	if hidden {
+		return true, poststats.CommentRemoved(tx, comment.PostID)
+	}
+	return true, poststats.CommentAdded(tx, comment.PostID, comment.CreatedAt)
+}
+
+// Resolve xử lý một báo cáo: ẩn hoặc xoá nội dung bị báo cáo (đóng mọi báo cáo đang mở của nội dung đó),
+// hoặc bỏ qua riêng báo cáo này.
+func (m *Moderator) Resolve(ctx context.Context, moderatorID uint, reportID uint, action, note string) (*models.Report, error) {
+	var report models.Report
+	var attachments []models.Attachment
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&report, reportID).Error; err != nil {
+			return err
+		}
+		if report.Status != models.ReportOpen {
+			return ErrReportClosed
+		}
+
+		now := time.Now()
+		closeReports := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", report.TargetType, report.TargetID, models.ReportOpen)
+		status := models.ReportResolved
+
+		switch action {
+		case models.ModerationHide:
+			if _, err := setHidden(tx, report.TargetType, report.TargetID, true); err != nil {
+				return err
+			}
+		case models.ModerationDelete:
+			var err error
+			if attachments, err = deleteTarget(tx, report.TargetType, report.TargetID); err != nil {
+				return err
+			}
+		case models.ModerationDismiss:
+			closeReports = tx.Model(&models.Report{}).Where("id = ?", report.ID)
+			status = models.ReportDismissed
+		default:
+			return ErrInvalidAction
+		}
+
+		if err := closeReports.Updates(map[string]interface{}{
+			"status":         status,
+			"resolved_by_id": moderatorID,
+			"resolved_at":    now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.ModerationAction{
+			ModeratorID: &moderatorID,
+			Action:      action,
+			TargetType:  report.TargetType,
+			TargetID:    report.TargetID,
+			ReportID:    &report.ID,
+			Note:        note,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.First(&report, report.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	deletion.Blobs(ctx, m.blobs, attachments)
+	return &report, nil
+}
+
+// SetHidden ẩn/hiện nội dung trực tiếp, không cần báo cáo
+func (m *Moderator) SetHidden(ctx context.Context, moderatorID uint, targetType string, targetID uint, hidden bool, note string) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := targetExists(tx, targetType, targetID); err != nil {
+			return err
+		}
+		changed, err := setHidden(tx, targetType, targetID, hidden)
+		if err != nil || !changed {
+			return err
+		}
+		action := models.ModerationUnhide
+		if hidden {
+			action = models.ModerationHide
+		}
+		return tx.Create(&models.ModerationAction{
+			ModeratorID: &moderatorID,
+			Action:      action,
+			TargetType:  targetType,
+			TargetID:    targetID,
+			Note:        note,
+		}).Error
+	})
+}
+
+// deleteTarget xoá nội dung bị báo cáo giống như khi xoá qua API. Trả về file đính kèm của post
+// bị xoá để xoá blob sau khi transaction commit.
+func deleteTarget(tx *gorm.DB, targetType string, targetID uint) ([]models.Attachment, error) {
+	switch targetType {
+	case models.TargetPost:
+		return deletion.Post(tx, targetID)
+	case models.TargetComment:
+		var comment models.Comment
+		if err := tx.Select("id", "post_id", "hidden").First(&comment, targetID).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&models.Comment{}, targetID).Error; err != nil {
+			return nil, err
+		}
+		if err := reactions.Delete(tx, models.TargetComment, []uint{targetID}); err != nil {
+			return nil, err
+		}
+		if comment.Hidden {
+			return nil, nil
+		}
+		return nil, poststats.CommentRemoved(tx, comment.PostID)
+	default:
+		return nil, fmt.Errorf("moderation: unknown target type %q", targetType)
+	}
+}
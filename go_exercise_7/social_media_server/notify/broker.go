@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"social_media_server/models"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const subscriberBuffer = 16
+
+// Broker chuyển thông báo mới tới các client đang kết nối (SSE) của người nhận
+type Broker interface {
+	Publish(ctx context.Context, n models.Notification) error
+	Subscribe(userID uint) (<-chan models.Notification, func())
+}
+
+// Hub là broker trong bộ nhớ, chỉ phục vụ client kết nối vào cùng instance
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uint]map[chan models.Notification]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[uint]map[chan models.Notification]struct{}{}}
+}
+
+func (h *Hub) Subscribe(userID uint) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[chan models.Notification]struct{}{}
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *Hub) Publish(ctx context.Context, n models.Notification) error {
+	h.deliver(n)
+	return nil
+}
+
+func (h *Hub) deliver(n models.Notification) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for ch := range h.subs[n.UserID] {
+		// Client đọc chậm thì bỏ qua, thông báo vẫn nằm trong DB
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+const redisChannel = "notifications"
+
+// RedisBroker phát thông báo qua Redis pub/sub để client kết nối vào instance nào cũng nhận được
+type RedisBroker struct {
+	rdb *redis.Client
+	hub *Hub
+}
+
+func NewRedisBroker(ctx context.Context, rdb *redis.Client) *RedisBroker {
+	b := &RedisBroker{rdb: rdb, hub: NewHub()}
+	go b.listen(ctx)
+	return b
+}
+
+func (b *RedisBroker) listen(ctx context.Context) {
+	pubsub := b.rdb.Subscribe(ctx, redisChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var n models.Notification
+		if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+			log.Printf("Invalid notification payload from Redis: %v", err)
+			continue
+		}
+		b.hub.deliver(n)
+	}
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, n models.Notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return b.rdb.Publish(ctx, redisChannel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(userID uint) (<-chan models.Notification, func()) {
+	return b.hub.Subscribe(userID)
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"social_media_server/models"
+	"social_media_server/render"
+
+	"gorm.io/gorm"
+)
+
+// Notifier tạo thông báo cho các sự kiện (comment, reply, mention, follow), lưu DB rồi đẩy realtime qua broker
+type Notifier struct {
+	db     *gorm.DB
+	broker Broker
+}
+
+func NewNotifier(db *gorm.DB, broker Broker) *Notifier {
+	return &Notifier{db: db, broker: broker}
+}
+
+func (nf *Notifier) Broker() Broker {
+	return nf.broker
+}
+
+func (nf *Notifier) CommentCreated(ctx context.Context, comment *models.Comment, post *models.Post) error {
+	notified := map[uint]bool{}
+	if comment.UserID != nil {
+		notified[*comment.UserID] = true
+	}
+
+	if comment.ParentID != nil {
+		var parent models.Comment
+		err := nf.db.WithContext(ctx).First(&parent, *comment.ParentID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && parent.UserID != nil && !notified[*parent.UserID] {
+			notified[*parent.UserID] = true
+			if err := nf.send(ctx, *parent.UserID, models.NotificationReply, comment.UserID, &post.ID, &comment.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	if post.UserID != nil && !notified[*post.UserID] {
+		notified[*post.UserID] = true
+		if err := nf.send(ctx, *post.UserID, models.NotificationComment, comment.UserID, &post.ID, &comment.ID); err != nil {
+			return err
+		}
+	}
+
+	return nf.mentioned(ctx, comment.Content, comment.UserID, &post.ID, &comment.ID, notified)
+}
+
+func (nf *Notifier) PostCreated(ctx context.Context, post *models.Post) error {
+	notified := map[uint]bool{}
+	if post.UserID != nil {
+		notified[*post.UserID] = true
+	}
+	return nf.mentioned(ctx, post.Content, post.UserID, &post.ID, nil, notified)
+}
+
+func (nf *Notifier) Followed(ctx context.Context, followerID, followeeID uint) error {
+	return nf.send(ctx, followeeID, models.NotificationFollow, &followerID, nil, nil)
+}
+
+// Mentioned báo cho những người vừa được thêm @mention khi post/comment được sửa
+func (nf *Notifier) Mentioned(ctx context.Context, userIDs []uint, actorID, postID, commentID *uint) error {
+	notified := map[uint]bool{}
+	if actorID != nil {
+		notified[*actorID] = true
+	}
+	return nf.notifyMentions(ctx, userIDs, actorID, postID, commentID, notified)
+}
+
+func (nf *Notifier) mentioned(ctx context.Context, content string, actorID, postID, commentID *uint, notified map[uint]bool) error {
+	usernames := render.Mentions(content)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	var userIDs []uint
+	if err := nf.db.WithContext(ctx).Model(&models.User{}).Where("username IN ?", usernames).Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	return nf.notifyMentions(ctx, userIDs, actorID, postID, commentID, notified)
+}
+
+func (nf *Notifier) notifyMentions(ctx context.Context, userIDs []uint, actorID, postID, commentID *uint, notified map[uint]bool) error {
+	for _, userID := range userIDs {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		if err := nf.send(ctx, userID, models.NotificationMention, actorID, postID, commentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (nf *Notifier) send(ctx context.Context, userID uint, notificationType string, actorID, postID, commentID *uint) error {
+	pref, err := nf.Preference(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !pref.Allows(notificationType) {
+		return nil
+	}
+
+	n := models.Notification{UserID: userID, Type: notificationType, ActorID: actorID, PostID: postID, CommentID: commentID}
+	if err := nf.db.WithContext(ctx).Create(&n).Error; err != nil {
+		return err
+	}
+
+	if actorID != nil {
+		var actor models.User
+		if err := nf.db.WithContext(ctx).First(&actor, *actorID).Error; err == nil {
+			n.Actor = &actor
+		}
+	}
+	return nf.broker.Publish(ctx, n)
+}
+
+func (nf *Notifier) Preference(ctx context.Context, userID uint) (models.NotificationPreference, error) {
+	var prefs []models.NotificationPreference
+	if err := nf.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&prefs).Error; err != nil {
+		return models.NotificationPreference{}, err
+	}
+	if len(prefs) == 0 {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	return prefs[0], nil
+}
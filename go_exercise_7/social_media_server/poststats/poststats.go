@@ -0,0 +1,67 @@
+package poststats
+
+import (
+	"context"
+	"social_media_server/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const recomputeBatch = 500
+
+// CommentAdded cập nhật comment_count và last_comment_at khi một comment hiển thị được thêm hoặc
+// được hiện lại. Phải gọi trong cùng transaction với thay đổi comment.
+func CommentAdded(tx *gorm.DB, postID uint, createdAt time.Time) error {
+	return tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]interface{}{
+		"comment_count": gorm.Expr("comment_count + 1"),
+		"last_comment_at": gorm.Expr("CASE WHEN last_comment_at IS NULL OR last_comment_at < ? THEN ? ELSE last_comment_at END",
+			createdAt, createdAt),
+	}).Error
+}
+
+// CommentRemoved cập nhật post sau khi một comment hiển thị bị xoá hoặc bị ẩn. last_comment_at được
+// tính lại từ các comment còn lại nên phải gọi sau khi đã xoá/ẩn comment trong transaction.
+func CommentRemoved(tx *gorm.DB, postID uint) error {
+	latest := tx.Model(&models.Comment{}).Select("MAX(created_at)").Where("post_id = ? AND hidden = ?", postID, false)
+	return tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(map[string]interface{}{
+		"comment_count":   gorm.Expr("CASE WHEN comment_count > 0 THEN comment_count - 1 ELSE 0 END"),
+		"last_comment_at": gorm.Expr("(?)", latest),
+	}).Error
+}
+
+// Recompute đếm lại comment_count và last_comment_at của các post từ bảng comments
+func Recompute(tx *gorm.DB, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	visible := func(sel string) *gorm.DB {
+		return tx.Model(&models.Comment{}).Select(sel).Where("comments.post_id = posts.id AND comments.hidden = ?", false)
+	}
+	return tx.Model(&models.Post{}).Where("id IN ?", postIDs).UpdateColumns(map[string]interface{}{
+		"comment_count":   gorm.Expr("(?)", visible("COUNT(*)")),
+		"last_comment_at": gorm.Expr("(?)", visible("MAX(comments.created_at)")),
+	}).Error
+}
+
+// RecomputeAll chạy Recompute cho mọi post theo từng lô và trả về số post đã xử lý
+func RecomputeAll(ctx context.Context, db *gorm.DB) (int, error) {
+	db = db.WithContext(ctx)
+	processed := 0
+	var lastID uint
+	for {
+		var ids []uint
+		if err := db.Model(&models.Post{}).Where("id > ?", lastID).
+			Order("id").Limit(recomputeBatch).Pluck("id", &ids).Error; err != nil {
+			return processed, err
+		}
+		if err := Recompute(db, ids); err != nil {
+			return processed, err
+		}
+		processed += len(ids)
+		if len(ids) < recomputeBatch {
+			return processed, nil
+		}
+		lastID = ids[len(ids)-1]
+	}
+}
@@ -0,0 +1,150 @@
+package purge
+
+import (
+	"context"
+	"log"
+	"social_media_server/models"
+	"social_media_server/reactions"
+	"social_media_server/storage"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const batchSize = 200
+
+// Report là số bản ghi đã bị xoá hẳn
+type Report struct {
+	Posts       int `json:"posts"`
+	Comments    int `json:"comments"`
+	Messages    int `json:"messages"`
+	Attachments int `json:"attachments"`
+}
+
+// Run xoá hẳn các post, comment và tin nhắn đã bị xoá mềm trước before, cùng mọi dữ liệu đi kèm
+// (comment của post, file đính kèm, revision, tag, mention, reaction, bookmark, thông báo). Mỗi
+// lô chạy trong một transaction; file trong blobs chỉ bị xoá sau khi lô đó commit, lỗi xoá file
+// chỉ được log như khi xoá post qua API.
+func Run(ctx context.Context, db *gorm.DB, blobs storage.BlobStore, before time.Time) (Report, error) {
+	db = db.WithContext(ctx)
+	var report Report
+
+	// File chỉ được xoá khi transaction của lô đã commit
+	var pending []models.Attachment
+	err := eachBatch(db, &models.Post{}, before, func(tx *gorm.DB, ids []uint) error {
+		var commentIDs []uint
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id IN ?", ids).Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if err := deleteComments(tx, commentIDs); err != nil {
+			return err
+		}
+
+		var attachments []models.Attachment
+		if err := tx.Unscoped().Where("post_id IN ?", ids).Find(&attachments).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("post_id IN ?", ids).Delete(&models.Attachment{}).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.PostScore{}, &models.Bookmark{}, &models.Notification{}} {
+			if err := tx.Where("post_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := deleteTarget(tx, models.TargetPost, ids); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Post{}).Error; err != nil {
+			return err
+		}
+
+		report.Posts += len(ids)
+		report.Comments += len(commentIDs)
+		report.Attachments += len(attachments)
+		pending = attachments
+		return nil
+	}, func() {
+		deleteBlobs(ctx, blobs, pending)
+	})
+	if err != nil {
+		return report, err
+	}
+
+	err = eachBatch(db, &models.Comment{}, before, func(tx *gorm.DB, ids []uint) error {
+		report.Comments += len(ids)
+		return deleteComments(tx, ids)
+	}, nil)
+	if err != nil {
+		return report, err
+	}
+
+	err = eachBatch(db, &models.Message{}, before, func(tx *gorm.DB, ids []uint) error {
+		report.Messages += len(ids)
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Message{}).Error
+	}, nil)
+	return report, err
+}
+
+// eachBatch gọi purge cho từng lô ID của model đã bị xoá mềm trước before, mỗi lô một transaction.
+// Lô đã xoá không còn khớp điều kiện nên luôn đọc lại từ đầu.
+func eachBatch(db *gorm.DB, model interface{}, before time.Time, purge func(tx *gorm.DB, ids []uint) error, committed func()) error {
+	for {
+		var ids []uint
+		if err := db.Unscoped().Model(model).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Order("id").Limit(batchSize).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return purge(tx, ids)
+		}); err != nil {
+			return err
+		}
+		if committed != nil {
+			committed()
+		}
+	}
+}
+
+func deleteComments(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Where("comment_id IN ?", ids).Delete(&models.Notification{}).Error; err != nil {
+		return err
+	}
+	if err := deleteTarget(tx, models.TargetComment, ids); err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error
+}
+
+// deleteTarget xoá dữ liệu gắn với post/comment theo (target_type, target_id)
+func deleteTarget(tx *gorm.DB, targetType string, ids []uint) error {
+	for _, model := range []interface{}{&models.Revision{}, &models.Tagging{}, &models.Mention{}} {
+		if err := tx.Where("target_type = ? AND target_id IN ?", targetType, ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return reactions.Delete(tx, targetType, ids)
+}
+
+func deleteBlobs(ctx context.Context, blobs storage.BlobStore, attachments []models.Attachment) {
+	if blobs == nil {
+		return
+	}
+	for _, a := range attachments {
+		for _, key := range []string{a.StorageKey, a.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+			if err := blobs.Delete(ctx, key); err != nil {
+				log.Printf("Failed to delete blob %s: %v", key, err)
+			}
+		}
+	}
+}
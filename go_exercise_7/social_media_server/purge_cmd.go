@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"social_media_server/config"
+	"social_media_server/purge"
+	"time"
+)
+
+// runPurge xoá hẳn post, comment và tin nhắn đã bị xoá mềm lâu hơn -older-than
+func runPurge(args []string) {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "only purge records deleted at least this long ago")
+	fs.Parse(args)
+
+	if *olderThan < 0 {
+		log.Fatalf("Invalid -older-than %s", *olderThan)
+	}
+
+	config.ConnectDB()
+	config.ConnectStorage()
+
+	before := time.Now().Add(-*olderThan)
+	report, err := purge.Run(context.Background(), maintenanceDB(), config.Storage, before)
+	if err != nil {
+		log.Fatalf("Purge failed: %v (purged so far: %+v)", err, report)
+	}
+	log.Printf("Purged %d posts, %d comments, %d messages and %d attachments deleted before %s",
+		report.Posts, report.Comments, report.Messages, report.Attachments, before.Format(time.RFC3339))
+}
@@ -0,0 +1,158 @@
+package ranking
+
+import (
+	"context"
+	"log"
+	"math"
+	"social_media_server/models"
+	"social_media_server/scheduler"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultInterval = 5 * time.Minute
+	refreshLockKey  = "ranking:refresh"
+	refreshBatch    = 500
+
+	// Mỗi comment được tính bằng nửa upvote trong điểm hot
+	commentWeight = 0.5
+	// Một post mới hơn hotDecay giây được xếp ngang với post cũ hơn có điểm gấp 10 lần
+	hotDecay = 45000
+)
+
+// hotEpoch là mốc thời gian của điểm hot, chỉ để giữ giá trị nhỏ
+var hotEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+// Hot tính điểm hot kiểu Reddit: log10 của điểm (vote + comment) cộng với thời điểm đăng, nên
+// điểm của một post không đổi theo thời gian nhưng post mới luôn được cộng nhiều hơn post cũ
+func Hot(score, comments int64, createdAt time.Time) float64 {
+	points := float64(score) + float64(comments)*commentWeight
+	order := math.Log10(math.Max(math.Abs(points), 1))
+	sign := 0.0
+	if points > 0 {
+		sign = 1
+	} else if points < 0 {
+		sign = -1
+	}
+	return sign*order + float64(createdAt.Unix()-hotEpoch)/hotDecay
+}
+
+// Refresh tính lại điểm của các post trong postIDs từ bộ đếm vote và comment_count của post
+func Refresh(db *gorm.DB, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+
+	var posts []models.Post
+	if err := db.Select("id", "created_at", "comment_count").Where("id IN ? AND status = ?", postIDs, models.PostPublished).
+		Find(&posts).Error; err != nil {
+		return err
+	}
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+
+	var counts []models.ReactionCount
+	if err := db.Where("target_type = ? AND target_id IN ? AND reaction IN ?", models.TargetPost, ids,
+		[]string{models.CountUpvote, models.CountDownvote}).Find(&counts).Error; err != nil {
+		return err
+	}
+	scores := make(map[uint]int64, len(ids))
+	for _, rc := range counts {
+		if rc.Reaction == models.CountUpvote {
+			scores[rc.TargetID] += rc.Count
+		} else {
+			scores[rc.TargetID] -= rc.Count
+		}
+	}
+
+	now := time.Now().UTC()
+	rows := make([]models.PostScore, len(posts))
+	for i, post := range posts {
+		rows[i] = models.PostScore{
+			PostID:      post.ID,
+			Score:       scores[post.ID],
+			Hot:         Hot(scores[post.ID], post.CommentCount, post.CreatedAt),
+			RefreshedAt: now,
+		}
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "hot", "refreshed_at"}),
+	}).Create(&rows).Error
+}
+
+// RefreshAll tính lại điểm của mọi post đã xuất bản theo từng lô và xoá điểm của các post đã bị
+// xoá hoặc không còn ở trạng thái published
+func RefreshAll(ctx context.Context, db *gorm.DB) (int, error) {
+	db = db.WithContext(ctx)
+	refreshed := 0
+	var lastID uint
+	for {
+		var ids []uint
+		if err := db.Model(&models.Post{}).Where("status = ? AND id > ?", models.PostPublished, lastID).
+			Order("id").Limit(refreshBatch).Pluck("id", &ids).Error; err != nil {
+			return refreshed, err
+		}
+		if err := Refresh(db, ids); err != nil {
+			return refreshed, err
+		}
+		refreshed += len(ids)
+		if len(ids) < refreshBatch {
+			break
+		}
+		lastID = ids[len(ids)-1]
+	}
+
+	published := db.Model(&models.Post{}).Select("id").Where("status = ?", models.PostPublished)
+	return refreshed, db.Where("post_id NOT IN (?)", published).Delete(&models.PostScore{}).Error
+}
+
+// Refresher định kỳ chạy RefreshAll. Locker (tuỳ chọn) để chỉ một instance tính lại mỗi lượt.
+type Refresher struct {
+	db       *gorm.DB
+	interval time.Duration
+	locker   scheduler.Locker
+}
+
+func NewRefresher(db *gorm.DB, interval time.Duration, locker scheduler.Locker) *Refresher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Refresher{db: db, interval: interval, locker: locker}
+}
+
+// Run tính lại ngay khi khởi động rồi sau mỗi interval cho tới khi ctx bị huỷ
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.refresh(ctx); err != nil {
+			log.Printf("Refreshing post rankings failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Refresher) refresh(ctx context.Context) error {
+	if r.locker != nil {
+		ok, err := r.locker.TryLock(ctx, refreshLockKey, r.interval)
+		if err != nil || !ok {
+			return err
+		}
+		defer r.locker.Unlock(context.Background(), refreshLockKey)
+	}
+	_, err := RefreshAll(ctx, r.db)
+	return err
+}
@@ -0,0 +1,228 @@
+package reactions
+
+import (
+	"context"
+	"errors"
+	"social_media_server/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxVoteAttempts giới hạn số lần thử lại khi vote bị request khác của cùng người dùng đổi cùng lúc
+const maxVoteAttempts = 3
+
+// ErrConflict: vote vẫn bị thay đổi đồng thời sau maxVoteAttempts lần thử
+var ErrConflict = errors.New("vote was changed concurrently")
+
+var errRetry = errors.New("retry")
+
+// SetVote đặt vote của userID trên post/comment thành value (VoteUp, VoteDown hoặc 0 để bỏ vote)
+// và trả về giá trị vote trước đó (0 nếu chưa vote)
+func SetVote(db *gorm.DB, userID uint, targetType string, targetID uint, value int) (int, error) {
+	for attempt := 0; attempt < maxVoteAttempts; attempt++ {
+		var old int
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			old, err = setVote(tx, userID, targetType, targetID, value)
+			return err
+		})
+		if !errors.Is(err, errRetry) {
+			return old, err
+		}
+	}
+	return 0, ErrConflict
+}
+
+// setVote chỉ đổi bộ đếm khi chính lệnh xoá/thêm Vote có tác dụng, nên hai request đồng thời
+// không thể đếm cùng một vote hai lần. errRetry làm transaction rollback để thử lại từ đầu.
+func setVote(tx *gorm.DB, userID uint, targetType string, targetID uint, value int) (int, error) {
+	var current []models.Vote
+	if err := tx.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Limit(1).Find(&current).Error; err != nil {
+		return 0, err
+	}
+	old := 0
+	if len(current) > 0 {
+		old = current[0].Value
+	}
+	if old == value {
+		return old, nil
+	}
+
+	if old != 0 {
+		res := tx.Where("user_id = ? AND target_type = ? AND target_id = ? AND value = ?", userID, targetType, targetID, old).
+			Delete(&models.Vote{})
+		if res.Error != nil {
+			return old, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return old, errRetry
+		}
+		if err := decrement(tx, targetType, targetID, voteCounter(old)); err != nil {
+			return old, err
+		}
+	}
+	if value != 0 {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Vote{UserID: userID, TargetType: targetType, TargetID: targetID, Value: value})
+		if res.Error != nil {
+			return old, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return old, errRetry
+		}
+		if err := increment(tx, targetType, targetID, voteCounter(value)); err != nil {
+			return old, err
+		}
+	}
+	return old, nil
+}
+
+// React thêm reaction emoji của userID. Trả về false nếu người dùng đã react emoji này rồi.
+func React(db *gorm.DB, userID uint, targetType string, targetID uint, emoji string) (bool, error) {
+	added := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.Reaction{UserID: userID, TargetType: targetType, TargetID: targetID, Emoji: emoji})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		added = true
+		return increment(tx, targetType, targetID, emoji)
+	})
+	return added, err
+}
+
+// Unreact bỏ reaction emoji của userID. Trả về false nếu người dùng chưa react emoji này.
+func Unreact(db *gorm.DB, userID uint, targetType string, targetID uint, emoji string) (bool, error) {
+	removed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND target_type = ? AND target_id = ? AND emoji = ?", userID, targetType, targetID, emoji).
+			Delete(&models.Reaction{})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		removed = true
+		return decrement(tx, targetType, targetID, emoji)
+	})
+	return removed, err
+}
+
+// Summaries đọc số reaction/vote của nhiều post/comment cùng loại bằng một query bộ đếm, cộng
+// thêm hai query vote/reaction của viewerID nếu có (0 là khách)
+func Summaries(db *gorm.DB, targetType string, ids []uint, viewerID uint) (map[uint]models.ReactionSummary, error) {
+	summaries := make(map[uint]models.ReactionSummary, len(ids))
+	for _, id := range ids {
+		summaries[id] = models.ReactionSummary{Emoji: map[string]int64{}, MyReactions: []string{}}
+	}
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	var counts []models.ReactionCount
+	if err := db.Where("target_type = ? AND target_id IN ? AND count > 0", targetType, ids).
+		Find(&counts).Error; err != nil {
+		return nil, err
+	}
+	for _, rc := range counts {
+		s := summaries[rc.TargetID]
+		switch rc.Reaction {
+		case models.CountUpvote:
+			s.Upvotes = rc.Count
+		case models.CountDownvote:
+			s.Downvotes = rc.Count
+		default:
+			s.Emoji[rc.Reaction] = rc.Count
+		}
+		s.Score = s.Upvotes - s.Downvotes
+		summaries[rc.TargetID] = s
+	}
+
+	if viewerID == 0 {
+		return summaries, nil
+	}
+	var votes []models.Vote
+	if err := db.Where("user_id = ? AND target_type = ? AND target_id IN ?", viewerID, targetType, ids).
+		Find(&votes).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range votes {
+		s := summaries[v.TargetID]
+		s.MyVote = v.Value
+		summaries[v.TargetID] = s
+	}
+	var mine []models.Reaction
+	if err := db.Where("user_id = ? AND target_type = ? AND target_id IN ?", viewerID, targetType, ids).
+		Order("created_at, emoji").Find(&mine).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range mine {
+		s := summaries[r.TargetID]
+		s.MyReactions = append(s.MyReactions, r.Emoji)
+		summaries[r.TargetID] = s
+	}
+	return summaries, nil
+}
+
+// Delete xoá toàn bộ reaction, vote và bộ đếm của một post/comment đã bị xoá
+func Delete(tx *gorm.DB, targetType string, targetIDs []uint) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+	for _, model := range []interface{}{&models.Reaction{}, &models.Vote{}, &models.ReactionCount{}} {
+		if err := tx.Where("target_type = ? AND target_id IN ?", targetType, targetIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func voteCounter(value int) string {
+	if value == models.VoteUp {
+		return models.CountUpvote
+	}
+	return models.CountDownvote
+}
+
+// increment tăng bộ đếm bằng một câu upsert để các request đồng thời không ghi đè lên nhau
+func increment(tx *gorm.DB, targetType string, targetID uint, reaction string) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "target_type"}, {Name: "target_id"}, {Name: "reaction"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("reaction_counts.count + 1")}),
+	}).Create(&models.ReactionCount{TargetType: targetType, TargetID: targetID, Reaction: reaction, Count: 1}).Error
+}
+
+func decrement(tx *gorm.DB, targetType string, targetID uint, reaction string) error {
+	return tx.Model(&models.ReactionCount{}).
+		Where("target_type = ? AND target_id = ? AND reaction = ? AND count > 0", targetType, targetID, reaction).
+		Update("count", gorm.Expr("count - 1")).Error
+}
+
+// RecomputeAll dựng lại toàn bộ reaction_counts từ bảng reactions và votes, dùng khi bộ đếm bị
+// lệch. Chạy trong một transaction nên người đọc không thấy bộ đếm rỗng giữa chừng.
+func RecomputeAll(ctx context.Context, db *gorm.DB) (int64, error) {
+	var rows int64
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ReactionCount{}).Error; err != nil {
+			return err
+		}
+		sources := []*gorm.DB{
+			tx.Model(&models.Reaction{}).Select("target_type, target_id, emoji, COUNT(*)").
+				Group("target_type, target_id, emoji"),
+			tx.Model(&models.Vote{}).Select("target_type, target_id, ?, COUNT(*)", models.CountUpvote).
+				Where("value = ?", models.VoteUp).Group("target_type, target_id"),
+			tx.Model(&models.Vote{}).Select("target_type, target_id, ?, COUNT(*)", models.CountDownvote).
+				Where("value = ?", models.VoteDown).Group("target_type, target_id"),
+		}
+		for _, source := range sources {
+			res := tx.Exec("INSERT INTO reaction_counts (target_type, target_id, reaction, count) ?", source)
+			if res.Error != nil {
+				return res.Error
+			}
+			rows += res.RowsAffected
+		}
+		return nil
+	})
+	return rows, err
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"social_media_server/config"
+	"social_media_server/poststats"
+	"social_media_server/ranking"
+	"social_media_server/reactions"
+	"social_media_server/store"
+	"social_media_server/tagging"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Các phần dữ liệu dẫn xuất mà lệnh rebuild dựng lại, theo thứ tự chạy: điểm xếp hạng tính từ
+// số comment và vote nên chạy sau cùng
+var rebuildSteps = []struct {
+	name, summary string
+	run           func(ctx context.Context) (int64, error)
+}{
+	{"tags", "hashtags and mentions of posts and comments", func(ctx context.Context) (int64, error) {
+		n, err := tagging.RebuildAll(ctx, maintenanceDB())
+		return int64(n), err
+	}},
+	{"comments", "comment counts and last comment times of posts", func(ctx context.Context) (int64, error) {
+		n, err := poststats.RecomputeAll(ctx, maintenanceDB())
+		return int64(n), err
+	}},
+	{"reactions", "vote and emoji reaction counters", func(ctx context.Context) (int64, error) {
+		return reactions.RecomputeAll(ctx, maintenanceDB())
+	}},
+	{"rankings", "hot and top scores of published posts", func(ctx context.Context) (int64, error) {
+		n, err := ranking.RefreshAll(ctx, maintenanceDB())
+		return int64(n), err
+	}},
+}
+
+// runRebuild dựng lại chỉ mục tag/mention và các bộ đếm được lưu sẵn từ dữ liệu gốc
+func runRebuild(args []string) {
+	names := make([]string, len(rebuildSteps))
+	for i, step := range rebuildSteps {
+		names[i] = step.name
+	}
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	only := fs.String("only", strings.Join(names, ","), "comma-separated steps to run: "+strings.Join(names, ", "))
+	fs.Parse(args)
+
+	selected := make(map[string]bool)
+	for _, name := range strings.Split(*only, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, step := range rebuildSteps {
+			known = known || step.name == name
+		}
+		if !known {
+			log.Fatalf("Unknown rebuild step %q (available: %s)", name, strings.Join(names, ", "))
+		}
+		selected[name] = true
+	}
+
+	config.ConnectDB()
+
+	ctx := context.Background()
+	for _, step := range rebuildSteps {
+		if !selected[step.name] {
+			continue
+		}
+		n, err := step.run(ctx)
+		if err != nil {
+			log.Fatalf("Rebuilding %s failed after %d rows: %v", step.summary, n, err)
+		}
+		log.Printf("Rebuilt %s (%d rows)", step.summary, n)
+	}
+}
+
+// maintenanceDB bỏ DB_QUERY_TIMEOUT cho các lệnh bảo trì, vốn có câu lệnh chạy trên cả bảng
+func maintenanceDB() *gorm.DB {
+	return store.WithoutTimeout(config.DB)
+}
@@ -0,0 +1,95 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	EntityMention = "mention"
+	EntityHashtag = "hashtag"
+
+	MaxHashtagLength = 64
+)
+
+var (
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_]{3,32})\b`)
+	// Hashtag nhận chữ Unicode (ví dụ #hàNội) và phải có ít nhất một chữ cái, nên "#1" không phải hashtag
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#&])#([\p{L}\p{N}_]+)`)
+)
+
+// Entity là một @mention hoặc #hashtag trong nội dung. Start/End là vị trí tính theo ký tự Unicode
+// (code point) của cả cụm kể cả dấu @/#, End không tính; Text là username, hoặc tag đã viết thường.
+type Entity struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Entities trả về các mention và hashtag theo thứ tự xuất hiện trong nội dung
+func Entities(content string) []Entity {
+	entities := []Entity{}
+	mentions := mentionPattern.FindAllStringSubmatchIndex(content, -1)
+	hashtags := hashtagPattern.FindAllStringSubmatchIndex(content, -1)
+	for len(mentions) > 0 || len(hashtags) > 0 {
+		if len(hashtags) == 0 || (len(mentions) > 0 && mentions[0][2] < hashtags[0][2]) {
+			m := mentions[0]
+			mentions = mentions[1:]
+			entities = append(entities, newEntity(content, EntityMention, content[m[2]:m[3]], m[2]-1, m[3]))
+			continue
+		}
+		h := hashtags[0]
+		hashtags = hashtags[1:]
+		tag := content[h[2]:h[3]]
+		if utf8.RuneCountInString(tag) > MaxHashtagLength || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+			continue
+		}
+		entities = append(entities, newEntity(content, EntityHashtag, strings.ToLower(tag), h[2]-1, h[3]))
+	}
+	return entities
+}
+
+func newEntity(content, entityType, text string, start, end int) Entity {
+	runeStart := utf8.RuneCountInString(content[:start])
+	return Entity{
+		Type:  entityType,
+		Text:  text,
+		Start: runeStart,
+		End:   runeStart + utf8.RuneCountInString(content[start:end]),
+	}
+}
+
+// Mentions trả về các username được @mention trong nội dung, không trùng lặp
+func Mentions(content string) []string {
+	return distinct(Entities(content), EntityMention)
+}
+
+// Hashtags trả về các tag (viết thường, không kèm #) trong nội dung, không trùng lặp
+func Hashtags(content string) []string {
+	return distinct(Entities(content), EntityHashtag)
+}
+
+func distinct(entities []Entity, entityType string) []string {
+	seen := map[string]bool{}
+	var values []string
+	for _, e := range entities {
+		if e.Type == entityType && !seen[e.Text] {
+			seen[e.Text] = true
+			values = append(values, e.Text)
+		}
+	}
+	return values
+}
+
+// NormalizeHashtag chuẩn hoá tag người dùng nhập (có thể kèm #) về dạng lưu trong DB; trả về "" nếu không hợp lệ
+func NormalizeHashtag(tag string) string {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	entities := Entities("#" + tag)
+	if len(entities) != 1 || entities[0].Type != EntityHashtag || entities[0].End != utf8.RuneCountInString(tag)+1 {
+		return ""
+	}
+	return entities[0].Text
+}
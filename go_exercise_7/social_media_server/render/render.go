@@ -0,0 +1,96 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+var (
+	// Không bật html.WithUnsafe nên raw HTML trong markdown sẽ bị bỏ ngay từ bước render
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(goldmarkhtml.WithHardWraps()),
+	)
+
+	policy = newPolicy()
+
+	urlPattern = regexp.MustCompile(`\bhttps?://[^\s<>"']+[^\s<>"'.,;:!?)\]]`)
+)
+
+// newPolicy là allowlist dùng chung cho mọi nội dung do người dùng nhập.
+// Link bị gắn rel="nofollow noopener" và link tuyệt đối được mở ở tab mới.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+func ValidFormat(format string) bool {
+	return format == FormatPlain || format == FormatMarkdown
+}
+
+// HTML render nội dung theo định dạng rồi sanitize, kết quả an toàn để client chèn thẳng vào DOM.
+func HTML(format, source string) (string, error) {
+	switch format {
+	case FormatPlain, "":
+		return policy.Sanitize(plainToHTML(source)), nil
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := markdown.Convert([]byte(source), &buf); err != nil {
+			return "", err
+		}
+		return policy.Sanitize(buf.String()), nil
+	default:
+		return "", fmt.Errorf("render: unknown content format %q", format)
+	}
+}
+
+// plainToHTML escape text thường, tách đoạn theo dòng trống và tự nhận diện link.
+func plainToHTML(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(source, "\n\n") {
+		paragraph = strings.Trim(paragraph, "\n")
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		for i, line := range strings.Split(paragraph, "\n") {
+			if i > 0 {
+				b.WriteString("<br>")
+			}
+			b.WriteString(linkify(line))
+		}
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+func linkify(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range urlPattern.FindAllStringIndex(line, -1) {
+		b.WriteString(html.EscapeString(line[last:m[0]]))
+		link := html.EscapeString(line[m[0]:m[1]])
+		fmt.Fprintf(&b, `<a href="%s">%s</a>`, link, link)
+		last = m[1]
+	}
+	b.WriteString(html.EscapeString(line[last:]))
+	return b.String()
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"social_media_server/config"
+	"social_media_server/poststats"
+)
+
+// runRepairCounts đếm lại comment_count và last_comment_at của mọi post, dùng khi số liệu bị lệch
+// (ví dụ sau khi sửa dữ liệu trực tiếp trong database)
+func runRepairCounts(args []string) {
+	fs := flag.NewFlagSet("repair-counts", flag.ExitOnError)
+	fs.Parse(args)
+
+	config.ConnectDB()
+
+	count, err := poststats.RecomputeAll(context.Background(), maintenanceDB())
+	if err != nil {
+		log.Fatalf("Repair failed after %d posts: %v", count, err)
+	}
+	log.Printf("Recomputed comment counts of %d posts", count)
+}
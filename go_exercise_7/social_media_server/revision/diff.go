@@ -0,0 +1,212 @@
+package revision
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContext = 3
+	// myers giữ lại trạng thái của từng bước nên bộ nhớ tăng theo bình phương số dòng khác nhau;
+	// văn bản dài hơn giới hạn này được diff thô (xoá hết rồi thêm lại)
+	maxDiffLines = 2000
+)
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+// edit là một dòng trong kết quả diff; a và b là vị trí dòng (tính từ 0) trong hai văn bản
+type edit struct {
+	kind opKind
+	a, b int
+	text string
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Unified trả về unified diff giữa hai văn bản, rỗng nếu giống nhau
+func Unified(fromName, toName, from, to string) string {
+	a, b := splitLines(from), splitLines(to)
+	edits := diffLines(a, b)
+
+	hunks := groupHunks(edits)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks {
+		writeHunk(&out, h)
+	}
+	return out.String()
+}
+
+func diffLines(a, b []string) []edit {
+	if len(a)+len(b) > maxDiffLines {
+		var edits []edit
+		for i, line := range a {
+			edits = append(edits, edit{kind: opDelete, a: i, b: 0, text: line})
+		}
+		for j, line := range b {
+			edits = append(edits, edit{kind: opInsert, a: len(a), b: j, text: line})
+		}
+		return edits
+	}
+	return myers(a, b)
+}
+
+// myers cài đặt thuật toán diff O((N+M)D) của Eugene Myers, dò ngược lại để lấy danh sách thao tác
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] là các phần tử k trong [-d, d] của v trước bước d
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vd := trace[d]
+		at := func(k int) int { return vd[k+d] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{kind: opEqual, a: x, b: y, text: a[x]})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				edits = append(edits, edit{kind: opInsert, a: x, b: y, text: b[y]})
+			} else {
+				x--
+				edits = append(edits, edit{kind: opDelete, a: x, b: y, text: a[x]})
+			}
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// groupHunks gom các thay đổi gần nhau (cách nhau không quá 2*diffContext dòng) thành hunk
+func groupHunks(edits []edit) [][]edit {
+	var hunks [][]edit
+	start, end := -1, -1
+	for i, e := range edits {
+		if e.kind == opEqual {
+			continue
+		}
+		lo := i - diffContext
+		if lo < 0 {
+			lo = 0
+		}
+		if start >= 0 && lo > end {
+			hunks = append(hunks, edits[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = lo
+		}
+		end = i + diffContext + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+	}
+	if start >= 0 {
+		hunks = append(hunks, edits[start:end])
+	}
+	return hunks
+}
+
+func writeHunk(out *strings.Builder, h []edit) {
+	var aCount, bCount int
+	for _, e := range h {
+		if e.kind != opInsert {
+			aCount++
+		}
+		if e.kind != opDelete {
+			bCount++
+		}
+	}
+	aStart, bStart := h[0].a+1, h[0].b+1
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+	for _, e := range h {
+		switch e.kind {
+		case opEqual:
+			out.WriteByte(' ')
+		case opDelete:
+			out.WriteByte('-')
+		case opInsert:
+			out.WriteByte('+')
+		}
+		out.WriteString(e.text)
+		out.WriteByte('\n')
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
@@ -0,0 +1,111 @@
+package revision
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numbered trả về các dòng "l1".."ln", mỗi dòng kết thúc bằng xuống dòng
+func numbered(n int, replace map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if line, ok := replace[i]; ok {
+			b.WriteString(line)
+		} else {
+			fmt.Fprintf(&b, "l%d\n", i)
+		}
+	}
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		want     string
+	}{
+		{"identical", "a\nb\nc\n", "a\nb\nc\n", ""},
+		{"both empty", "", "", ""},
+		{"trailing newline only", "a\nb", "a\nb\n", ""},
+		{"insert into empty", "", "a\nb\n", "@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"delete everything", "a\nb\n", "", "@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{
+			"pure insertion",
+			numbered(6, nil),
+			numbered(6, map[int]string{3: "l3\nx\n"}),
+			"@@ -1,6 +1,7 @@\n l1\n l2\n l3\n+x\n l4\n l5\n l6\n",
+		},
+		{
+			"pure deletion",
+			numbered(8, nil),
+			numbered(8, map[int]string{4: ""}),
+			"@@ -1,7 +1,6 @@\n l1\n l2\n l3\n-l4\n l5\n l6\n l7\n",
+		},
+		{
+			"change at start",
+			numbered(8, nil),
+			numbered(8, map[int]string{1: "first\n"}),
+			"@@ -1,4 +1,4 @@\n-l1\n+first\n l2\n l3\n l4\n",
+		},
+		{
+			"change at end",
+			numbered(8, nil),
+			numbered(8, map[int]string{8: "last\n"}),
+			"@@ -5,4 +5,4 @@\n l5\n l6\n l7\n-l8\n+last\n",
+		},
+		{
+			"single line",
+			"a\n",
+			"b\n",
+			"@@ -1 +1 @@\n-a\n+b\n",
+		},
+		{
+			"two hunks",
+			numbered(20, nil),
+			numbered(20, map[int]string{2: "two\n", 18: "eighteen\n"}),
+			"@@ -1,5 +1,5 @@\n l1\n-l2\n+two\n l3\n l4\n l5\n" +
+				"@@ -15,6 +15,6 @@\n l15\n l16\n l17\n-l18\n+eighteen\n l19\n l20\n",
+		},
+		{
+			// khoảng cách không quá 2*diffContext dòng thì gộp chung một hunk
+			"close changes share a hunk",
+			numbered(12, nil),
+			numbered(12, map[int]string{2: "two\n", 9: "nine\n"}),
+			"@@ -1,12 +1,12 @@\n l1\n-l2\n+two\n l3\n l4\n l5\n l6\n l7\n l8\n-l9\n+nine\n l10\n l11\n l12\n",
+		},
+		{
+			"insertion and deletion in separate hunks",
+			numbered(16, nil),
+			numbered(16, map[int]string{1: "l0\nl1\n", 16: ""}),
+			"@@ -1,3 +1,4 @@\n+l0\n l1\n l2\n l3\n" +
+				"@@ -13,4 +14,3 @@\n l13\n l14\n l15\n-l16\n",
+		},
+	}
+	for _, tt := range tests {
+		got := Unified("old", "new", tt.from, tt.to)
+		want := tt.want
+		if want != "" {
+			want = "--- old\n+++ new\n" + want
+		}
+		if got != want {
+			t.Errorf("%s: Unified() =\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
+
+// Văn bản vượt maxDiffLines được diff thô nhưng vẫn phải là một hunk hợp lệ
+func TestUnifiedLargeInput(t *testing.T) {
+	from := numbered(maxDiffLines, nil)
+	to := numbered(maxDiffLines, map[int]string{1: "first\n"})
+	got := Unified("old", "new", from, to)
+
+	header := fmt.Sprintf("--- old\n+++ new\n@@ -1,%d +1,%d @@\n", maxDiffLines, maxDiffLines)
+	if !strings.HasPrefix(got, header) {
+		t.Fatalf("Unified() starts with %q, want %q", got[:min(len(got), len(header))], header)
+	}
+	lines := strings.Split(strings.TrimSuffix(strings.TrimPrefix(got, header), "\n"), "\n")
+	if len(lines) != 2*maxDiffLines || lines[0] != "-l1" || lines[maxDiffLines] != "+first" {
+		t.Fatalf("Unified() has %d body lines, first %q, want %d", len(lines), lines[0], 2*maxDiffLines)
+	}
+}
@@ -0,0 +1,73 @@
+package revision
+
+import (
+	"social_media_server/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FromPost tạo revision (chưa có version) từ trạng thái hiện tại của post
+func FromPost(post *models.Post) models.Revision {
+	return models.Revision{
+		TargetType:    models.TargetPost,
+		TargetID:      post.ID,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+	}
+}
+
+func FromComment(comment *models.Comment) models.Revision {
+	return models.Revision{
+		TargetType:    models.TargetComment,
+		TargetID:      comment.ID,
+		Content:       comment.Content,
+		ContentFormat: comment.ContentFormat,
+	}
+}
+
+// SameContent cho biết hai revision có cùng nội dung hay không (bỏ qua metadata)
+func SameContent(a, b models.Revision) bool {
+	return a.Title == b.Title && a.Content == b.Content && a.ContentFormat == b.ContentFormat
+}
+
+// Document là văn bản dùng để diff: với post, dòng đầu là tiêu đề
+func Document(rev models.Revision) string {
+	if rev.TargetType == models.TargetPost {
+		return rev.Title + "\n\n" + rev.Content
+	}
+	return rev.Content
+}
+
+// EnsureInitial ghi version 1 cho nội dung tạo ra trước khi có lịch sử sửa (hoặc được import),
+// dùng trạng thái trước khi sửa, người tạo và thời điểm tạo gốc.
+func EnsureInitial(tx *gorm.DB, original models.Revision, authorID *uint, createdAt time.Time) error {
+	var count int64
+	if err := tx.Model(&models.Revision{}).
+		Where("target_type = ? AND target_id = ?", original.TargetType, original.TargetID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	original.Version = 1
+	original.EditorID = authorID
+	original.CreatedAt = createdAt
+	return tx.Create(&original).Error
+}
+
+// Append ghi revision mới với version kế tiếp. Unique index (target, version) khiến hai lần sửa
+// đồng thời không thể cùng lấy một version.
+func Append(tx *gorm.DB, rev *models.Revision) error {
+	var latest int
+	if err := tx.Model(&models.Revision{}).
+		Where("target_type = ? AND target_id = ?", rev.TargetType, rev.TargetID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return err
+	}
+	rev.Version = latest + 1
+	return tx.Create(rev).Error
+}
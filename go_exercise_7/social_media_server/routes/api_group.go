@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Overrides thay handler cuối của một route khi đăng ký cho phiên bản API mới. Key có dạng
+// "METHOD /path" (path tính từ gốc của phiên bản, ví dụ "GET /posts/:id"); handler nil bỏ hẳn route đó.
+// Middleware của route và của group (RequireAuth, Idempotent...) vẫn được giữ nguyên.
+type Overrides map[string]gin.HandlerFunc
+
+// apiGroup bọc gin.RouterGroup để một hàm đăng ký route (như registerV1) dùng lại được cho phiên bản
+// sau, chỉ thay những route có hợp đồng thay đổi
+type apiGroup struct {
+	rg        *gin.RouterGroup
+	prefix    string
+	overrides Overrides
+	used      map[string]bool
+}
+
+func newAPIGroup(rg *gin.RouterGroup, overrides Overrides) *apiGroup {
+	return &apiGroup{rg: rg, prefix: "/", overrides: overrides, used: map[string]bool{}}
+}
+
+func (g *apiGroup) Group(relativePath string, handlers ...gin.HandlerFunc) *apiGroup {
+	return &apiGroup{
+		rg:        g.rg.Group(relativePath, handlers...),
+		prefix:    path.Join(g.prefix, relativePath),
+		overrides: g.overrides,
+		used:      g.used,
+	}
+}
+
+func (g *apiGroup) handle(method, relativePath string, handlers []gin.HandlerFunc) {
+	key := method + " " + path.Join(g.prefix, relativePath)
+	if handler, ok := g.overrides[key]; ok {
+		g.used[key] = true
+		if handler == nil {
+			return
+		}
+		handlers = append(handlers[:len(handlers)-1:len(handlers)-1], handler)
+	}
+	g.rg.Handle(method, relativePath, handlers...)
+}
+
+func (g *apiGroup) GET(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodGet, relativePath, handlers)
+}
+
+func (g *apiGroup) POST(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPost, relativePath, handlers)
+}
+
+func (g *apiGroup) PUT(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodPut, relativePath, handlers)
+}
+
+func (g *apiGroup) DELETE(relativePath string, handlers ...gin.HandlerFunc) {
+	g.handle(http.MethodDelete, relativePath, handlers)
+}
+
+// checkOverrides báo lỗi khi có override không khớp route nào (thường do gõ sai path)
+func (g *apiGroup) checkOverrides() error {
+	var unknown []string
+	for key := range g.overrides {
+		if !g.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("overrides for unknown routes: %v", unknown)
+	}
+	return nil
+}
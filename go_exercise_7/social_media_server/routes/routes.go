@@ -1,8 +1,11 @@
 package routes
 
 import (
+	"log"
+	appconfig "social_media_server/config"
 	"social_media_server/controllers"
-	"time"
+	"social_media_server/middleware"
+	"social_media_server/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -12,33 +15,212 @@ func SetupRouter() *gin.Engine {
 	router := gin.Default()
 	router.RedirectTrailingSlash = false 
 
+	security := appconfig.LoadHTTPSecurity()
+	router.Use(middleware.SecurityHeaders(middleware.SecurityHeaderConfig{
+		HSTSMaxAge:            security.HSTSMaxAge,
+		HSTSIncludeSubdomains: security.HSTSIncludeSubdomains,
+		ReferrerPolicy:        security.ReferrerPolicy,
+		ContentSecurityPolicy: security.ContentSecurityPolicy,
+		SwaggerSecurityPolicy: security.SwaggerSecurityPolicy,
+	}))
+
 	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:5173"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
-	config.AllowCredentials = true
-	config.MaxAge = 12 * time.Hour
+	if security.AllowAllOrigins() {
+		config.AllowAllOrigins = true
+	} else {
+		config.AllowOriginFunc = security.OriginAllowed
+	}
+	config.AllowMethods = security.AllowMethods
+	config.AllowHeaders = append(security.AllowHeaders, middleware.IdempotencyKeyHeader)
+	config.ExposeHeaders = []string{"Content-Length", middleware.RequestIDHeader, middleware.IdempotentReplayedHeader}
+	config.AllowCredentials = security.AllowCredentials
+	config.MaxAge = security.CORSMaxAge
 	router.Use(cors.New(config))
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Authenticate())
+
+	for _, version := range Versions {
+		group := router.Group("/api/" + version.Name)
+		if !version.DeprecatedAt.IsZero() {
+			group.Use(middleware.Deprecation(version.deprecation()))
+		}
+		api := newAPIGroup(group, version.Overrides)
+		registerV1(api)
+		if version.Register != nil {
+			version.Register(api)
+		}
+		if err := api.checkOverrides(); err != nil {
+			log.Fatalf("API %s: %v", version.Name, err)
+		}
+	}
+
+	// Các route cũ không có tiền tố phiên bản vẫn phục vụ hợp đồng v1 cho client hiện tại
+	legacy := router.Group("", middleware.Deprecation(legacyDeprecation()))
+	registerV1(newAPIGroup(legacy, nil))
 
+	return router
+}
+
+// registerV1 đăng ký các route của hợp đồng v1. Phiên bản sau dùng lại hàm này và chỉ thay
+// những route thay đổi qua Version.Overrides.
+func registerV1(api *apiGroup) {
 	postController := controllers.NewPostController()
 	commentController := controllers.NewCommentController()
+	attachmentController := controllers.NewAttachmentController()
+	transferController := controllers.NewTransferController()
+	userController := controllers.NewUserController()
+	feedController := controllers.NewFeedController()
+	notificationController := controllers.NewNotificationController()
+	moderationController := controllers.NewModerationController()
+	auditController := controllers.NewAuditController()
+	revisionController := controllers.NewRevisionController()
+	communityController := controllers.NewCommunityController()
+	messageController := controllers.NewMessageController()
+	tagController := controllers.NewTagController()
+	reactionController := controllers.NewReactionController()
+	bookmarkController := controllers.NewBookmarkController()
 
-	postRoutes := router.Group("/posts")
+	postRoutes := api.Group("/posts")
 	{
 		postRoutes.GET("", postController.GetPosts)        
-		postRoutes.POST("", postController.CreatePost)       
+		postRoutes.POST("", middleware.Idempotent(), postController.CreatePost)       
 		postRoutes.GET("/:id", postController.GetPost)   
 		postRoutes.PUT("/:id", postController.UpdatePost)
 		postRoutes.DELETE("/:id", postController.DeletePost) 
+		postRoutes.GET("/:id/comments", commentController.GetPostComments)
+		postRoutes.POST("/:id/attachments", attachmentController.UploadAttachment)
+		postRoutes.GET("/:id/attachments", attachmentController.GetAttachments)
+		postRoutes.GET("/:id/revisions", revisionController.GetPostRevisions)
+		postRoutes.GET("/:id/revisions/diff", revisionController.DiffPostRevisions)
+		postRoutes.GET("/:id/revisions/:version", revisionController.GetPostRevision)
+		postRoutes.POST("/:id/revisions/:version/revert", revisionController.RevertPost)
+		postRoutes.PUT("/:id/vote", middleware.RequireAuth(), reactionController.VotePost)
+		postRoutes.GET("/:id/reactions", reactionController.GetPostReactions)
+		postRoutes.POST("/:id/reactions", middleware.RequireAuth(), reactionController.ReactPost)
+		postRoutes.DELETE("/:id/reactions/:emoji", middleware.RequireAuth(), reactionController.UnreactPost)
+		postRoutes.POST("/:id/bookmark", middleware.RequireAuth(), bookmarkController.BookmarkPost)
+		postRoutes.DELETE("/:id/bookmark", middleware.RequireAuth(), bookmarkController.UnbookmarkPost)
 	}
 
-	commentRoutes := router.Group("/comments")
+	commentRoutes := api.Group("/comments")
 	{
-		commentRoutes.POST("", commentController.CreateComment) 
+		commentRoutes.POST("", middleware.Idempotent(), commentController.CreateComment) 
+		commentRoutes.GET("/:id", commentController.GetComment)
 		commentRoutes.PUT("/:id", commentController.UpdateComment)
 		commentRoutes.DELETE("/:id", commentController.DeleteComment)
+		commentRoutes.GET("/:id/revisions", revisionController.GetCommentRevisions)
+		commentRoutes.GET("/:id/revisions/diff", revisionController.DiffCommentRevisions)
+		commentRoutes.GET("/:id/revisions/:version", revisionController.GetCommentRevision)
+		commentRoutes.POST("/:id/revisions/:version/revert", revisionController.RevertComment)
+		commentRoutes.PUT("/:id/vote", middleware.RequireAuth(), reactionController.VoteComment)
+		commentRoutes.GET("/:id/reactions", reactionController.GetCommentReactions)
+		commentRoutes.POST("/:id/reactions", middleware.RequireAuth(), reactionController.ReactComment)
+		commentRoutes.DELETE("/:id/reactions/:emoji", middleware.RequireAuth(), reactionController.UnreactComment)
 	}
 
-	return router
-}
\ No newline at end of file
+	attachmentRoutes := api.Group("/attachments")
+	{
+		attachmentRoutes.GET("/:id/download", attachmentController.DownloadAttachment)
+		attachmentRoutes.GET("/:id/thumbnail", attachmentController.DownloadThumbnail)
+		attachmentRoutes.DELETE("/:id", attachmentController.DeleteAttachment)
+	}
+
+	userRoutes := api.Group("/users")
+	{
+		userRoutes.POST("", userController.CreateUser)
+		userRoutes.GET("/me", middleware.RequireAuth(), userController.GetMe)
+		userRoutes.GET("/me/blocks", middleware.RequireAuth(), userController.GetBlocks)
+		userRoutes.GET("/:id", userController.GetUser)
+		userRoutes.POST("/:id/follow", middleware.RequireAuth(), userController.FollowUser)
+		userRoutes.DELETE("/:id/follow", middleware.RequireAuth(), userController.UnfollowUser)
+		userRoutes.POST("/:id/block", middleware.RequireAuth(), userController.BlockUser)
+		userRoutes.DELETE("/:id/block", middleware.RequireAuth(), userController.UnblockUser)
+		userRoutes.GET("/:id/followers", userController.GetFollowers)
+		userRoutes.GET("/:id/following", userController.GetFollowing)
+	}
+
+	communityRoutes := api.Group("/communities")
+	{
+		communityRoutes.GET("", communityController.GetCommunities)
+		communityRoutes.POST("", middleware.RequireAuth(), communityController.CreateCommunity)
+		communityRoutes.GET("/:slug", communityController.GetCommunity)
+		communityRoutes.PUT("/:slug", middleware.RequireAuth(), communityController.UpdateCommunity)
+		communityRoutes.GET("/:slug/posts", communityController.GetCommunityPosts)
+		communityRoutes.POST("/:slug/join", middleware.RequireAuth(), communityController.JoinCommunity)
+		communityRoutes.POST("/:slug/leave", middleware.RequireAuth(), communityController.LeaveCommunity)
+		communityRoutes.GET("/:slug/members", communityController.GetMembers)
+		communityRoutes.DELETE("/:slug/members/:user_id", middleware.RequireAuth(), communityController.RemoveMember)
+		communityRoutes.PUT("/:slug/members/:user_id/role", middleware.RequireAuth(), communityController.UpdateMemberRole)
+		communityRoutes.GET("/:slug/join_requests", middleware.RequireAuth(), communityController.GetJoinRequests)
+		communityRoutes.POST("/:slug/join_requests/:id/approve", middleware.RequireAuth(), communityController.ApproveJoinRequest)
+		communityRoutes.POST("/:slug/join_requests/:id/reject", middleware.RequireAuth(), communityController.RejectJoinRequest)
+	}
+
+	conversationRoutes := api.Group("/conversations", middleware.RequireAuth())
+	{
+		conversationRoutes.GET("", messageController.GetConversations)
+		conversationRoutes.POST("", messageController.CreateConversation)
+		conversationRoutes.GET("/unread_count", messageController.GetUnreadCount)
+		conversationRoutes.GET("/:id", messageController.GetConversation)
+		conversationRoutes.POST("/:id/leave", messageController.LeaveConversation)
+		conversationRoutes.POST("/:id/read", messageController.MarkRead)
+		conversationRoutes.GET("/:id/messages", messageController.GetMessages)
+		conversationRoutes.POST("/:id/messages", middleware.Idempotent(), messageController.SendMessage)
+	}
+	messageRoutes := api.Group("/messages", middleware.RequireAuth())
+	{
+		messageRoutes.PUT("/:id", messageController.UpdateMessage)
+		messageRoutes.DELETE("/:id", messageController.DeleteMessage)
+	}
+
+	bookmarkRoutes := api.Group("/bookmarks", middleware.RequireAuth())
+	{
+		bookmarkRoutes.GET("", bookmarkController.GetBookmarks)
+		bookmarkRoutes.GET("/collections", bookmarkController.GetCollections)
+		bookmarkRoutes.POST("/collections", bookmarkController.CreateCollection)
+		bookmarkRoutes.PUT("/collections/:id", bookmarkController.UpdateCollection)
+		bookmarkRoutes.DELETE("/collections/:id", bookmarkController.DeleteCollection)
+	}
+
+	tagRoutes := api.Group("/tags")
+	{
+		tagRoutes.GET("/trending", tagController.GetTrendingTags)
+		tagRoutes.GET("/:tag/posts", tagController.GetTagPosts)
+	}
+
+	api.GET("/feed", middleware.RequireAuth(), feedController.GetFeed)
+
+	api.GET("/notifications/stream", middleware.QueryAPIKey("api_key"), middleware.RequireAuth(), notificationController.StreamNotifications)
+	notificationRoutes := api.Group("/notifications", middleware.RequireAuth())
+	{
+		notificationRoutes.GET("", notificationController.GetNotifications)
+		notificationRoutes.GET("/unread_count", notificationController.GetUnreadCount)
+		notificationRoutes.POST("/read_all", notificationController.MarkAllRead)
+		notificationRoutes.POST("/:id/read", notificationController.MarkRead)
+		notificationRoutes.GET("/preferences", notificationController.GetPreferences)
+		notificationRoutes.PUT("/preferences", notificationController.UpdatePreferences)
+	}
+
+	api.POST("/reports", middleware.RequireAuth(), moderationController.CreateReport)
+	moderationRoutes := api.Group("/moderation", middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	{
+		moderationRoutes.GET("/reports", moderationController.GetReports)
+		moderationRoutes.GET("/reports/:id", moderationController.GetReport)
+		moderationRoutes.POST("/reports/:id/resolve", moderationController.ResolveReport)
+		moderationRoutes.POST("/posts/:id/hide", moderationController.HidePost)
+		moderationRoutes.POST("/posts/:id/unhide", moderationController.UnhidePost)
+		moderationRoutes.POST("/comments/:id/hide", moderationController.HideComment)
+		moderationRoutes.POST("/comments/:id/unhide", moderationController.UnhideComment)
+		moderationRoutes.GET("/actions", moderationController.GetActions)
+	}
+	adminRoutes := api.Group("/admin", middleware.RequireRole(models.RoleAdmin))
+	{
+		adminRoutes.PUT("/users/:id/role", moderationController.UpdateUserRole)
+		adminRoutes.GET("/audit_logs", auditController.GetAuditLogs)
+		adminRoutes.GET("/audit_logs/export", auditController.ExportAuditLogs)
+	}
+
+	// Export chứa cả bản nháp và nội dung bị ẩn, import ghi thẳng ID/tác giả/thời gian: chỉ dành cho admin
+	api.GET("/export", middleware.RequireRole(models.RoleAdmin), transferController.ExportData)
+	api.POST("/import", middleware.RequireRole(models.RoleAdmin), transferController.ImportData)
+}
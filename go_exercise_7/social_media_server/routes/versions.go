@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"log"
+	"os"
+	"social_media_server/middleware"
+	"strings"
+	"time"
+)
+
+// Version là một phiên bản API mount tại /api/<Name>. Mọi phiên bản đều bắt đầu từ các route
+// của registerV1; Overrides thay handler của những route đổi hợp đồng và Register thêm route mới.
+// Để ra mắt v2: thêm Version{Name: "v2", Overrides: ..., Register: ...}, đặt DeprecatedAt và
+// Successor cho v1, rồi sinh swagger riêng cho instance "v2" (xem go:generate trong main.go).
+type Version struct {
+	Name      string
+	Overrides Overrides
+	Register  func(api *apiGroup)
+	// DeprecatedAt khác zero thì mọi response kèm header Deprecation; ngày Sunset đọc từ
+	// API_SUNSET_<NAME> (ví dụ API_SUNSET_V1=2027-06-30)
+	DeprecatedAt time.Time
+	Successor    string
+}
+
+var Versions = []Version{
+	{Name: "v1"},
+}
+
+// Ngày các route không có tiền tố phiên bản bắt đầu bị coi là cũ (khi có /api/v1)
+var legacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+func (v Version) deprecation() middleware.DeprecationPolicy {
+	policy := middleware.DeprecationPolicy{
+		DeprecatedAt: v.DeprecatedAt,
+		Sunset:       sunsetFromEnv(v.Name),
+		Prefix:       "/api/" + v.Name,
+	}
+	if v.Successor != "" {
+		policy.SuccessorPrefix = "/api/" + v.Successor
+	}
+	return policy
+}
+
+func legacyDeprecation() middleware.DeprecationPolicy {
+	return middleware.DeprecationPolicy{
+		DeprecatedAt:    legacyDeprecatedAt,
+		Sunset:          sunsetFromEnv("legacy"),
+		SuccessorPrefix: "/api/v1",
+	}
+}
+
+func sunsetFromEnv(name string) time.Time {
+	key := "API_SUNSET_" + strings.ToUpper(name)
+	v := os.Getenv(key)
+	if v == "" {
+		return time.Time{}
+	}
+	sunset, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		log.Fatalf("Invalid %s %q, expected YYYY-MM-DD", key, v)
+	}
+	return sunset
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Locker đảm bảo chỉ một instance chạy một lượt quét tại một thời điểm
+type Locker interface {
+	// TryLock trả về false (không lỗi) nếu instance khác đang giữ khoá
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key string) error
+}
+
+// Chỉ xoá khoá nếu vẫn là khoá của instance này (khoá có thể đã hết hạn và bị instance khác lấy)
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisLocker dùng SET NX PX làm khoá phân tán giữa các instance
+type RedisLocker struct {
+	rdb   *redis.Client
+	token string
+}
+
+func NewRedisLocker(rdb *redis.Client) *RedisLocker {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return &RedisLocker{rdb: rdb, token: hex.EncodeToString(b)}
+}
+
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return l.rdb.SetNX(ctx, key, l.token, ttl).Result()
+}
+
+func (l *RedisLocker) Unlock(ctx context.Context, key string) error {
+	return unlockScript.Run(ctx, l.rdb, []string{key}, l.token).Err()
+}
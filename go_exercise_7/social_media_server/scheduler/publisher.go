@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"social_media_server/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultInterval = 30 * time.Second
+	publishLockKey  = "scheduler:publish"
+	publishBatch    = 100
+)
+
+// PublishedFunc được gọi một lần cho mỗi post vừa được xuất bản (before là bản ở trạng thái hẹn giờ)
+type PublishedFunc func(ctx context.Context, before, after *models.Post)
+
+// Publisher định kỳ xuất bản các post hẹn giờ đã đến publish_at.
+//
+// Mỗi post được chuyển trạng thái bằng một UPDATE có điều kiện status = scheduled, nên dù nhiều
+// instance cùng quét thì chỉ một instance cập nhật được và gọi onPublished. Locker (tuỳ chọn)
+// chỉ để tránh các instance quét trùng lặp.
+type Publisher struct {
+	db          *gorm.DB
+	interval    time.Duration
+	locker      Locker
+	onPublished PublishedFunc
+}
+
+func NewPublisher(db *gorm.DB, interval time.Duration, locker Locker, onPublished PublishedFunc) *Publisher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Publisher{db: db, interval: interval, locker: locker, onPublished: onPublished}
+}
+
+// Run quét ngay khi khởi động rồi sau mỗi interval cho tới khi ctx bị huỷ
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := p.PublishDue(ctx); err != nil {
+			log.Printf("Scheduled publishing failed: %v", err)
+		} else if n > 0 {
+			log.Printf("Published %d scheduled posts", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue xuất bản các post có publish_at <= hiện tại và trả về số post instance này đã xuất bản
+func (p *Publisher) PublishDue(ctx context.Context) (int, error) {
+	if p.locker != nil {
+		ok, err := p.locker.TryLock(ctx, publishLockKey, p.interval)
+		if err != nil || !ok {
+			return 0, err
+		}
+		defer p.locker.Unlock(context.Background(), publishLockKey)
+	}
+
+	db := p.db.WithContext(ctx)
+	published := 0
+	for {
+		var due []models.Post
+		if err := db.Where("status = ? AND publish_at <= ?", models.PostScheduled, time.Now().UTC()).
+			Order("publish_at").Order("id").Limit(publishBatch).
+			Find(&due).Error; err != nil {
+			return published, err
+		}
+
+		for i := range due {
+			ok, err := p.publish(ctx, &due[i])
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				published++
+			}
+		}
+		if len(due) < publishBatch {
+			return published, nil
+		}
+	}
+}
+
+// publish chuyển một post sang published, created_at là thời điểm đã hẹn để feed sắp xếp đúng.
+// Trả về false nếu instance khác đã xuất bản hoặc tác giả đã đổi trạng thái trước đó.
+func (p *Publisher) publish(ctx context.Context, post *models.Post) (bool, error) {
+	db := p.db.WithContext(ctx)
+	result := db.Model(&models.Post{}).
+		Where("id = ? AND status = ?", post.ID, models.PostScheduled).
+		UpdateColumns(map[string]interface{}{
+			"status":     models.PostPublished,
+			"publish_at": nil,
+			"created_at": *post.PublishAt,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	var after models.Post
+	if err := db.First(&after, post.ID).Error; err != nil {
+		return true, err
+	}
+	if p.onPublished != nil {
+		p.onPublished(ctx, post, &after)
+	}
+	return true, nil
+}
@@ -0,0 +1,183 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"social_media_server/middleware"
+	"social_media_server/models"
+	"social_media_server/poststats"
+	"social_media_server/ranking"
+	"social_media_server/reactions"
+	"social_media_server/revision"
+	"social_media_server/tagging"
+
+	"gorm.io/gorm"
+)
+
+// ErrAlreadySeeded: một trong các username mẫu đã tồn tại, chạy lại sẽ tạo dữ liệu trùng
+var ErrAlreadySeeded = errors.New("sample users already exist")
+
+// Account là người dùng mẫu đã tạo kèm API key (chỉ hiện một lần)
+type Account struct {
+	Username string
+	Role     string
+	APIKey   string
+}
+
+var sampleUsers = []struct{ username, displayName, role string }{
+	{"admin", "Administrator", models.RoleAdmin},
+	{"alice", "Alice", models.RoleModerator},
+	{"bob", "Bob", models.RoleUser},
+	{"carol", "Carol", models.RoleUser},
+}
+
+// post/comment mẫu, author và parent là chỉ số trong sampleUsers và samplePosts/sampleComments
+var samplePosts = []struct {
+	author         int
+	title, content string
+	community      bool
+}{
+	{1, "Hello everyone", "First post on the new server! Say hi to @bob and @carol #intro", false},
+	{2, "Go tips", "Run `gofmt` before every commit and keep your interfaces small. #golang #tips", true},
+	{3, "Weekend plans", "Anyone up for a hike on Saturday, @alice? #outdoors", false},
+}
+
+var sampleComments = []struct {
+	author, post int
+	parent       int // -1 là comment gốc
+	content      string
+}{
+	{2, 0, -1, "Welcome @alice!"},
+	{1, 0, 0, "Thanks @bob #intro"},
+	{3, 1, -1, "Table-driven tests too #golang"},
+	{1, 2, -1, "Count me in!"},
+}
+
+// Run tạo dữ liệu mẫu để thử API: người dùng (một admin, một moderator), follow, một community,
+// post và comment có hashtag/mention, vote và reaction (emojis là các emoji được phép dùng).
+// Mọi thứ nằm trong một transaction; trả về ErrAlreadySeeded nếu đã có người dùng mẫu.
+func Run(ctx context.Context, db *gorm.DB, emojis []string) ([]Account, error) {
+	var accounts []Account
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		usernames := make([]string, len(sampleUsers))
+		for i, u := range sampleUsers {
+			usernames[i] = u.username
+		}
+		var existing int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("username IN ?", usernames).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadySeeded
+		}
+
+		users := make([]models.User, len(sampleUsers))
+		for i, u := range sampleUsers {
+			apiKey, hash, err := middleware.NewAPIKey()
+			if err != nil {
+				return err
+			}
+			users[i] = models.User{Username: u.username, DisplayName: u.displayName, APIKeyHash: hash, Role: u.role}
+			if err := tx.Create(&users[i]).Error; err != nil {
+				return err
+			}
+			accounts = append(accounts, Account{Username: u.username, Role: u.role, APIKey: apiKey})
+		}
+
+		// alice, bob và carol theo dõi lẫn nhau
+		for _, a := range users[1:] {
+			for _, b := range users[1:] {
+				if a.ID == b.ID {
+					continue
+				}
+				if err := tx.Create(&models.Follow{FollowerID: a.ID, FolloweeID: b.ID}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		community := models.Community{
+			Name:        "Gophers",
+			Slug:        "gophers",
+			Description: "Everything about Go",
+			JoinPolicy:  models.JoinOpen,
+			OwnerID:     users[1].ID,
+		}
+		if err := tx.Create(&community).Error; err != nil {
+			return err
+		}
+		memberships := []models.CommunityMembership{
+			{CommunityID: community.ID, UserID: users[1].ID, Role: models.CommunityOwner},
+			{CommunityID: community.ID, UserID: users[2].ID, Role: models.CommunityMember},
+			{CommunityID: community.ID, UserID: users[3].ID, Role: models.CommunityMember},
+		}
+		if err := tx.Create(&memberships).Error; err != nil {
+			return err
+		}
+
+		posts := make([]models.Post, len(samplePosts))
+		postIDs := make([]uint, len(samplePosts))
+		for i, p := range samplePosts {
+			posts[i] = models.Post{Title: p.title, Content: p.content, UserID: &users[p.author].ID}
+			if p.community {
+				posts[i].CommunityID = &community.ID
+			}
+			if err := tx.Create(&posts[i]).Error; err != nil {
+				return err
+			}
+			if _, err := tagging.Sync(tx, models.TargetPost, posts[i].ID, posts[i].Content); err != nil {
+				return err
+			}
+			rev := revision.FromPost(&posts[i])
+			rev.EditorID = posts[i].UserID
+			if err := revision.Append(tx, &rev); err != nil {
+				return err
+			}
+			postIDs[i] = posts[i].ID
+		}
+
+		comments := make([]models.Comment, len(sampleComments))
+		for i, cm := range sampleComments {
+			comments[i] = models.Comment{Content: cm.content, PostID: posts[cm.post].ID, UserID: &users[cm.author].ID}
+			if cm.parent >= 0 {
+				comments[i].ParentID = &comments[cm.parent].ID
+			}
+			if err := tx.Create(&comments[i]).Error; err != nil {
+				return err
+			}
+			if _, err := tagging.Sync(tx, models.TargetComment, comments[i].ID, comments[i].Content); err != nil {
+				return err
+			}
+			if err := poststats.CommentAdded(tx, comments[i].PostID, comments[i].CreatedAt); err != nil {
+				return err
+			}
+			rev := revision.FromComment(&comments[i])
+			rev.EditorID = comments[i].UserID
+			if err := revision.Append(tx, &rev); err != nil {
+				return err
+			}
+		}
+
+		// Mỗi người dùng upvote post của người khác và react emoji đầu tiên được phép
+		for _, u := range users[1:] {
+			for _, p := range posts {
+				if *p.UserID == u.ID {
+					continue
+				}
+				if _, err := reactions.SetVote(tx, u.ID, models.TargetPost, p.ID, models.VoteUp); err != nil {
+					return err
+				}
+				if len(emojis) > 0 {
+					if _, err := reactions.React(tx, u.ID, models.TargetPost, p.ID, emojis[0]); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return ranking.Refresh(tx, postIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"social_media_server/config"
+	"social_media_server/seed"
+	"text/tabwriter"
+)
+
+// runSeed tạo dữ liệu mẫu cho môi trường dev và in API key của các người dùng mẫu
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	fs.Parse(args)
+
+	config.ConnectDB()
+	config.SetupReactions()
+
+	accounts, err := seed.Run(context.Background(), config.DB, config.ReactionEmojis)
+	if errors.Is(err, seed.ErrAlreadySeeded) {
+		log.Fatal("Sample data is already present, nothing to do")
+	}
+	if err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tROLE\tAPI KEY")
+	for _, a := range accounts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", a.Username, a.Role, a.APIKey)
+	}
+	w.Flush()
+	log.Printf("Seeded %d users with sample posts and comments", len(accounts))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"social_media_server/config"
+	_ "social_media_server/docs/v1"
+	"social_media_server/routes"
+
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// runServe chạy HTTP server; đây là lệnh mặc định khi không truyền lệnh nào
+func runServe(args []string) {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	fs.StringVar(&port, "port", port, "port to listen on (overrides PORT)")
+	fs.Parse(args)
+
+	config.ConnectDB()
+	config.ConnectStorage()
+	config.SetupFeed()
+	config.SetupNotifications()
+	config.SetupModeration()
+	config.SetupContentCheck()
+	config.SetupIdempotency()
+	config.SetupMessaging()
+	config.SetupReactions()
+	config.StartScheduler(context.Background())
+	config.StartRanking(context.Background())
+	// config.ConnectRedis()
+
+	router := routes.SetupRouter()
+
+	for _, version := range routes.Versions {
+		router.GET("/api/"+version.Name+"/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName(version.Name)))
+	}
+	// Đường dẫn cũ của Swagger UI, hiển thị tài liệu v1
+	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName("v1")))
+
+	log.Printf("Server starting on port %s", port)
+	log.Printf("Swagger UI available at http://localhost:%s/api/v1/swagger/index.html", port)
+	if err := router.Run(":" + port); err != nil {
+		log.Fatal("Failed to run server:", err)
+	}
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrURLExpired          = errors.New("storage: signed URL has expired")
+	ErrURLInvalidSignature = errors.New("storage: invalid URL signature")
+)
+
+// URLSigner tạo và kiểm tra URL tải file có chữ ký HMAC và thời hạn.
+type URLSigner struct {
+	key []byte
+}
+
+func NewURLSigner(key []byte) *URLSigner {
+	return &URLSigner{key: key}
+}
+
+func (s *URLSigner) signature(path string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(mac, "%s\n%d", path, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *URLSigner) Sign(path string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("signature", s.signature(path, expires))
+	return path + "?" + q.Encode()
+}
+
+func (s *URLSigner) Verify(path, expiresStr, signature string) error {
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return ErrURLInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(path, expires))) {
+		return ErrURLInvalidSignature
+	}
+	if time.Now().Unix() > expires {
+		return ErrURLExpired
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const maxConnectBackoff = 30 * time.Second
+
+// PoolConfig là cấu hình pool của database/sql, áp dụng cho từng database (primary, mỗi replica)
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Options điều khiển cách Connect/ConnectReplicas mở kết nối. QueryTimeout bằng 0 là không giới
+// hạn thời gian mỗi câu lệnh.
+type Options struct {
+	Pool            PoolConfig
+	QueryTimeout    time.Duration
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+}
+
+var DefaultOptions = Options{
+	Pool: PoolConfig{
+		MaxOpenConns:    25,
+		MaxIdleConns:    10,
+		ConnMaxLifetime: 30 * time.Minute,
+	},
+	QueryTimeout:    10 * time.Second,
+	ConnectAttempts: 5,
+	ConnectBackoff:  time.Second,
+}
+
+// Connect mở primary database. Khi khởi động cùng lúc với database (docker compose, k8s) lần
+// kết nối đầu thường lỗi, nên thử lại tối đa ConnectAttempts lần, thời gian chờ gấp đôi sau mỗi
+// lần (tối đa 30s).
+func Connect(ctx context.Context, driver, dsn string, opts Options) (*gorm.DB, error) {
+	if _, err := newDialector(driver, dsn); err != nil {
+		return nil, err
+	}
+	return connectWithRetry(ctx, opts, func() (*gorm.DB, error) {
+		db, err := Open(driver, dsn)
+		if err != nil {
+			return nil, err
+		}
+		return db, configure(db, driver, dsn, opts)
+	})
+}
+
+// ConnectReplicas mở handle chỉ dùng để đọc tới các read replica. Có nhiều replica thì truy
+// vấn được chia lần lượt giữa chúng; replica đầu tiên dùng lại pool của kết nối gốc nên mỗi
+// replica chỉ có một pool.
+func ConnectReplicas(ctx context.Context, driver string, dsns []string, opts Options) (*gorm.DB, error) {
+	if len(dsns) == 0 {
+		return nil, fmt.Errorf("store: no replica DSNs")
+	}
+	others := make([]gorm.Dialector, len(dsns)-1)
+	for i, dsn := range dsns[1:] {
+		var err error
+		if others[i], err = newDialector(driver, dsn); err != nil {
+			return nil, err
+		}
+	}
+
+	return connectWithRetry(ctx, opts, func() (*gorm.DB, error) {
+		db, err := Open(driver, dsns[0])
+		if err != nil {
+			return nil, err
+		}
+		if len(dsns) > 1 {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return db, err
+			}
+			resolver := dbresolver.Register(dbresolver.Config{
+				Replicas: append([]gorm.Dialector{connDialector(driver, sqlDB)}, others...),
+				Policy:   dbresolver.StrictRoundRobinPolicy(),
+			})
+			resolver.
+				SetMaxOpenConns(opts.Pool.MaxOpenConns).
+				SetMaxIdleConns(opts.Pool.MaxIdleConns).
+				SetConnMaxLifetime(opts.Pool.ConnMaxLifetime)
+			if err := db.Use(resolver); err != nil {
+				return db, err
+			}
+		}
+		return db, configure(db, driver, dsns[0], opts)
+	})
+}
+
+// configure áp dụng pool và timeout. SQLite ":memory:" giữ nguyên một kết nối không hết hạn,
+// vì đóng kết nối đó là mất cả database.
+func configure(db *gorm.DB, driver, dsn string, opts Options) error {
+	if !(driver == SQLite && sqliteInMemory(dsn)) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		sqlDB.SetMaxOpenConns(opts.Pool.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(opts.Pool.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(opts.Pool.ConnMaxLifetime)
+	}
+	if opts.QueryTimeout > 0 {
+		return db.Use(queryTimeout(opts.QueryTimeout))
+	}
+	return nil
+}
+
+func connectWithRetry(ctx context.Context, opts Options, open func() (*gorm.DB, error)) (*gorm.DB, error) {
+	attempts := opts.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := opts.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := open()
+		if err == nil {
+			return db, nil
+		}
+		if db != nil {
+			closeDB(db)
+		}
+		if attempt == attempts {
+			return nil, fmt.Errorf("store: giving up after %d attempts: %w", attempts, err)
+		}
+
+		log.Printf("Database connection failed (attempt %d/%d): %v; retrying in %s", attempt, attempts, err, backoff)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+func closeDB(db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"social_media_server/models"
+	"strings"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Các driver được hỗ trợ (DB_DRIVER)
+const (
+	MySQL    = "mysql"
+	Postgres = "postgres"
+	SQLite   = "sqlite"
+)
+
+// Open mở kết nối tới database theo driver. Mọi phần riêng của từng driver nằm ở đây, phần còn
+// lại của server chỉ dùng truy vấn gorm chạy được trên cả ba. Với SQLite, dsn là đường dẫn file
+// hoặc ":memory:"; SQLite so sánh thời gian theo chuỗi nên server cần chạy với TZ=UTC.
+func Open(driver, dsn string) (*gorm.DB, error) {
+	dialector, err := newDialector(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	if driver == SQLite && sqliteInMemory(dsn) {
+		// Mỗi kết nối tới ":memory:" là một database rỗng riêng, nên chỉ giữ một kết nối
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+	return db, nil
+}
+
+func newDialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case MySQL:
+		return mysql.Open(dsn), nil
+	case Postgres:
+		return postgres.Open(dsn), nil
+	case SQLite:
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	default:
+		return nil, fmt.Errorf("store: unknown driver %q (available: mysql, postgres, sqlite)", driver)
+	}
+}
+
+// connDialector bọc một pool đã mở để dbresolver dùng lại thay vì mở pool mới tới cùng database
+func connDialector(driver string, conn gorm.ConnPool) gorm.Dialector {
+	switch driver {
+	case Postgres:
+		return postgres.New(postgres.Config{Conn: conn})
+	case SQLite:
+		return &sqlite.Dialector{Conn: conn}
+	default:
+		return mysql.New(mysql.Config{Conn: conn})
+	}
+}
+
+// Migrate tạo hoặc cập nhật schema của mọi model
+func Migrate(db *gorm.DB) error {
+	return WithoutTimeout(db).AutoMigrate(Models()...)
+}
+
+// Models là danh sách model có bảng trong database, theo thứ tự migrate
+func Models() []interface{} {
+	return []interface{}{
+		&models.Post{},
+		&models.Comment{},
+		&models.Attachment{},
+		&models.User{},
+		&models.Follow{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.ContentFingerprint{},
+		&models.AuditLog{},
+		&models.Revision{},
+		&models.IdempotencyKey{},
+		&models.Community{},
+		&models.CommunityMembership{},
+		&models.CommunityJoinRequest{},
+		&models.Block{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.Tag{},
+		&models.Tagging{},
+		&models.Mention{},
+		&models.Reaction{},
+		&models.Vote{},
+		&models.ReactionCount{},
+		&models.PostScore{},
+		&models.Collection{},
+		&models.Bookmark{},
+	}
+}
+
+// sqliteDSN bật khoá ngoại như MySQL/Postgres và chờ thay vì trả lỗi "database is locked" khi có
+// nhiều ghi đồng thời; file trên đĩa dùng WAL để đọc không chặn ghi
+func sqliteDSN(dsn string) string {
+	pragmas := []string{"foreign_keys(1)", "busy_timeout(5000)"}
+	if !sqliteInMemory(dsn) {
+		pragmas = append(pragmas, "journal_mode(WAL)")
+	}
+
+	params := url.Values{}
+	for _, pragma := range pragmas {
+		name := pragma[:strings.IndexByte(pragma, '(')]
+		if !strings.Contains(dsn, name) {
+			params.Add("_pragma", pragma)
+		}
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + params.Encode()
+}
+
+func sqliteInMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	timeoutKey   = "store:query_timeout"
+	noTimeoutKey = "store:no_query_timeout"
+)
+
+// queryTimeout là plugin gorm đặt deadline cho từng câu lệnh, tính từ context đang có (thường là
+// context của request) nên cái nào hết trước thì huỷ trước. Row/Rows/Scan không được bọc vì
+// kết quả còn được đọc sau khi callback chạy xong. MySQL/Postgres huỷ câu lệnh đang chạy; driver
+// SQLite chỉ trả lỗi timeout sau khi câu lệnh chạy xong.
+type queryTimeout time.Duration
+
+type timeoutState struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+func (queryTimeout) Name() string {
+	return timeoutKey
+}
+
+func (t queryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().Before("*").Register(timeoutKey+":begin", t.begin),
+		cb.Create().After("*").Register(timeoutKey+":end", end),
+		cb.Query().Before("*").Register(timeoutKey+":begin", t.begin),
+		cb.Query().After("*").Register(timeoutKey+":end", end),
+		cb.Update().Before("*").Register(timeoutKey+":begin", t.begin),
+		cb.Update().After("*").Register(timeoutKey+":end", end),
+		cb.Delete().Before("*").Register(timeoutKey+":begin", t.begin),
+		cb.Delete().After("*").Register(timeoutKey+":end", end),
+		cb.Raw().Before("*").Register(timeoutKey+":begin", t.begin),
+		cb.Raw().After("*").Register(timeoutKey+":end", end),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithoutTimeout bỏ giới hạn thời gian cho các câu lệnh chạy qua db, dùng cho migration và việc
+// bảo trì có thể chạy lâu
+func WithoutTimeout(db *gorm.DB) *gorm.DB {
+	return db.Set(noTimeoutKey, true)
+}
+
+func (t queryTimeout) begin(db *gorm.DB) {
+	if _, ok := db.Get(noTimeoutKey); ok {
+		return
+	}
+	parent := db.Statement.Context
+	ctx, cancel := context.WithTimeout(parent, time.Duration(t))
+	db.Statement.Settings.Store(timeoutKey, timeoutState{parent: parent, cancel: cancel})
+	db.Statement.Context = ctx
+}
+
+// end trả lại context cũ vì statement có thể được dùng tiếp, ví dụ query.Count() rồi query.Find()
+func end(db *gorm.DB) {
+	v, ok := db.Statement.Settings.LoadAndDelete(timeoutKey)
+	if !ok {
+		return
+	}
+	state := v.(timeoutState)
+	state.cancel()
+	db.Statement.Context = state.parent
+}
@@ -0,0 +1,63 @@
+package tagging
+
+import (
+	"context"
+	"social_media_server/models"
+
+	"gorm.io/gorm"
+)
+
+const rebuildBatch = 200
+
+// RebuildAll đọc lại nội dung mọi post và comment còn tồn tại để dựng lại tag và mention, rồi xoá
+// tagging/mention của những bản ghi đã bị xoá. Trả về số post và comment đã xử lý.
+func RebuildAll(ctx context.Context, db *gorm.DB) (int, error) {
+	db = db.WithContext(ctx)
+	posts, err := rebuild(db, &models.Post{}, models.TargetPost)
+	if err != nil {
+		return posts, err
+	}
+	comments, err := rebuild(db, &models.Comment{}, models.TargetComment)
+	return posts + comments, err
+}
+
+type content struct {
+	ID      uint
+	Content string
+}
+
+// rebuild đồng bộ lại từng lô trong một transaction và xoá tagging/mention của bản ghi không còn
+func rebuild(db *gorm.DB, model interface{}, targetType string) (int, error) {
+	processed := 0
+	var lastID uint
+	for {
+		var rows []content
+		if err := db.Model(model).Select("id", "content").Where("id > ?", lastID).
+			Order("id").Limit(rebuildBatch).Find(&rows).Error; err != nil {
+			return processed, err
+		}
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range rows {
+				if _, err := Sync(tx, targetType, row.ID, row.Content); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return processed, err
+		}
+		processed += len(rows)
+		if len(rows) < rebuildBatch {
+			break
+		}
+		lastID = rows[len(rows)-1].ID
+	}
+
+	live := db.Model(model).Select("id")
+	for _, stale := range []interface{}{&models.Tagging{}, &models.Mention{}} {
+		if err := db.Where("target_type = ? AND target_id NOT IN (?)", targetType, live).Delete(stale).Error; err != nil {
+			return processed, err
+		}
+	}
+	return processed, nil
+}
@@ -0,0 +1,94 @@
+package tagging
+
+import (
+	"social_media_server/models"
+	"social_media_server/render"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sync cập nhật hashtag và mention đã lưu của một post/comment theo nội dung mới. Trả về ID những
+// người dùng vừa được mention lần đầu trong nội dung này để gửi thông báo.
+func Sync(tx *gorm.DB, targetType string, targetID uint, content string) ([]uint, error) {
+	if err := syncTags(tx, targetType, targetID, render.Hashtags(content)); err != nil {
+		return nil, err
+	}
+	return syncMentions(tx, targetType, targetID, render.Mentions(content))
+}
+
+func syncTags(tx *gorm.DB, targetType string, targetID uint, names []string) error {
+	var tagIDs []uint
+	if len(names) > 0 {
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			tags[i] = models.Tag{Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	stale := tx.Where("target_type = ? AND target_id = ?", targetType, targetID)
+	if len(tagIDs) > 0 {
+		stale = stale.Where("tag_id NOT IN ?", tagIDs)
+	}
+	if err := stale.Delete(&models.Tagging{}).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	taggings := make([]models.Tagging, len(tagIDs))
+	for i, id := range tagIDs {
+		taggings[i] = models.Tagging{TagID: id, TargetType: targetType, TargetID: targetID}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&taggings).Error
+}
+
+func syncMentions(tx *gorm.DB, targetType string, targetID uint, usernames []string) ([]uint, error) {
+	var userIDs []uint
+	if len(usernames) > 0 {
+		if err := tx.Model(&models.User{}).Where("username IN ?", usernames).Pluck("id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var existing []uint
+	if err := tx.Model(&models.Mention{}).Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Pluck("user_id", &existing).Error; err != nil {
+		return nil, err
+	}
+
+	stale := tx.Where("target_type = ? AND target_id = ?", targetType, targetID)
+	if len(userIDs) > 0 {
+		stale = stale.Where("user_id NOT IN ?", userIDs)
+	}
+	if err := stale.Delete(&models.Mention{}).Error; err != nil {
+		return nil, err
+	}
+
+	mentioned := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		mentioned[id] = true
+	}
+	var added []uint
+	var mentions []models.Mention
+	for _, id := range userIDs {
+		if !mentioned[id] {
+			added = append(added, id)
+			mentions = append(mentions, models.Mention{UserID: id, TargetType: targetType, TargetID: targetID})
+		}
+	}
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&mentions).Error; err != nil {
+		return nil, err
+	}
+	return added, nil
+}
@@ -0,0 +1,158 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"social_media_server/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const exportBatchSize = 200
+
+var csvHeader = []string{"type", "id", "post_id", "title", "content", "content_format", "created_at", "updated_at", "user_id", "parent_id", "status", "publish_at"}
+
+// File CSV export trước khi có status/publish_at chỉ có 10 cột đầu, vẫn import được
+const legacyCSVColumns = 10
+
+type recordWriter interface {
+	Write(rec PostRecord) error
+	Close() error
+}
+
+// Export stream toàn bộ post kèm comment ra w theo từng batch để không phải giữ hết dữ liệu trong bộ nhớ.
+// Trả về số post đã ghi.
+func Export(ctx context.Context, db *gorm.DB, w io.Writer, format string) (int, error) {
+	bw := bufio.NewWriter(w)
+	out, err := newRecordWriter(bw, format)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	var posts []models.Post
+	result := db.WithContext(ctx).
+		Preload("Comments", func(tx *gorm.DB) *gorm.DB { return tx.Order("id ASC") }).
+		Order("id ASC").
+		FindInBatches(&posts, exportBatchSize, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				if err := out.Write(newPostRecord(post)); err != nil {
+					return err
+				}
+				count++
+			}
+			return bw.Flush()
+		})
+	if result.Error != nil {
+		return count, result.Error
+	}
+
+	if err := out.Close(); err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+func newRecordWriter(w io.Writer, format string) (recordWriter, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}, nil
+	case FormatJSON:
+		return &jsonWriter{w: w, enc: json.NewEncoder(w)}, nil
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw}, nil
+	default:
+		return nil, fmt.Errorf("transfer: unknown format %q", format)
+	}
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (nw *ndjsonWriter) Write(rec PostRecord) error { return nw.enc.Encode(rec) }
+func (nw *ndjsonWriter) Close() error               { return nil }
+
+// jsonWriter ghi một JSON array nhưng vẫn stream từng phần tử
+type jsonWriter struct {
+	w       io.Writer
+	enc     *json.Encoder
+	started bool
+}
+
+func (jw *jsonWriter) Write(rec PostRecord) error {
+	sep := ","
+	if !jw.started {
+		sep = "["
+		jw.started = true
+	}
+	if _, err := io.WriteString(jw.w, sep); err != nil {
+		return err
+	}
+	return jw.enc.Encode(rec)
+}
+
+func (jw *jsonWriter) Close() error {
+	if !jw.started {
+		_, err := io.WriteString(jw.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(jw.w, "]\n")
+	return err
+}
+
+// csvWriter ghi mỗi post một dòng "post", theo sau là các dòng "comment" của post đó
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) Write(rec PostRecord) error {
+	id := strconv.FormatUint(uint64(rec.ID), 10)
+	if err := cw.w.Write([]string{
+		"post", id, "", rec.Title, rec.Content, rec.ContentFormat,
+		rec.CreatedAt.Format(time.RFC3339Nano), rec.UpdatedAt.Format(time.RFC3339Nano), formatCSVOptionalID(rec.UserID), "",
+		rec.Status, formatCSVOptionalTime(rec.PublishAt),
+	}); err != nil {
+		return err
+	}
+	for _, comment := range rec.Comments {
+		if err := cw.w.Write([]string{
+			"comment", strconv.FormatUint(uint64(comment.ID), 10), id, "", comment.Content, comment.ContentFormat,
+			comment.CreatedAt.Format(time.RFC3339Nano), comment.UpdatedAt.Format(time.RFC3339Nano), formatCSVOptionalID(comment.UserID),
+			formatCSVOptionalID(comment.ParentID), "", "",
+		}); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func formatCSVOptionalID(id *uint) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatUint(uint64(*id), 10)
+}
+
+func formatCSVOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
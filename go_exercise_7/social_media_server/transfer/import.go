@@ -0,0 +1,453 @@
+package transfer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"social_media_server/models"
+	"social_media_server/poststats"
+	"social_media_server/tagging"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const DefaultBatchSize = 100
+
+type ImportOptions struct {
+	// PreserveIDs giữ nguyên ID trong file, nếu không DB sẽ cấp ID mới và comment được map sang post mới
+	PreserveIDs bool
+	// PreserveTimestamps giữ created_at/updated_at trong file thay vì dùng thời điểm import
+	PreserveTimestamps bool
+	BatchSize          int
+}
+
+type RecordError struct {
+	Record int    `json:"record"`
+	ID     uint   `json:"id,omitempty"`
+	Error  string `json:"error"`
+}
+
+type ImportReport struct {
+	PostsImported    int           `json:"posts_imported"`
+	CommentsImported int           `json:"comments_imported"`
+	Failed           int           `json:"failed"`
+	Errors           []RecordError `json:"errors"`
+	// PostIDMap ánh xạ ID trong file sang ID mới trong DB (chỉ có khi không giữ ID)
+	PostIDMap map[uint]uint `json:"post_id_map,omitempty"`
+}
+
+type recordReader interface {
+	// Next trả về io.EOF khi hết dữ liệu. Lỗi kiểu *recordParseError chỉ làm hỏng bản ghi hiện tại.
+	Next() (*PostRecord, error)
+}
+
+type recordParseError struct {
+	err error
+}
+
+func (e *recordParseError) Error() string { return e.err.Error() }
+
+type pendingRecord struct {
+	index int
+	rec   *PostRecord
+}
+
+// Import đọc bản ghi từ r, validate rồi ghi vào DB theo từng batch, mỗi batch là một transaction.
+// Bản ghi lỗi được bỏ qua và ghi vào report, các bản ghi khác trong batch vẫn được import.
+func Import(ctx context.Context, db *gorm.DB, r io.Reader, format string, opts ImportOptions) (*ImportReport, error) {
+	in, err := newRecordReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+
+	report := &ImportReport{Errors: []RecordError{}}
+	if !opts.PreserveIDs {
+		report.PostIDMap = map[uint]uint{}
+	}
+
+	batch := make([]pendingRecord, 0, opts.BatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := importBatch(ctx, db, batch, opts, report)
+		batch = batch[:0]
+		return err
+	}
+
+	for index := 1; ; index++ {
+		rec, err := in.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *recordParseError
+		if errors.As(err, &parseErr) {
+			report.fail(index, 0, parseErr)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+
+		if err := rec.validate(); err != nil {
+			report.fail(index, rec.ID, err)
+			continue
+		}
+		if opts.PreserveIDs && rec.ID == 0 {
+			report.fail(index, 0, fmt.Errorf("id is required when preserving IDs"))
+			continue
+		}
+
+		batch = append(batch, pendingRecord{index: index, rec: rec})
+		if len(batch) >= opts.BatchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, flush()
+}
+
+func (report *ImportReport) fail(index int, id uint, err error) {
+	report.Failed++
+	report.Errors = append(report.Errors, RecordError{Record: index, ID: id, Error: err.Error()})
+}
+
+func importBatch(ctx context.Context, db *gorm.DB, batch []pendingRecord, opts ImportOptions, report *ImportReport) error {
+	type imported struct {
+		oldID, newID uint
+		comments     int
+	}
+	var done []imported
+	var failed []RecordError
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, item := range batch {
+			// Savepoint để một bản ghi lỗi không làm rollback cả batch
+			savepoint := "import_record_" + strconv.Itoa(i)
+			if err := tx.SavePoint(savepoint).Error; err != nil {
+				return err
+			}
+			newID, comments, err := importRecord(tx, item.rec, opts)
+			if err != nil {
+				if rbErr := tx.RollbackTo(savepoint).Error; rbErr != nil {
+					return rbErr
+				}
+				failed = append(failed, RecordError{Record: item.index, ID: item.rec.ID, Error: err.Error()})
+				continue
+			}
+			done = append(done, imported{oldID: item.rec.ID, newID: newID, comments: comments})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, d := range done {
+		report.PostsImported++
+		report.CommentsImported += d.comments
+		if report.PostIDMap != nil && d.oldID != 0 {
+			report.PostIDMap[d.oldID] = d.newID
+		}
+	}
+	report.Failed += len(failed)
+	report.Errors = append(report.Errors, failed...)
+	return nil
+}
+
+func importRecord(tx *gorm.DB, rec *PostRecord, opts ImportOptions) (uint, int, error) {
+	post := models.Post{
+		UserID:        rec.UserID,
+		Title:         rec.Title,
+		Content:       rec.Content,
+		ContentFormat: rec.ContentFormat,
+		Status:        rec.Status,
+		PublishAt:     rec.PublishAt,
+	}
+	if opts.PreserveIDs {
+		var existing int64
+		if err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", rec.ID).Count(&existing).Error; err != nil {
+			return 0, 0, err
+		}
+		if existing > 0 {
+			return 0, 0, fmt.Errorf("post with id %d already exists", rec.ID)
+		}
+		post.ID = rec.ID
+	}
+	if opts.PreserveTimestamps {
+		post.CreatedAt, post.UpdatedAt = rec.CreatedAt, rec.UpdatedAt
+	}
+	if err := tx.Create(&post).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to create post: %w", err)
+	}
+	if _, err := tagging.Sync(tx, models.TargetPost, post.ID, post.Content); err != nil {
+		return 0, 0, fmt.Errorf("failed to index post tags: %w", err)
+	}
+
+	// Comment cha luôn đứng trước comment trả lời (export theo thứ tự id) nên có thể map ID mới ngay khi duyệt
+	commentIDs := make(map[uint]uint, len(rec.Comments))
+	for i, rc := range rec.Comments {
+		var parentID *uint
+		if rc.ParentID != nil {
+			newParentID, ok := commentIDs[*rc.ParentID]
+			if !ok {
+				return 0, 0, fmt.Errorf("comment %d: parent comment %d is not part of this post", i, *rc.ParentID)
+			}
+			parentID = &newParentID
+		}
+
+		comment := models.Comment{
+			PostID:        post.ID,
+			ParentID:      parentID,
+			UserID:        rc.UserID,
+			Content:       rc.Content,
+			ContentFormat: rc.ContentFormat,
+		}
+		if opts.PreserveIDs && rc.ID != 0 {
+			var existing int64
+			if err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", rc.ID).Count(&existing).Error; err != nil {
+				return 0, 0, err
+			}
+			if existing > 0 {
+				return 0, 0, fmt.Errorf("comment %d: comment with id %d already exists", i, rc.ID)
+			}
+			comment.ID = rc.ID
+		}
+		if opts.PreserveTimestamps {
+			comment.CreatedAt, comment.UpdatedAt = rc.CreatedAt, rc.UpdatedAt
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return 0, 0, fmt.Errorf("comment %d: failed to create comment: %w", i, err)
+		}
+		if _, err := tagging.Sync(tx, models.TargetComment, comment.ID, comment.Content); err != nil {
+			return 0, 0, fmt.Errorf("comment %d: failed to index tags: %w", i, err)
+		}
+		if rc.ID != 0 {
+			commentIDs[rc.ID] = comment.ID
+		}
+	}
+	if err := poststats.Recompute(tx, []uint{post.ID}); err != nil {
+		return 0, 0, fmt.Errorf("failed to count comments: %w", err)
+	}
+	return post.ID, len(rec.Comments), nil
+}
+
+func newRecordReader(r io.Reader, format string) (recordReader, error) {
+	switch format {
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16<<20)
+		return &ndjsonReader{scanner: scanner}, nil
+	case FormatJSON:
+		return &jsonReader{dec: json.NewDecoder(r)}, nil
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return &csvReader{r: cr}, nil
+	default:
+		return nil, fmt.Errorf("transfer: unknown format %q", format)
+	}
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (nr *ndjsonReader) Next() (*PostRecord, error) {
+	for nr.scanner.Scan() {
+		line := nr.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec PostRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, &recordParseError{err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		return &rec, nil
+	}
+	if err := nr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type jsonReader struct {
+	dec     *json.Decoder
+	started bool
+}
+
+func (jr *jsonReader) Next() (*PostRecord, error) {
+	if !jr.started {
+		tok, err := jr.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("invalid JSON: expected an array of posts")
+		}
+		jr.started = true
+	}
+	if !jr.dec.More() {
+		return nil, io.EOF
+	}
+
+	// Decode từng phần tử qua RawMessage để một phần tử sai kiểu không làm dừng cả file
+	var raw json.RawMessage
+	if err := jr.dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	var rec PostRecord
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return nil, &recordParseError{err: fmt.Errorf("invalid record: %w", err)}
+	}
+	return &rec, nil
+}
+
+// csvReader gom dòng "post" và các dòng "comment" ngay sau nó thành một bản ghi
+type csvReader struct {
+	r          *csv.Reader
+	headerRead bool
+	columns    int
+	pending    []string
+}
+
+func (cr *csvReader) Next() (*PostRecord, error) {
+	if !cr.headerRead {
+		header, err := cr.r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("invalid CSV header: %w", err)
+		}
+		if (len(header) != len(csvHeader) && len(header) != legacyCSVColumns) || header[0] != csvHeader[0] {
+			return nil, fmt.Errorf("invalid CSV header, expected %v", csvHeader)
+		}
+		cr.headerRead = true
+		cr.columns = len(header)
+	}
+
+	row := cr.pending
+	cr.pending = nil
+	if row == nil {
+		var err error
+		if row, err = cr.r.Read(); err != nil {
+			return nil, err
+		}
+	}
+	if len(row) != cr.columns {
+		return nil, &recordParseError{err: fmt.Errorf("expected %d columns, got %d", cr.columns, len(row))}
+	}
+	if row[0] != "post" {
+		return nil, &recordParseError{err: fmt.Errorf("comment row for post %s does not follow its post row", row[2])}
+	}
+
+	rec := &PostRecord{Title: row[3], Content: row[4], ContentFormat: row[5]}
+	var parseErr error
+	rec.ID, parseErr = parseCSVID(row[1])
+	if parseErr == nil {
+		rec.CreatedAt, rec.UpdatedAt, parseErr = parseCSVTimes(row[6], row[7])
+	}
+	if parseErr == nil {
+		rec.UserID, parseErr = parseCSVOptionalID("user_id", row[8])
+	}
+	if parseErr == nil && cr.columns > legacyCSVColumns {
+		rec.Status = row[10]
+		rec.PublishAt, parseErr = parseCSVOptionalTime("publish_at", row[11])
+	}
+
+	for {
+		next, err := cr.r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(next) != cr.columns || next[0] != "comment" {
+			cr.pending = next
+			break
+		}
+		comment := CommentRecord{Content: next[4], ContentFormat: next[5]}
+		if parseErr == nil {
+			comment.ID, parseErr = parseCSVID(next[1])
+		}
+		if parseErr == nil {
+			comment.PostID, parseErr = parseCSVID(next[2])
+		}
+		if parseErr == nil {
+			comment.CreatedAt, comment.UpdatedAt, parseErr = parseCSVTimes(next[6], next[7])
+		}
+		if parseErr == nil {
+			comment.UserID, parseErr = parseCSVOptionalID("user_id", next[8])
+		}
+		if parseErr == nil {
+			comment.ParentID, parseErr = parseCSVOptionalID("parent_id", next[9])
+		}
+		rec.Comments = append(rec.Comments, comment)
+	}
+
+	if parseErr != nil {
+		return nil, &recordParseError{err: parseErr}
+	}
+	return rec, nil
+}
+
+func parseCSVID(s string) (uint, error) {
+	if s == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return uint(id), nil
+}
+
+func parseCSVOptionalID(column, s string) (*uint, error) {
+	if s == "" {
+		return nil, nil
+	}
+	id, err := parseCSVID(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", column, s)
+	}
+	return &id, nil
+}
+
+func parseCSVOptionalTime(column, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", column, s)
+	}
+	return &t, nil
+}
+
+func parseCSVTimes(created, updated string) (time.Time, time.Time, error) {
+	var createdAt, updatedAt time.Time
+	var err error
+	if created != "" {
+		if createdAt, err = time.Parse(time.RFC3339Nano, created); err != nil {
+			return createdAt, updatedAt, fmt.Errorf("invalid created_at %q", created)
+		}
+	}
+	if updated != "" {
+		if updatedAt, err = time.Parse(time.RFC3339Nano, updated); err != nil {
+			return createdAt, updatedAt, fmt.Errorf("invalid updated_at %q", updated)
+		}
+	}
+	return createdAt, updatedAt, nil
+}
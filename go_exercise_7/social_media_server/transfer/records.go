@@ -0,0 +1,112 @@
+package transfer
+
+import (
+	"fmt"
+	"social_media_server/models"
+	"time"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+)
+
+func ValidFormat(format string) bool {
+	return format == FormatNDJSON || format == FormatJSON || format == FormatCSV
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+type CommentRecord struct {
+	ID            uint      `json:"id"`
+	PostID        uint      `json:"post_id"`
+	ParentID      *uint     `json:"parent_id"`
+	UserID        *uint     `json:"user_id"`
+	Content       string    `json:"content"`
+	ContentFormat string    `json:"content_format"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// PostRecord là đơn vị export/import: một post cùng toàn bộ comment của nó
+type PostRecord struct {
+	ID            uint            `json:"id"`
+	UserID        *uint           `json:"user_id"`
+	Title         string          `json:"title"`
+	Content       string          `json:"content"`
+	ContentFormat string          `json:"content_format"`
+	Status        string          `json:"status,omitempty"`
+	PublishAt     *time.Time      `json:"publish_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	Comments      []CommentRecord `json:"comments"`
+}
+
+func newPostRecord(post models.Post) PostRecord {
+	rec := PostRecord{
+		ID:            post.ID,
+		UserID:        post.UserID,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		Status:        post.Status,
+		PublishAt:     post.PublishAt,
+		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
+		Comments:      make([]CommentRecord, 0, len(post.Comments)),
+	}
+	for _, comment := range post.Comments {
+		rec.Comments = append(rec.Comments, CommentRecord{
+			ID:            comment.ID,
+			PostID:        comment.PostID,
+			ParentID:      comment.ParentID,
+			UserID:        comment.UserID,
+			Content:       comment.Content,
+			ContentFormat: comment.ContentFormat,
+			CreatedAt:     comment.CreatedAt,
+			UpdatedAt:     comment.UpdatedAt,
+		})
+	}
+	return rec
+}
+
+func validFormatField(format string) bool {
+	return format == "" || format == "plain" || format == "markdown"
+}
+
+func (rec *PostRecord) validate() error {
+	if rec.Title == "" && rec.Content == "" {
+		return fmt.Errorf("post must have a title or content")
+	}
+	if !validFormatField(rec.ContentFormat) {
+		return fmt.Errorf("invalid content_format %q", rec.ContentFormat)
+	}
+	if rec.Status != "" && !models.ValidPostStatus(rec.Status) {
+		return fmt.Errorf("invalid status %q", rec.Status)
+	}
+	if rec.Status == models.PostScheduled && rec.PublishAt == nil {
+		return fmt.Errorf("scheduled post must have a publish_at")
+	}
+	for i, comment := range rec.Comments {
+		if comment.Content == "" {
+			return fmt.Errorf("comment %d: content cannot be empty", i)
+		}
+		if !validFormatField(comment.ContentFormat) {
+			return fmt.Errorf("comment %d: invalid content_format %q", i, comment.ContentFormat)
+		}
+		if comment.PostID != 0 && rec.ID != 0 && comment.PostID != rec.ID {
+			return fmt.Errorf("comment %d: post_id %d does not match post id %d", i, comment.PostID, rec.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"social_media_server/config"
+	"social_media_server/transfer"
+)
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", transfer.FormatNDJSON, "output format: ndjson, json or csv")
+	output := fs.String("o", "-", "output file, - for stdout")
+	fs.Parse(args)
+
+	if !transfer.ValidFormat(*format) {
+		log.Fatalf("Invalid format %q, expected ndjson, json or csv", *format)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Could not create output file: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	config.ConnectDB()
+
+	count, err := transfer.Export(context.Background(), config.DB, w, *format)
+	if err != nil {
+		log.Fatalf("Export failed after %d posts: %v", count, err)
+	}
+	log.Printf("Exported %d posts", count)
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", transfer.FormatNDJSON, "input format: ndjson, json or csv")
+	preserveIDs := fs.Bool("preserve-ids", false, "keep IDs from the file instead of assigning new ones")
+	preserveTimestamps := fs.Bool("preserve-timestamps", false, "keep created_at/updated_at from the file")
+	batchSize := fs.Int("batch-size", transfer.DefaultBatchSize, "number of posts per transaction")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: social_media_server import [flags] <file|->")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if !transfer.ValidFormat(*format) {
+		log.Fatalf("Invalid format %q, expected ndjson, json or csv", *format)
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("Could not open input file: %v", err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	config.ConnectDB()
+
+	report, err := transfer.Import(context.Background(), config.DB, r, *format, transfer.ImportOptions{
+		PreserveIDs:        *preserveIDs,
+		PreserveTimestamps: *preserveTimestamps,
+		BatchSize:          *batchSize,
+	})
+	if report != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+	if err != nil {
+		log.Fatalf("Import aborted: %v", err)
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"social_media_server/audit"
+	"social_media_server/config"
+	"social_media_server/middleware"
+	"social_media_server/models"
+
+	"gorm.io/gorm"
+)
+
+// Audit log của lệnh CLI không có người thực hiện; Method/Path ghi lại lệnh đã chạy
+const auditMethodCLI = "CLI"
+
+func runCreateUser(args []string) {
+	fs := flag.NewFlagSet("create-user", flag.ExitOnError)
+	displayName := fs.String("display-name", "", "display name (defaults to the username)")
+	role := fs.String("role", models.RoleUser, "role: user, moderator or admin")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: social_media_server create-user [flags] <username>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	username := fs.Arg(0)
+	if !models.ValidUsername(username) {
+		log.Fatalf("Invalid username %q, expected 3-32 letters, digits or underscores", username)
+	}
+	if !models.ValidRole(*role) {
+		log.Fatalf("Invalid role %q, expected user, moderator or admin", *role)
+	}
+
+	config.ConnectDB()
+
+	var existing int64
+	if err := config.DB.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&existing).Error; err != nil {
+		log.Fatalf("Could not check username: %v", err)
+	}
+	if existing > 0 {
+		log.Fatalf("Username %q is already taken", username)
+	}
+
+	apiKey, hash, err := middleware.NewAPIKey()
+	if err != nil {
+		log.Fatalf("Could not generate API key: %v", err)
+	}
+	user := models.User{Username: username, DisplayName: *displayName, APIKeyHash: hash, Role: *role}
+	if user.DisplayName == "" {
+		user.DisplayName = user.Username
+	}
+	if err := config.DB.Create(&user).Error; err != nil {
+		log.Fatalf("Could not create user: %v", err)
+	}
+	recordCLIAudit("create-user", models.AuditCreate, user.ID, nil, user)
+
+	log.Printf("Created user %s (id %d, role %s)", user.Username, user.ID, user.Role)
+	fmt.Println(apiKey)
+}
+
+func runGrantRole(args []string) {
+	fs := flag.NewFlagSet("grant-role", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: social_media_server grant-role <username> <user|moderator|admin>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	username, role := fs.Arg(0), fs.Arg(1)
+	if !models.ValidRole(role) {
+		log.Fatalf("Invalid role %q, expected user, moderator or admin", role)
+	}
+
+	config.ConnectDB()
+
+	var user models.User
+	if err := config.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Fatalf("User %q not found", username)
+		}
+		log.Fatalf("Could not retrieve user: %v", err)
+	}
+	if user.Role == role {
+		log.Printf("User %s already has role %s", user.Username, role)
+		return
+	}
+	before := user
+	if err := config.DB.Model(&user).Update("role", role).Error; err != nil {
+		log.Fatalf("Could not update role: %v", err)
+	}
+	recordCLIAudit("grant-role", models.AuditUpdate, user.ID, before, user)
+	log.Printf("User %s now has role %s", user.Username, role)
+}
+
+func recordCLIAudit(command, action string, userID uint, before, after interface{}) {
+	entry := models.AuditLog{
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   userID,
+		Before:       audit.Snapshot(before),
+		After:        audit.Snapshot(after),
+		Method:       auditMethodCLI,
+		Path:         command,
+	}
+	if err := audit.Record(context.Background(), config.DB, &entry); err != nil {
+		log.Printf("Failed to write audit log for %s: %v", command, err)
+	}
+}
@@ -7,6 +7,8 @@ interface Comment {
   UpdatedAt: string;
   DeletedAt: string | null;
   content: string;
+  content_format: "plain" | "markdown";
+  content_html: string;
   post_id: number;
 }
 
@@ -17,6 +19,8 @@ interface Post {
   DeletedAt: string | null;
   title: string;
   content: string;
+  content_format: "plain" | "markdown";
+  content_html: string;
   comments: Comment[];
 }
 
@@ -26,6 +30,7 @@ function App() {
   const [posts, setPosts] = useState<Post[]>([]);
   const [newPostTitle, setNewPostTitle] = useState("");
   const [newPostContent, setNewPostContent] = useState("");
+  const [newPostMarkdown, setNewPostMarkdown] = useState(false);
   const [isLoading, setIsLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
   const [selectedPost, setSelectedPost] = useState<Post | null>(null); 
@@ -66,7 +71,11 @@ function App() {
         headers: {
           "Content-Type": "application/json",
         },
-        body: JSON.stringify({ title: newPostTitle, content: newPostContent }),
+        body: JSON.stringify({
+          title: newPostTitle,
+          content: newPostContent,
+          content_format: newPostMarkdown ? "markdown" : "plain",
+        }),
       });
       if (!response.ok) {
         const errorData = await response.json();
@@ -77,6 +86,7 @@ function App() {
       // const createdPost: Post = await response.json(); // Dữ liệu post vừa tạo
       setNewPostTitle("");
       setNewPostContent("");
+      setNewPostMarkdown(false);
       fetchPosts(); 
     } catch (e: any) {
       setError(e.message || "Failed to create post.");
@@ -125,6 +135,17 @@ function App() {
                 required
               />
             </div>
+            <div>
+              <label htmlFor="postMarkdown">
+                <input
+                  type="checkbox"
+                  id="postMarkdown"
+                  checked={newPostMarkdown}
+                  onChange={(e) => setNewPostMarkdown(e.target.checked)}
+                />{" "}
+                Markdown
+              </label>
+            </div>
             <button type="submit" disabled={isLoading}>
               {isLoading ? "Creating..." : "Create Post"}
             </button>
@@ -170,7 +191,11 @@ function App() {
               ×
             </button>
             <h2>{selectedPost.title}</h2>
-            <p className="post-full-content">{selectedPost.content}</p>
+            {/* content_html đã được server sanitize nên có thể chèn trực tiếp */}
+            <div
+              className="post-full-content"
+              dangerouslySetInnerHTML={{ __html: selectedPost.content_html }}
+            />
             <hr />
             <h4>
               Comments (
@@ -180,7 +205,9 @@ function App() {
               <ul className="comments-list">
                 {selectedPost.comments.map((comment) => (
                   <li key={comment.ID} className="comment-item">
-                    <p>{comment.content}</p>
+                    <div
+                      dangerouslySetInnerHTML={{ __html: comment.content_html }}
+                    />
                     <small>
                       Commented on:{" "}
                       {new Date(comment.CreatedAt).toLocaleString()}